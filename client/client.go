@@ -0,0 +1,115 @@
+// Package client holds the algod, kmd and indexer clients shared by
+// everything talking to the network, along with a cache of algod's
+// suggested params so that building large groups doesn't make a round trip
+// per transaction.
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/client/kmd"
+	"github.com/algorand/go-algorand-sdk/client/v2/algod"
+	"github.com/algorand/go-algorand-sdk/client/v2/indexer"
+	"github.com/algorand/go-algorand-sdk/types"
+)
+
+// DefaultParamsTTL is how long suggested params are cached for by default
+const DefaultParamsTTL = 5 * time.Second
+
+// Config holds the hosts and tokens of each client. Clients whose host is
+// empty aren't created.
+type Config struct {
+	AlgodHost string
+	AlgodToken string
+	KMDHost string
+	KMDToken string
+	IndexerHost string
+	IndexerToken string
+
+	// ParamsTTL is how long suggested params are cached for. Zero uses
+	// DefaultParamsTTL and a negative TTL disables caching.
+	ParamsTTL time.Duration
+}
+
+// Context is a set of clients shared between callers. It is safe for
+// concurrent use.
+type Context struct {
+	Algod *algod.Client
+	KMD kmd.Client
+	Indexer *indexer.Client
+
+	paramsTTL time.Duration
+	now func() time.Time
+
+	mu sync.Mutex
+	params types.SuggestedParams
+	paramsAt time.Time
+}
+
+// New creates the clients in config
+func New(config Config) (*Context, error) {
+
+	c := &Context{
+		paramsTTL: config.ParamsTTL,
+		now: time.Now,
+	}
+	if c.paramsTTL == 0 {
+		c.paramsTTL = DefaultParamsTTL
+	}
+
+	var err error
+	if config.AlgodHost != "" {
+		c.Algod, err = algod.MakeClient(config.AlgodHost, config.AlgodToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.KMDHost != "" {
+		c.KMD, err = kmd.MakeClient(config.KMDHost, config.KMDToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.IndexerHost != "" {
+		c.Indexer, err = indexer.MakeClient(config.IndexerHost, config.IndexerToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// SuggestedParams returns algod's suggested params, fetching them only if
+// the cached ones are older than the TTL
+func (c *Context) SuggestedParams(ctx context.Context) (types.SuggestedParams, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paramsTTL > 0 && !c.paramsAt.IsZero() && c.now().Sub(c.paramsAt) < c.paramsTTL {
+		return c.params, nil
+	}
+
+	params, err := c.Algod.SuggestedParams().Do(ctx)
+	if err != nil {
+		return types.SuggestedParams{}, err
+	}
+
+	c.params = params
+	c.paramsAt = c.now()
+	return params, nil
+}
+
+// InvalidateParams drops the cached suggested params, e.g. once a group
+// has been confirmed and the round has moved on, so that identical
+// transactions built afterwards get a different validity window and so a
+// different ID.
+func (c *Context) InvalidateParams() {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.paramsAt = time.Time{}
+}
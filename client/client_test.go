@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeAlgod returns a context whose algod serves suggested params with
+// an increasing last round, counting the requests made
+func newFakeAlgod(t *testing.T, ttl time.Duration) (*Context, *uint64) {
+
+	var requests uint64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v2/transactions/params", r.URL.Path)
+		n := atomic.AddUint64(&requests, 1)
+		json.NewEncoder(w).Encode(models.TransactionParametersResponse{
+			LastRound: n,
+			MinFee: 1000,
+			GenesisId: "sandnet-v1",
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(Config{
+		AlgodHost: srv.URL,
+		ParamsTTL: ttl,
+	})
+	require.NoError(t, err)
+	return c, &requests
+}
+
+func TestSuggestedParamsCached(t *testing.T) {
+
+	c, requests := newFakeAlgod(t, time.Minute)
+	now := time.Unix(1_000_000, 0)
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 50; i++ {
+		params, err := c.SuggestedParams(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), uint64(params.FirstRoundValid))
+	}
+	require.Equal(t, uint64(1), atomic.LoadUint64(requests))
+
+	// Expires after the TTL
+	now = now.Add(time.Minute)
+	params, err := c.SuggestedParams(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), uint64(params.FirstRoundValid))
+
+	// And when invalidated
+	c.InvalidateParams()
+	params, err = c.SuggestedParams(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), uint64(params.FirstRoundValid))
+	require.Equal(t, uint64(3), atomic.LoadUint64(requests))
+}
+
+func TestSuggestedParamsCachingDisabled(t *testing.T) {
+
+	c, requests := newFakeAlgod(t, -1)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.SuggestedParams(context.Background())
+		require.NoError(t, err)
+	}
+	require.Equal(t, uint64(3), atomic.LoadUint64(requests))
+}
+
+func TestNewOnlyCreatesConfiguredClients(t *testing.T) {
+
+	c, err := New(Config{IndexerHost: "http://localhost:4003"})
+	require.NoError(t, err)
+	require.Nil(t, c.Algod)
+	require.NotNil(t, c.Indexer)
+	require.Equal(t, DefaultParamsTTL, c.paramsTTL)
+}
@@ -0,0 +1,125 @@
+// Command algokeno-api serves the history of lotto apps as JSON over HTTP:
+// their rounds, tickets and winners, and each player's tickets.
+//
+// It indexes the apps into its own store under -data_dir, and keeps it up to
+// date by following new blocks. Restarting it picks up from the last round
+// it indexed. Syncing errors, e.g. while algod or the indexer are down, are
+// logged and retried after -retry_interval.
+//
+// By default it indexes every lotto app from the first round, searching
+// every app call. On a long chain, -start_round skips the rounds before the
+// first app, and -creators limits it to the apps of those accounts so that
+// only their txns and the calls to their apps are searched.
+//
+//	algokeno-api -addr :8080 -data_dir algokeno-api-data
+//	curl localhost:8080/rounds/86/winners
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno/client"
+	"github.com/neurotempest/algokeno/history"
+)
+
+var (
+	algodHost = flag.String("algod_host", "http://localhost:4001", "Host of algod client")
+	algodTokenPath = flag.String("algod_token_path", "algorand/algod.token", "Path to algod token")
+	indexerHost = flag.String("indexer_host", "http://localhost:4003", "Host of indexer client")
+	addr = flag.String("addr", ":8080", "Address to serve HTTP on")
+	dataDir = flag.String("data_dir", "algokeno-api-data", "Directory of the history store")
+	batchRounds = flag.Uint64("batch_rounds", history.DefaultBatchRounds, "Most rounds indexed in one batch while catching up")
+	retryInterval = flag.Duration("retry_interval", 5*time.Second, "How long to wait before retrying after a syncing error")
+	startRound = flag.Uint64("start_round", 0, "Round to start indexing from when the store is empty")
+	creators = flag.String("creators", "", "Comma separated addresses of the only creators whose apps are indexed, or empty for all")
+)
+
+func main() {
+
+	flag.Parse()
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+
+	algodToken, err := os.ReadFile(*algodTokenPath)
+	if err != nil {
+		return err
+	}
+
+	cl, err := client.New(client.Config{
+		AlgodHost: *algodHost,
+		AlgodToken: strings.TrimSpace(string(algodToken)),
+		IndexerHost: *indexerHost,
+	})
+	if err != nil {
+		return err
+	}
+
+	store, err := history.Open(*dataDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	follower := history.NewFollower(cl, store)
+	follower.BatchRounds = *batchRounds
+	follower.StartRound = *startRound
+	for _, c := range strings.Split(*creators, ",") {
+		if c == "" {
+			continue
+		}
+		addr, err := types.DecodeAddress(strings.TrimSpace(c))
+		if err != nil {
+			return fmt.Errorf("creator %q: %w", c, err)
+		}
+		follower.Creators = append(follower.Creators, addr)
+	}
+	go follow(ctx, follower)
+
+	srv := &http.Server{Addr: *addr, Handler: history.NewHandler(store)}
+	go func() {
+
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	log.Printf("serving on %s, indexed up to round %d", *addr, store.LastRound())
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// follow runs f until ctx is done, retrying after errors
+func follow(ctx context.Context, f *history.Follower) {
+
+	for {
+		err := f.Run(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("following blocks: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(*retryInterval):
+		}
+	}
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/algorand/go-algorand-sdk/abi"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported by the generator")
+	ErrNoMethods = errors.New("app spec has no methods")
+)
+
+// AppSpec is the part of an ARC-32 app spec the client is generated from
+type AppSpec struct {
+	// Hints are keyed by method signature
+	Hints map[string]Hint `json:"hints"`
+	State struct {
+		Global Schema `json:"global"`
+		Local Schema `json:"local"`
+	} `json:"state"`
+	Contract abi.Contract `json:"contract"`
+	// BareCallConfig is keyed by on completion, e.g. "opt_in"
+	BareCallConfig map[string]string `json:"bare_call_config"`
+}
+
+// Hint holds how a method can be called: its call config, keyed by on
+// completion, is "CALL", "CREATE" or "ALL"
+type Hint struct {
+	CallConfig map[string]string `json:"call_config"`
+}
+
+type Schema struct {
+	NumByteSlices uint64 `json:"num_byte_slices"`
+	NumUints uint64 `json:"num_uints"`
+}
+
+// ParseAppSpec parses the ARC-32 app spec b
+func ParseAppSpec(b []byte) (AppSpec, error) {
+
+	var spec AppSpec
+	if err := json.Unmarshal(b, &spec); err != nil {
+		return AppSpec{}, err
+	}
+	if len(spec.Contract.Methods) == 0 {
+		return AppSpec{}, ErrNoMethods
+	}
+	return spec, nil
+}
+
+// onCompletions maps the on completions of call configs to the SDK's
+var onCompletions = map[string]string{
+	"no_op": "types.NoOpOC",
+	"opt_in": "types.OptInOC",
+	"close_out": "types.CloseOutOC",
+	"delete_application": "types.DeleteApplicationOC",
+}
+
+// bareNames are the names of the Client methods making bare calls
+var bareNames = map[string]string{
+	"no_op": "Bare",
+	"opt_in": "OptIn",
+	"close_out": "CloseOut",
+	"delete_application": "Delete",
+}
+
+type method struct {
+	GoName string
+	Signature string
+	Desc string
+	Create bool
+	OnCompletion string
+	Method abi.Method
+	Params []param
+}
+
+type param struct {
+	Name string
+	Type string
+}
+
+type bareCall struct {
+	GoName string
+	Name string
+	OnCompletion string
+}
+
+// Generate returns the gofmt-ed source of the client of spec, in package pkg
+func Generate(spec AppSpec, pkg string) ([]byte, error) {
+
+	data := struct {
+		Pkg string
+		Contract abi.Contract
+		State struct {
+			Global Schema `json:"global"`
+			Local Schema `json:"local"`
+		}
+		Methods []method
+		Bare []bareCall
+		Big bool
+	}{
+		Pkg: pkg,
+		Contract: spec.Contract,
+		State: spec.State,
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range spec.Contract.Methods {
+		gm, err := newMethod(m, spec.Hints[m.GetSignature()])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.GetSignature(), err)
+		}
+		// Overloads would need telling apart in Go names
+		if seen[gm.GoName] {
+			return nil, fmt.Errorf("%w: overloaded method %s", ErrUnsupported, m.Name)
+		}
+		seen[gm.GoName] = true
+
+		for _, p := range gm.Params {
+			data.Big = data.Big || strings.Contains(p.Type, "big.Int")
+		}
+		data.Methods = append(data.Methods, gm)
+	}
+
+	for oc, config := range spec.BareCallConfig {
+		name, ok := bareNames[oc]
+		if !ok || config != "CALL" {
+			return nil, fmt.Errorf("%w: bare %s call config %s", ErrUnsupported, oc, config)
+		}
+		data.Bare = append(data.Bare, bareCall{
+			GoName: name,
+			Name: oc,
+			OnCompletion: onCompletions[oc],
+		})
+	}
+	sort.Slice(data.Bare, func(i, j int) bool { return data.Bare[i].GoName < data.Bare[j].GoName })
+
+	var buf bytes.Buffer
+	if err := clientTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+func newMethod(m abi.Method, hint Hint) (method, error) {
+
+	res := method{
+		GoName: goName(m.Name, true),
+		Signature: m.GetSignature(),
+		Desc: m.Desc,
+		Method: m,
+	}
+
+	if !m.Returns.IsVoid() {
+		return method{}, fmt.Errorf("%w: return type %s", ErrUnsupported, m.Returns.Type)
+	}
+
+	// Each method is called with a single on completion, either creating
+	// the app or calling it once created
+	if len(hint.CallConfig) > 1 {
+		return method{}, fmt.Errorf("%w: call config %v", ErrUnsupported, hint.CallConfig)
+	}
+	res.OnCompletion = onCompletions["no_op"]
+	for oc, config := range hint.CallConfig {
+		var ok bool
+		res.OnCompletion, ok = onCompletions[oc]
+		if !ok || (config != "CALL" && config != "CREATE") {
+			return method{}, fmt.Errorf("%w: call config %s %s", ErrUnsupported, oc, config)
+		}
+		res.Create = config == "CREATE"
+	}
+
+	used := map[string]bool{"c": true, "atc": true, "opts": true, "approval": true, "clear": true}
+	for i, arg := range m.Args {
+		typ, err := goType(arg.Type)
+		if err != nil {
+			return method{}, err
+		}
+
+		name := goName(arg.Name, false)
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		if used[name] || token.IsKeyword(name) {
+			name += "Arg"
+		}
+		used[name] = true
+
+		res.Params = append(res.Params, param{
+			Name: name,
+			Type: typ,
+		})
+	}
+	return res, nil
+}
+
+// goType returns the Go type of values of the ABI arg type t
+func goType(t string) (string, error) {
+
+	switch {
+	case abi.IsTransactionType(t):
+		return "future.TransactionWithSigner", nil
+	case t == abi.AccountReferenceType:
+		return "types.Address", nil
+	case abi.IsReferenceType(t):
+		return "uint64", nil
+	}
+
+	if _, err := abi.TypeOf(t); err != nil {
+		return "", err
+	}
+
+	switch {
+	case t == "byte[]":
+		return "[]byte", nil
+	case strings.HasSuffix(t, "[]"):
+		elem, err := goType(strings.TrimSuffix(t, "[]"))
+		return "[]" + elem, err
+	case strings.HasSuffix(t, "]"):
+		i := strings.LastIndex(t, "[")
+		elem, err := goType(t[:i])
+		return t[i:] + elem, err
+	case strings.HasPrefix(t, "("):
+		elems := splitTuple(t)
+		for _, e := range elems[1:] {
+			if e != elems[0] {
+				return "[]interface{}", nil
+			}
+		}
+		elem, err := goType(elems[0])
+		return fmt.Sprintf("[%d]%s", len(elems), elem), err
+	case strings.HasPrefix(t, "uint"), strings.HasPrefix(t, "ufixed"):
+		bits, err := strconv.Atoi(strings.SplitN(strings.TrimLeft(t, "ufixedint"), "x", 2)[0])
+		if err != nil {
+			return "", err
+		}
+		for _, size := range []int{8, 16, 32, 64} {
+			if bits <= size {
+				return fmt.Sprintf("uint%d", size), nil
+			}
+		}
+		return "*big.Int", nil
+	case t == "address":
+		return "types.Address", nil
+	case t == "byte", t == "bool", t == "string":
+		return t, nil
+	}
+	return "", fmt.Errorf("%w: type %s", ErrUnsupported, t)
+}
+
+// splitTuple splits the tuple type t into its element types
+func splitTuple(t string) []string {
+
+	inner := t[1:len(t)-1]
+	var elems []string
+	depth, start := 0, 0
+	for i, c := range inner {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				elems = append(elems, inner[start:i])
+				start = i+1
+			}
+		}
+	}
+	return append(elems, inner[start:])
+}
+
+// goName converts the snake_case name to CamelCase, or camelCase if it's
+// unexported
+func goName(name string, exported bool) string {
+
+	var b strings.Builder
+	for i, word := range strings.Split(name, "_") {
+		if word == "" {
+			continue
+		}
+		r := []rune(word)
+		if i > 0 || exported {
+			r[0] = unicode.ToUpper(r[0])
+		}
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+// comment wraps text as a doc comment of lines of at most 76 columns
+func comment(text string) string {
+
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > 73 {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	lines = append(lines, line)
+	return "// " + strings.Join(lines, "\n// ")
+}
+
+// lowerFirst lowers the first letter of a description, which follows the
+// method name in its doc comment
+func lowerFirst(s string) string {
+
+	r := []rune(s)
+	if len(r) > 1 && unicode.IsUpper(r[0]) && !unicode.IsUpper(r[1]) {
+		r[0] = unicode.ToLower(r[0])
+	}
+	return string(r)
+}
+
+var clientTemplate = template.Must(template.New("client").Funcs(template.FuncMap{
+	"comment": comment,
+	"lowerFirst": lowerFirst,
+	"quote": strconv.Quote,
+}).Parse(`// Code generated by algokeno-bindgen. DO NOT EDIT.
+
+package {{.Pkg}}
+
+import (
+{{- if .Big}}
+	"math/big"
+{{end}}
+	"github.com/algorand/go-algorand-sdk/abi"
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+)
+
+// Signatures of the app's ARC-4 methods
+const (
+{{- range .Methods}}
+	{{.GoName}}Signature = {{quote .Signature}}
+{{- end}}
+)
+
+// The app's ARC-4 methods
+var (
+{{- range .Methods}}
+	Method{{.GoName}} = abi.Method{
+		Name: {{quote .Method.Name}},
+		{{- if .Desc}}
+		Desc: {{quote .Desc}},
+		{{- end}}
+		Args: []abi.Arg{
+		{{- range .Method.Args}}
+			{Name: {{quote .Name}}, Type: {{quote .Type}}},
+		{{- end}}
+		},
+		Returns: abi.Return{Type: {{quote .Method.Returns.Type}}},
+	}
+{{- end}}
+)
+
+// Contract is the app's ARC-4 contract
+var Contract = abi.Contract{
+	Name: {{quote .Contract.Name}},
+	{{- if .Contract.Desc}}
+	Desc: {{quote .Contract.Desc}},
+	{{- end}}
+	Methods: []abi.Method{
+	{{- range .Methods}}
+		Method{{.GoName}},
+	{{- end}}
+	},
+}
+
+// The app's global and local state schema
+var (
+	GlobalSchema = types.StateSchema{NumUint: {{.State.Global.NumUints}}, NumByteSlice: {{.State.Global.NumByteSlices}}}
+	LocalSchema = types.StateSchema{NumUint: {{.State.Local.NumUints}}, NumByteSlice: {{.State.Local.NumByteSlices}}}
+)
+
+// Client adds calls of the app AppID, sent by Sender and signed by Signer,
+// to atomic transaction composers
+type Client struct {
+	AppID uint64
+	Sender types.Address
+	Signer future.TransactionSigner
+}
+
+// CallOpts are the params of a single app call
+type CallOpts struct {
+	Params types.SuggestedParams
+	Note []byte
+}
+{{range .Methods}}
+{{if .Create -}}
+{{comment (printf "%s adds a call of %s to atc%s, from the approval and clear programs" .GoName .Signature (or (and .Desc (printf ", which %s" (lowerFirst .Desc))) ""))}}
+func (c Client) {{.GoName}}(atc *future.AtomicTransactionComposer, opts CallOpts, approval, clear []byte{{range .Params}}, {{.Name}} {{.Type}}{{end}}) error {
+
+	return atc.AddMethodCall(future.AddMethodCallParams{
+		Method: Method{{.GoName}},
+		MethodArgs: []interface{}{ {{- range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Name}}{{end -}} },
+		Sender: c.Sender,
+		SuggestedParams: opts.Params,
+		OnComplete: {{.OnCompletion}},
+		ApprovalProgram: approval,
+		ClearProgram: clear,
+		GlobalSchema: GlobalSchema,
+		LocalSchema: LocalSchema,
+		Note: opts.Note,
+		Signer: c.Signer,
+	})
+}
+{{- else -}}
+{{comment (printf "%s adds a call of %s to atc%s" .GoName .Signature (or (and .Desc (printf ", which %s" (lowerFirst .Desc))) ""))}}
+func (c Client) {{.GoName}}(atc *future.AtomicTransactionComposer, opts CallOpts{{range .Params}}, {{.Name}} {{.Type}}{{end}}) error {
+
+	return c.call(atc, opts, Method{{.GoName}}, {{.OnCompletion}}{{range .Params}}, {{.Name}}{{end}})
+}
+{{- end}}
+{{end}}
+{{- range .Bare}}
+// {{.GoName}} adds a bare {{.Name}} call to atc, which has no args
+func (c Client) {{.GoName}}(atc *future.AtomicTransactionComposer, opts CallOpts) error {
+
+	tx, err := future.MakeApplicationCallTx(
+		c.AppID,
+		nil,
+		nil,
+		nil,
+		nil,
+		{{.OnCompletion}},
+		nil,
+		nil,
+		types.StateSchema{},
+		types.StateSchema{},
+		opts.Params,
+		c.Sender,
+		opts.Note,
+		types.Digest{},
+		[32]byte{},
+		types.Address{},
+	)
+	if err != nil {
+		return err
+	}
+	return atc.AddTransaction(future.TransactionWithSigner{
+		Txn: tx,
+		Signer: c.Signer,
+	})
+}
+{{end}}
+func (c Client) call(
+	atc *future.AtomicTransactionComposer,
+	opts CallOpts,
+	method abi.Method,
+	onComplete types.OnCompletion,
+	args ...interface{},
+) error {
+
+	return atc.AddMethodCall(future.AddMethodCallParams{
+		AppID: c.AppID,
+		Method: method,
+		MethodArgs: args,
+		Sender: c.Sender,
+		SuggestedParams: opts.Params,
+		OnComplete: onComplete,
+		Note: opts.Note,
+		Signer: c.Signer,
+	})
+}
+`))
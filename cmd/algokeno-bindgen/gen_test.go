@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestGenerate checks the generated lotto client is up to date with the
+// app spec, i.e. `go generate ./lotto` has been run since it changed
+func TestGenerate(t *testing.T) {
+
+	b, err := os.ReadFile("../../contract/application.json")
+	require.NoError(t, err)
+
+	spec, err := ParseAppSpec(b)
+	require.NoError(t, err)
+
+	src, err := Generate(spec, "lotto")
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile("../../lotto/client.go")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(src))
+}
+
+func TestGenerateUnsupported(t *testing.T) {
+
+	testCases := []struct{
+		Name string
+		Spec string
+	}{
+		{
+			Name: "return value",
+			Spec: `{"contract": {"methods": [{"name": "m", "args": [], "returns": {"type": "uint64"}}]}}`,
+		},
+		{
+			Name: "overload",
+			Spec: `{"contract": {"methods": [
+				{"name": "m", "args": [], "returns": {"type": "void"}},
+				{"name": "m", "args": [{"type": "uint64"}], "returns": {"type": "void"}}
+			]}}`,
+		},
+		{
+			Name: "call config",
+			Spec: `{
+				"hints": {"m()void": {"call_config": {"no_op": "ALL"}}},
+				"contract": {"methods": [{"name": "m", "args": [], "returns": {"type": "void"}}]}
+			}`,
+		},
+		{
+			Name: "bare update",
+			Spec: `{
+				"contract": {"methods": [{"name": "m", "args": [], "returns": {"type": "void"}}]},
+				"bare_call_config": {"update_application": "CALL"}
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+
+			spec, err := ParseAppSpec([]byte(tc.Spec))
+			require.NoError(t, err)
+
+			_, err = Generate(spec, "p")
+			require.ErrorIs(t, err, ErrUnsupported)
+		})
+	}
+
+	_, err := ParseAppSpec([]byte(`{"contract": {"methods": []}}`))
+	require.ErrorIs(t, err, ErrNoMethods)
+}
+
+func TestGoType(t *testing.T) {
+
+	testCases := []struct{
+		Type string
+		Expected string
+	}{
+		{Type: "uint64", Expected: "uint64"},
+		{Type: "uint24", Expected: "uint32"},
+		{Type: "uint128", Expected: "*big.Int"},
+		{Type: "ufixed64x2", Expected: "uint64"},
+		{Type: "byte[]", Expected: "[]byte"},
+		{Type: "byte[32]", Expected: "[32]byte"},
+		{Type: "uint16[]", Expected: "[]uint16"},
+		{Type: "bool[3][]", Expected: "[][3]bool"},
+		{Type: "address", Expected: "types.Address"},
+		{Type: "string", Expected: "string"},
+		{Type: "(uint64,uint64)[]", Expected: "[][2]uint64"},
+		{Type: "(address,uint64)[]", Expected: "[][]interface{}"},
+		{Type: "((uint8,uint8),(uint8,uint8))", Expected: "[2][2]uint8"},
+		{Type: "pay", Expected: "future.TransactionWithSigner"},
+		{Type: "txn", Expected: "future.TransactionWithSigner"},
+		{Type: "account", Expected: "types.Address"},
+		{Type: "application", Expected: "uint64"},
+		{Type: "asset", Expected: "uint64"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Type, func(t *testing.T) {
+
+			actual, err := goType(tc.Type)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, actual)
+		})
+	}
+
+	_, err := goType("uint65")
+	require.Error(t, err)
+}
+
+func TestGoName(t *testing.T) {
+
+	require.Equal(t, "SetDrawAsset", goName("set_draw_asset", true))
+	require.Equal(t, "nextAppAddress", goName("next_app_address", false))
+	require.Equal(t, "beneficiary1", goName("beneficiary_1", false))
+	require.Equal(t, "Claim", goName("claim", true))
+}
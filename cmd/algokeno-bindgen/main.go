@@ -0,0 +1,62 @@
+// Command algokeno-bindgen generates a typed Go client of an app from its
+// ARC-32 app spec, which calls the app's ARC-4 methods through the SDK's
+// AtomicTransactionComposer.
+//
+// The client has a constant with the signature and a variable with the
+// abi.Method of each method, the app's ARC-4 contract and state schema, and
+// a Client whose methods add a call of each method (and of each bare call,
+// e.g. an opt in) to an AtomicTransactionComposer.
+//
+// ABI types map to Go types as the SDK's ABI encoding accepts them: uintN
+// to the smallest Go uint holding N bits (or *big.Int above 64), byte[] to
+// []byte, T[] and T[N] to slices and arrays of T, tuples whose elements
+// share a type to arrays and other tuples to []interface{}. Transaction args
+// are future.TransactionWithSigner, account args types.Address, and
+// application and asset args their uint64 ID.
+//
+//	algokeno-bindgen -spec contract/application.json -pkg lotto -out lotto/client.go
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+)
+
+var (
+	specPath = flag.String("spec", "contract/application.json", "Path to the app's ARC-32 app spec")
+	pkg = flag.String("pkg", "lotto", "Package of the generated client")
+	out = flag.String("out", "", "File to write the client to, or stdout if empty")
+)
+
+func main() {
+
+	flag.Parse()
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+
+	b, err := os.ReadFile(*specPath)
+	if err != nil {
+		return err
+	}
+
+	spec, err := ParseAppSpec(b)
+	if err != nil {
+		return err
+	}
+
+	src, err := Generate(spec, *pkg)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*out, src, 0644)
+}
@@ -0,0 +1,106 @@
+// Command algokeno-sponsor deposits into the prize pool of a lotto app,
+// guaranteeing the prize of a tier, or seeds the app's jackpot up to a
+// guaranteed minimum.
+//
+// The sponsoring account's mnemonic is read from $ALGOKENO_SPONSOR_MNEMONIC.
+//
+//	algokeno-sponsor -app 86 -tier 3 -amount 1000000 -note "acme corp"
+//	algokeno-sponsor -app 86 -jackpot_min 50000000 -note "house seed"
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/mnemonic"
+
+	"github.com/neurotempest/algokeno/client"
+	"github.com/neurotempest/algokeno/sponsor"
+)
+
+const mnemonicEnv = "ALGOKENO_SPONSOR_MNEMONIC"
+
+var (
+	algodHost = flag.String("algod_host", "http://localhost:4001", "Host of algod client")
+	algodTokenPath = flag.String("algod_token_path", "algorand/algod.token", "Path to algod token")
+	appID = flag.Uint64("app", 0, "ID of the lotto app to sponsor")
+	tier = flag.Int("tier", 0, "Tier to deposit into, i.e. the number of matching numbers")
+	amount = flag.Uint64("amount", 0, "Amount to deposit into -tier, in microalgos or the app's asset")
+	jackpotMin = flag.Uint64("jackpot_min", 0, "Seed the jackpot so its guaranteed prize is at least this, instead of depositing into -tier")
+	note = flag.String("note", "", "Note attributing the deposit")
+	timeout = flag.Duration("timeout", time.Minute, "How long to wait for the deposit to be confirmed")
+)
+
+func main() {
+
+	flag.Parse()
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+
+	if *appID == 0 {
+		return errors.New("-app is required")
+	}
+
+	acc, err := account()
+	if err != nil {
+		return err
+	}
+
+	algodToken, err := os.ReadFile(*algodTokenPath)
+	if err != nil {
+		return err
+	}
+
+	cl, err := client.New(client.Config{
+		AlgodHost: *algodHost,
+		AlgodToken: strings.TrimSpace(string(algodToken)),
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	s := sponsor.New(cl, acc)
+	if *jackpotMin > 0 {
+		deposited, err := s.SeedJackpot(ctx, *appID, *jackpotMin, *note)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("seeded jackpot of app %d with %d\n", *appID, deposited)
+		return nil
+	}
+
+	txID, err := s.Deposit(ctx, *appID, *tier, *amount, *note)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("deposited %d into tier %d of app %d in %s\n", *amount, *tier, *appID, txID)
+	return nil
+}
+
+func account() (crypto.Account, error) {
+
+	m := os.Getenv(mnemonicEnv)
+	if m == "" {
+		return crypto.Account{}, fmt.Errorf("$%s not set", mnemonicEnv)
+	}
+
+	key, err := mnemonic.ToPrivateKey(m)
+	if err != nil {
+		return crypto.Account{}, err
+	}
+	return crypto.AccountFromPrivateKey(key)
+}
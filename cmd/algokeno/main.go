@@ -0,0 +1,415 @@
+// Command algokeno plays lotto apps.
+//
+// The commit subcommand buys a ticket in an app, either with chosen numbers,
+// or with -quick-pick N, N distinct random tickets drawn with crypto/rand.
+// In bonus ball games the last of -numbers is the bonus number.
+//
+// Players' mnemonics are read from $ALGOKENO_MNEMONICS, one per line. An
+// account holds a single ticket per app, so N quick picks take N of them.
+//
+// The odds subcommand prints the odds of each tier of a game config, and
+// the return of a ticket given its prize pools, either in full (-pools) or
+// as basis point shares (-shares) of what's left of the tickets sold once
+// the house fee is paid. Both list the tiers of 1 to -picks matches, then
+// in bonus ball games the bonus tiers of 0 to -picks matches.
+//
+// The simulate subcommand simulates the rounds of a chain of apps, each
+// rolling over into the next, writing a CSV or JSON row per round to
+// stdout and a summary to stderr. Prizes are either -shares of each
+// round's pot, or fixed -prizes per winner with the rest in the jackpot.
+//
+//	algokeno commit -app 86 -numbers 3,14,15,41,59,63
+//	algokeno commit -app 86 -quick-pick 3 -wager 2000000
+//	algokeno odds -max_number 49 -tickets 1000000 -shares 0,0,1000,2000,2000,5000
+//	algokeno simulate -rounds 5000 -tickets 20000 -shares 0,0,1000,2000,2000,5000 > rounds.csv
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/mnemonic"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/client"
+	"github.com/neurotempest/algokeno/odds"
+	"github.com/neurotempest/algokeno/player"
+	"github.com/neurotempest/algokeno/sim"
+)
+
+const mnemonicsEnv = "ALGOKENO_MNEMONICS"
+
+const usage = `usage: algokeno <command> [flags]
+
+commands:
+  commit    buy tickets in a lotto app
+  odds      print the odds and ticket return of a game config
+  simulate  simulate the economics of many rounds of a game config
+`
+
+func main() {
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "commit":
+		err = commit(os.Args[2:])
+	case "odds":
+		err = printOdds(os.Args[2:])
+	case "simulate":
+		err = simulate(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func commit(args []string) error {
+
+	fs := flag.NewFlagSet("commit", flag.ExitOnError)
+	algodHost := fs.String("algod_host", "http://localhost:4001", "Host of algod client")
+	algodTokenPath := fs.String("algod_token_path", "algorand/algod.token", "Path to algod token")
+	appID := fs.Uint64("app", 0, "ID of the lotto app to buy tickets in")
+	numbers := fs.String("numbers", "", "Comma separated numbers of the ticket, the bonus number last in bonus ball games")
+	quickPick := fs.Int("quick-pick", 0, "Buy this many distinct random tickets instead of -numbers")
+	wager := fs.Uint64("wager", 0, "Wager of each ticket, in microalgos or the app's asset (default the app's ticket price)")
+	timeout := fs.Duration("timeout", time.Minute, "How long to wait for the tickets to be confirmed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *appID == 0 {
+		return errors.New("-app is required")
+	}
+	if (*numbers == "") == (*quickPick == 0) {
+		return errors.New("one of -numbers or -quick-pick is required")
+	}
+
+	accs, err := accounts()
+	if err != nil {
+		return err
+	}
+
+	algodToken, err := os.ReadFile(*algodTokenPath)
+	if err != nil {
+		return err
+	}
+
+	cl, err := client.New(client.Config{
+		AlgodHost: *algodHost,
+		AlgodToken: strings.TrimSpace(string(algodToken)),
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	config, err := player.New(cl, accs[0]).Config(ctx, *appID)
+	if err != nil {
+		return err
+	}
+
+	var tickets []algokeno.Commitment
+	if *quickPick > 0 {
+		tickets, err = algokeno.QuickPicks(config, *quickPick)
+	} else {
+		var c algokeno.Commitment
+		c, err = parseNumbers(config, *numbers)
+		tickets = []algokeno.Commitment{c}
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(tickets) > len(accs) {
+		return fmt.Errorf("%d tickets but %d accounts in $%s", len(tickets), len(accs), mnemonicsEnv)
+	}
+
+	for i, c := range tickets {
+		txID, err := player.New(cl, accs[i]).Commit(ctx, *appID, c, *wager)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s bought %v in app %d in %s\n", accs[i].Address, c.Numbers(), *appID, txID)
+	}
+	return nil
+}
+
+func printOdds(args []string) error {
+
+	fs := flag.NewFlagSet("odds", flag.ExitOnError)
+	gameConfig := gameFlags(fs)
+	tickets := fs.Uint64("tickets", 1, "Number of tickets sold, sharing the pools of the tiers they win")
+	pools := fs.String("pools", "", "Comma separated prize pool of each tier")
+	shares := fs.String("shares", "", "Comma separated share of each tier, in basis points, instead of -pools")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := gameConfig()
+	if err != nil {
+		return err
+	}
+
+	tierProbs, bonusProbs := odds.Tiers(config), odds.BonusTiers(config)
+	for i, p := range tierProbs {
+		printTier(fmt.Sprintf("%d", i+1), p)
+	}
+	for i, p := range bonusProbs {
+		printTier(fmt.Sprintf("%d + bonus", i), p)
+	}
+
+	if (*pools == "") == (*shares == "") {
+		return nil
+	}
+
+	list := *pools
+	if list == "" {
+		list = *shares
+	}
+
+	tierPools, bonusPools, err := parseTiers(config, list)
+	if err != nil {
+		return err
+	}
+
+	if *shares != "" {
+		tierPools, bonusPools, err = odds.Pools(config, *tickets, tierPools, bonusPools)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("pools %v, bonus pools %v\n", tierPools, bonusPools)
+	}
+
+	ret, err := odds.TicketReturn(config, *tickets, tierPools, bonusPools)
+	if err != nil {
+		return err
+	}
+
+	jackpot, err := odds.BreakEvenJackpot(config, *tickets, tierPools, bonusPools)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("EV %.2f, std. dev. %.2f, house edge %.2f%%\n", ret.EV, math.Sqrt(ret.Variance), ret.HouseEdge*100)
+	fmt.Printf("break-even jackpot %d\n", jackpot)
+	return nil
+}
+
+func simulate(args []string) error {
+
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	gameConfig := gameFlags(fs)
+	rounds := fs.Int("rounds", 1000, "Number of rounds to simulate")
+	seed := fs.Int64("seed", 1, "Seed of the simulation's randomness")
+	sales := fs.String("sales", "poisson", "Distribution of the tickets sold a round: fixed, uniform or poisson")
+	tickets := fs.Uint64("tickets", 10_000, "Tickets sold a round: the number of fixed, the mean of poisson, or the min of uniform")
+	ticketsMax := fs.Uint64("tickets_max", 20_000, "Max tickets sold a round of uniform")
+	rolloverTickets := fs.Float64("rollover_tickets", 0, "Extra tickets sold a round per unit rolled over into it")
+	shares := fs.String("shares", "", "Comma separated share of the pot of each tier, in basis points")
+	prizes := fs.String("prizes", "", "Comma separated fixed prize of each tier's winners, the rest of the pot going to the jackpot, instead of -shares")
+	format := fs.String("format", "csv", "Output format: csv or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := gameConfig()
+	if err != nil {
+		return err
+	}
+
+	var s sim.Sales
+	switch *sales {
+	case "fixed":
+		s = sim.FixedSales(*tickets)
+	case "uniform":
+		s = sim.UniformSales(*tickets, *ticketsMax)
+	case "poisson":
+		s = sim.PoissonSales(float64(*tickets))
+	default:
+		return fmt.Errorf("unknown -sales %q", *sales)
+	}
+	if *rolloverTickets > 0 {
+		s = sim.RolloverSales(s, *rolloverTickets)
+	}
+
+	if (*shares == "") == (*prizes == "") {
+		return errors.New("one of -shares or -prizes is required")
+	}
+
+	var policy sim.Policy
+	if *shares != "" {
+		tiers, bonusTiers, err := parseTiers(config, *shares)
+		if err != nil {
+			return err
+		}
+		policy = sim.SharePolicy(tiers, bonusTiers)
+	} else {
+		tiers, bonusTiers, err := parseTiers(config, *prizes)
+		if err != nil {
+			return err
+		}
+		policy = sim.FixedPolicy(tiers, bonusTiers)
+	}
+
+	res, err := sim.Run(sim.Config{
+		Game: config,
+		Rounds: *rounds,
+		Sales: s,
+		Policy: policy,
+		Seed: *seed,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "csv":
+		err = sim.WriteCSV(os.Stdout, res)
+	case "json":
+		err = sim.WriteJSON(os.Stdout, res)
+	default:
+		err = fmt.Errorf("unknown -format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, sim.Summarize(res))
+	return nil
+}
+
+// gameFlags defines the flags of a game config on fs, returning a func
+// which validates and returns the config once fs is parsed
+func gameFlags(fs *flag.FlagSet) func() (algokeno.GameConfig, error) {
+
+	def := algokeno.DefaultGameConfig
+	picks := fs.Int("picks", def.Picks, "Numbers making up a ticket")
+	maxNumber := fs.Int("max_number", def.MaxNumber, "Exclusive upper bound of the numbers")
+	bonusMax := fs.Int("bonus_max", def.BonusMax, "Exclusive upper bound of the bonus number, or 0 for no bonus ball")
+	price := fs.Uint64("price", def.TicketPrice, "Ticket price, in microalgos or the app's asset")
+	feeBps := fs.Uint64("fee_bps", def.HouseFeeBps, "House fee in basis points")
+	rolloverThreshold := fs.Uint64("rollover_threshold", def.RolloverThreshold, "Amount the rollover has to exceed to be sent to the next app")
+
+	return func() (algokeno.GameConfig, error) {
+
+		config := def
+		config.Picks, config.MaxNumber, config.BonusMax = *picks, *maxNumber, *bonusMax
+		config.TicketPrice, config.HouseFeeBps = *price, *feeBps
+		config.RolloverThreshold = *rolloverThreshold
+		return config, config.Validate()
+	}
+}
+
+// parseTiers returns a comma separated list of an amount per tier of an
+// app created with config, of tiers 1 to config.Picks followed in a bonus
+// game by bonus tiers 0 to config.Picks, split into the tiers and the bonus
+// tiers
+func parseTiers(config algokeno.GameConfig, s string) ([]uint64, []uint64, error) {
+
+	list, err := parseList(s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	numTiers := config.Picks
+	if config.HasBonus() {
+		numTiers += config.Picks + 1
+	}
+	if len(list) != numTiers {
+		return nil, nil, fmt.Errorf("%w: %d of %d", odds.ErrPoolsLength, len(list), numTiers)
+	}
+
+	if !config.HasBonus() {
+		return list, nil, nil
+	}
+	return list[:config.Picks], list[config.Picks:], nil
+}
+
+func printTier(name string, p *big.Rat) {
+
+	f, _ := p.Float64()
+	if f == 0 {
+		fmt.Printf("%-10s never\n", name)
+		return
+	}
+	fmt.Printf("%-10s 1 in %.2f (%s)\n", name, 1/f, p.RatString())
+}
+
+func parseList(s string) ([]uint64, error) {
+
+	var res []uint64
+	for _, n := range strings.Split(s, ",") {
+		u, err := strconv.ParseUint(strings.TrimSpace(n), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, u)
+	}
+	return res, nil
+}
+
+// parseNumbers returns the ticket of a comma separated list of numbers, the
+// last of which is the bonus number in a bonus ball game
+func parseNumbers(config algokeno.GameConfig, s string) (algokeno.Commitment, error) {
+
+	var c algokeno.Commitment
+	for _, n := range strings.Split(s, ",") {
+		u, err := strconv.ParseUint(strings.TrimSpace(n), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("parsing -numbers: %w", err)
+		}
+		c = append(c, uint8(u))
+	}
+
+	if err := c.ValidateFor(config); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func accounts() ([]crypto.Account, error) {
+
+	var accs []crypto.Account
+	for _, m := range strings.Split(os.Getenv(mnemonicsEnv), "\n") {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+
+		key, err := mnemonic.ToPrivateKey(m)
+		if err != nil {
+			return nil, err
+		}
+
+		acc, err := crypto.AccountFromPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		accs = append(accs, acc)
+	}
+
+	if len(accs) == 0 {
+		return nil, fmt.Errorf("$%s not set", mnemonicsEnv)
+	}
+	return accs, nil
+}
@@ -0,0 +1,306 @@
+package algokeno
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	// NumPicks is the number of numbers making up a ticket (and a draw) in
+	// DefaultGameConfig
+	NumPicks = 6
+
+	// MaxNumber is the exclusive upper bound of any number in a ticket in
+	// DefaultGameConfig
+	MaxNumber = 64
+
+	// BitmaskVersion is the version byte which starts a bitmask encoded
+	// commitment
+	BitmaskVersion = 1
+
+	// BitmaskLength is the length of a bitmask encoded commitment: the
+	// version byte followed by a big endian uint64
+	BitmaskLength = 9
+
+	// BitmaskMaxNumber is the exclusive upper bound of any number in a
+	// bitmask encoded commitment
+	BitmaskMaxNumber = 64
+
+	// SealedLength is the length of a sealed commitment, which is the
+	// sha512_256 hash of a ticket's numbers followed by a salt
+	SealedLength = sha512.Size256
+)
+
+var (
+	ErrCommitmentLength = errors.New("commitment has wrong length")
+	ErrCommitmentOrder = errors.New("commitment numbers are not strictly increasing")
+	ErrCommitmentRange = errors.New("commitment number out of range")
+)
+
+// Commitment is the encoding of a ticket (or a draw) as passed to the
+// contract. It's either GameConfig.Picks strictly increasing numbers, one
+// per byte, or bitmask encoded: BitmaskVersion followed by a big endian
+// uint64 with bit n set for number n. The bitmask encoding only holds
+// numbers below BitmaskMaxNumber, but lets matches be counted with a
+// popcount rather than by comparing numbers.
+//
+// In a bonus game (see GameConfig.BonusMax) either encoding is followed by
+// one more byte holding the bonus number.
+type Commitment []byte
+
+// Match is how a ticket matched a draw, which decides the tier it wins
+type Match struct {
+	// Numbers is how many of the ticket's picks were drawn
+	Numbers int
+
+	// Bonus is whether the ticket's bonus number was drawn, which only
+	// happens in a bonus game
+	Bonus bool
+}
+
+// NewCommitment builds a Commitment from nums and validates it against
+// DefaultGameConfig
+func NewCommitment(nums ...uint8) (Commitment, error) {
+
+	c := make(Commitment, len(nums))
+	copy(c, nums)
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewBitmaskCommitment builds a bitmask encoded Commitment from nums and
+// validates it against DefaultGameConfig
+func NewBitmaskCommitment(nums ...uint8) (Commitment, error) {
+
+	var bm uint64
+	for _, n := range nums {
+		if n >= BitmaskMaxNumber {
+			return nil, fmt.Errorf("%w: %d", ErrCommitmentRange, n)
+		}
+		if bm&(1<<n) != 0 {
+			return nil, fmt.Errorf("%w: %d repeated", ErrCommitmentOrder, n)
+		}
+		bm |= 1 << n
+	}
+
+	c := bitmaskCommitment(bm)
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate returns an error if the commitment would not be accepted by the
+// contract's `is_valid_commitment` in an app created with DefaultGameConfig
+func (c Commitment) Validate() error {
+
+	return c.ValidateFor(DefaultGameConfig)
+}
+
+// ValidateFor returns an error if the commitment would not be accepted by
+// the contract's `is_valid_ticket` in an app created with config
+func (c Commitment) ValidateFor(config GameConfig) error {
+
+	if config.HasBonus() {
+		bonus, ok := c.Bonus(config)
+		if !ok {
+			return fmt.Errorf("%w: 0 bytes", ErrCommitmentLength)
+		}
+		if int(bonus) >= config.BonusMax {
+			return fmt.Errorf("%w: bonus %d", ErrCommitmentRange, bonus)
+		}
+
+		picks := c.Picks(config)
+		config.BonusMax = 0
+		return picks.ValidateFor(config)
+	}
+
+	if c.IsBitmask() {
+		bm := c.Bitmap()
+		if n := bits.OnesCount64(bm); n != config.Picks {
+			return fmt.Errorf("%w: %d bits set", ErrCommitmentLength, n)
+		}
+		if bits.Len64(bm) > config.MaxNumber {
+			return fmt.Errorf("%w: %d", ErrCommitmentRange, bits.Len64(bm)-1)
+		}
+		return nil
+	}
+
+	if len(c) != config.Picks {
+		return fmt.Errorf("%w: %d bytes", ErrCommitmentLength, len(c))
+	}
+
+	for i := 1; i < len(c); i++ {
+		if c[i-1] >= c[i] {
+			return fmt.Errorf("%w: %d >= %d at index %d", ErrCommitmentOrder, c[i-1], c[i], i)
+		}
+	}
+
+	if len(c) == 0 {
+		return fmt.Errorf("%w: 0 bytes", ErrCommitmentLength)
+	}
+
+	if int(c[len(c)-1]) >= config.MaxNumber {
+		return fmt.Errorf("%w: %d", ErrCommitmentRange, c[len(c)-1])
+	}
+
+	return nil
+}
+
+// WithBonus returns c followed by the bonus number bonus, as a ticket or
+// draw of a bonus game
+func (c Commitment) WithBonus(bonus uint8) Commitment {
+
+	return append(append(Commitment(nil), c...), bonus)
+}
+
+// Picks returns c without its bonus number if config is a bonus game, in
+// the same way as the contract's `picks_of`
+func (c Commitment) Picks(config GameConfig) Commitment {
+
+	if !config.HasBonus() || len(c) == 0 {
+		return c
+	}
+	return c[:len(c)-1]
+}
+
+// Bonus returns the bonus number of c if config is a bonus game, and
+// whether it has one
+func (c Commitment) Bonus(config GameConfig) (uint8, bool) {
+
+	if !config.HasBonus() || len(c) == 0 {
+		return 0, false
+	}
+	return c[len(c)-1], true
+}
+
+// IsBitmask returns whether c is bitmask encoded, in the same way as the
+// contract's `is_bitmask`
+func (c Commitment) IsBitmask() bool {
+
+	return len(c) == BitmaskLength && c[0] == BitmaskVersion
+}
+
+// Bitmap returns the numbers in c as a bitmap, with bit n set for number n,
+// in the same way as the contract's `to_bitmap`. Numbers of
+// BitmaskMaxNumber and above are left out.
+func (c Commitment) Bitmap() uint64 {
+
+	if c.IsBitmask() {
+		return binary.BigEndian.Uint64(c[1:])
+	}
+
+	var bm uint64
+	for _, n := range c {
+		if n < BitmaskMaxNumber {
+			bm |= 1 << n
+		}
+	}
+	return bm
+}
+
+// Numbers returns the numbers in c in increasing order
+func (c Commitment) Numbers() []uint8 {
+
+	if !c.IsBitmask() {
+		return append([]uint8(nil), c...)
+	}
+
+	var nums []uint8
+	for bm := c.Bitmap(); bm != 0; bm &= bm - 1 {
+		nums = append(nums, uint8(bits.TrailingZeros64(bm)))
+	}
+	return nums
+}
+
+// ToBitmask returns c bitmask encoded, or an error if it holds a number
+// which can't be
+func (c Commitment) ToBitmask() (Commitment, error) {
+
+	if c.IsBitmask() {
+		return c, nil
+	}
+
+	for _, n := range c {
+		if n >= BitmaskMaxNumber {
+			return nil, fmt.Errorf("%w: %d", ErrCommitmentRange, n)
+		}
+	}
+	return bitmaskCommitment(c.Bitmap()), nil
+}
+
+// Matches returns how many numbers c has in common with draw. Both are
+// assumed to be valid (i.e. sorted without duplicates). If either is bitmask
+// encoded, matches are counted with a popcount, as a number which can't be
+// in the bitmap can't match.
+func (c Commitment) Matches(draw Commitment) int {
+
+	if c.IsBitmask() || draw.IsBitmask() {
+		return bits.OnesCount64(c.Bitmap() & draw.Bitmap())
+	}
+
+	var n int
+	i, j := 0, 0
+	for i < len(c) && j < len(draw) {
+		switch {
+		case c[i] == draw[j]:
+			n++
+			i++
+			j++
+		case c[i] < draw[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return n
+}
+
+// MatchFor returns how c matches draw in an app created with config, i.e.
+// how many picks they have in common and, in a bonus game, whether they have
+// the same bonus number
+func (c Commitment) MatchFor(config GameConfig, draw Commitment) Match {
+
+	cb, cok := c.Bonus(config)
+	db, dok := draw.Bonus(config)
+	return Match{
+		Numbers: c.Picks(config).Matches(draw.Picks(config)),
+		Bonus: cok && dok && cb == db,
+	}
+}
+
+func bitmaskCommitment(bm uint64) Commitment {
+
+	c := make(Commitment, BitmaskLength)
+	c[0] = BitmaskVersion
+	binary.BigEndian.PutUint64(c[1:], bm)
+	return c
+}
+
+// Seal returns the sealed commitment of c with salt, which is what a player
+// commits to keep their numbers hidden until they reveal them after the draw
+func Seal(c Commitment, salt []byte) Commitment {
+
+	h := sha512.Sum512_256(append(append([]byte(nil), c...), salt...))
+	return h[:]
+}
+
+// IsSealed returns whether c is a sealed commitment, which the contract
+// tells apart by its length alone
+func (c Commitment) IsSealed() bool {
+
+	return len(c) == SealedLength
+}
+
+// String returns the base64 encoding of the commitment, which is how it
+// appears in the app's global and local state
+func (c Commitment) String() string {
+
+	return base64.StdEncoding.EncodeToString(c)
+}
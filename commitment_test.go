@@ -0,0 +1,342 @@
+package algokeno
+
+import (
+	"crypto/sha512"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitmentValidate(t *testing.T) {
+
+	testCases := []struct{
+		Name string
+		Commitment Commitment
+		ExpectedErr error
+	}{
+		{
+			Name: "valid",
+			Commitment: Commitment{1, 2, 3, 4, 5, 6},
+		},
+		{
+			Name: "valid with 0 and 63",
+			Commitment: Commitment{0, 10, 20, 30, 40, 63},
+		},
+		{
+			Name: "not ordered",
+			Commitment: Commitment{1, 3, 2, 4, 5, 6},
+			ExpectedErr: ErrCommitmentOrder,
+		},
+		{
+			Name: "duplicate numbers",
+			Commitment: Commitment{1, 2, 3, 4, 8, 8},
+			ExpectedErr: ErrCommitmentOrder,
+		},
+		{
+			Name: "too short",
+			Commitment: Commitment{1, 2, 3, 4, 8},
+			ExpectedErr: ErrCommitmentLength,
+		},
+		{
+			Name: "too long",
+			Commitment: Commitment{1, 2, 3, 4, 5, 6, 7},
+			ExpectedErr: ErrCommitmentLength,
+		},
+		{
+			Name: "empty",
+			ExpectedErr: ErrCommitmentLength,
+		},
+		{
+			Name: "number greater than 63",
+			Commitment: Commitment{1, 2, 3, 4, 10, 64},
+			ExpectedErr: ErrCommitmentRange,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			err := test.Commitment.Validate()
+			if test.ExpectedErr != nil {
+				require.ErrorIs(t, err, test.ExpectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCommitmentValidateFor(t *testing.T) {
+
+	config := GameConfig{
+		Picks: 3,
+		MaxNumber: 10,
+		TicketPrice: 1,
+	}
+
+	require.NoError(t, Commitment{0, 5, 9}.ValidateFor(config))
+	require.ErrorIs(t, Commitment{0, 5, 10}.ValidateFor(config), ErrCommitmentRange)
+	require.ErrorIs(t, Commitment{1, 2, 3, 4, 5, 6}.ValidateFor(config), ErrCommitmentLength)
+	require.ErrorIs(t, Commitment{5, 0, 9}.ValidateFor(config), ErrCommitmentOrder)
+
+	config.Picks, config.MaxNumber = MaxPicks, MaxNumberLimit
+	require.NoError(t, Commitment{1, 2, 3, 4, 5, 6, 255}.ValidateFor(config))
+}
+
+func TestCommitmentMatches(t *testing.T) {
+
+	draw := Commitment{0, 10, 15, 20, 25, 63}
+
+	require.Equal(t, 6, draw.Matches(draw))
+	require.Equal(t, 5, Commitment{0, 5, 10, 15, 20, 25}.Matches(draw))
+	require.Equal(t, 3, Commitment{0, 10, 20, 30, 40, 50}.Matches(draw))
+	require.Equal(t, 3, Commitment{1, 10, 15, 25, 40, 50}.Matches(draw))
+	require.Equal(t, 4, Commitment{1, 10, 20, 25, 62, 63}.Matches(draw))
+	require.Equal(t, 0, Commitment{1, 2, 3, 4, 5, 6}.Matches(draw))
+}
+
+func TestBitmaskCommitment(t *testing.T) {
+
+	c, err := NewBitmaskCommitment(0, 10, 15, 20, 25, 63)
+	require.NoError(t, err)
+	require.Equal(t, Commitment{BitmaskVersion, 0x80, 0, 0, 0, 0x02, 0x10, 0x84, 0x01}, c)
+	require.True(t, c.IsBitmask())
+	require.Equal(t, []uint8{0, 10, 15, 20, 25, 63}, c.Numbers())
+
+	bytes := Commitment{0, 10, 15, 20, 25, 63}
+	require.False(t, bytes.IsBitmask())
+	require.Equal(t, c.Bitmap(), bytes.Bitmap())
+	converted, err := bytes.ToBitmask()
+	require.NoError(t, err)
+	require.Equal(t, c, converted)
+
+	_, err = NewBitmaskCommitment(1, 2, 3, 4, 5)
+	require.ErrorIs(t, err, ErrCommitmentLength)
+	_, err = NewBitmaskCommitment(1, 2, 3, 4, 5, 5)
+	require.ErrorIs(t, err, ErrCommitmentOrder)
+	_, err = NewBitmaskCommitment(1, 2, 3, 4, 5, 64)
+	require.ErrorIs(t, err, ErrCommitmentRange)
+
+	// Only version 1 is a bitmask, anything else is checked as bytes
+	c[0] = 2
+	require.False(t, c.IsBitmask())
+	require.ErrorIs(t, c.Validate(), ErrCommitmentLength)
+
+	// Bitmasks are checked against the app's config like bytes are
+	config := GameConfig{
+		Picks: 3,
+		MaxNumber: 10,
+		TicketPrice: 1,
+	}
+	c, err = Commitment{0, 5, 9}.ToBitmask()
+	require.NoError(t, err)
+	require.NoError(t, c.ValidateFor(config))
+	c, err = Commitment{0, 5, 10}.ToBitmask()
+	require.NoError(t, err)
+	require.ErrorIs(t, c.ValidateFor(config), ErrCommitmentRange)
+
+	// Numbers of 64 and above can't be bitmask encoded
+	_, err = Commitment{1, 2, 200}.ToBitmask()
+	require.ErrorIs(t, err, ErrCommitmentRange)
+	require.Equal(t, uint64(0b110), Commitment{1, 2, 200}.Bitmap())
+}
+
+func TestCommitmentMatchesMixedEncodings(t *testing.T) {
+
+	draw := Commitment{0, 10, 15, 20, 25, 63}
+	drawBits, err := draw.ToBitmask()
+	require.NoError(t, err)
+
+	for _, c := range []Commitment{
+		{0, 5, 10, 15, 20, 25},
+		{1, 10, 20, 25, 62, 63},
+		{1, 2, 3, 4, 5, 6},
+	} {
+		cBits, err := c.ToBitmask()
+		require.NoError(t, err)
+
+		expected := c.Matches(draw)
+		require.Equal(t, expected, cBits.Matches(draw), "%v", c.Numbers())
+		require.Equal(t, expected, c.Matches(drawBits), "%v", c.Numbers())
+		require.Equal(t, expected, cBits.Matches(drawBits), "%v", c.Numbers())
+	}
+
+	// Numbers which can't be in a bitmap can't match one
+	require.Equal(t, 2, Commitment{10, 15, 100}.Matches(drawBits))
+}
+
+func TestBonusCommitment(t *testing.T) {
+
+	config := GameConfig{
+		Picks: 5,
+		MaxNumber: 70,
+		TicketPrice: 1,
+		BonusMax: 26,
+	}
+
+	c := Commitment{1, 2, 3, 4, 5}.WithBonus(25)
+	require.NoError(t, c.ValidateFor(config))
+	require.Equal(t, Commitment{1, 2, 3, 4, 5}, c.Picks(config))
+	bonus, ok := c.Bonus(config)
+	require.True(t, ok)
+	require.Equal(t, uint8(25), bonus)
+
+	bits, err := Commitment{1, 2, 3, 4, 5}.ToBitmask()
+	require.NoError(t, err)
+	require.NoError(t, bits.WithBonus(0).ValidateFor(config))
+
+	require.ErrorIs(t, Commitment{1, 2, 3, 4, 5}.WithBonus(26).ValidateFor(config), ErrCommitmentRange)
+	require.ErrorIs(t, Commitment{1, 2, 3, 4, 5}.ValidateFor(config), ErrCommitmentLength)
+	require.ErrorIs(t, Commitment{}.ValidateFor(config), ErrCommitmentLength)
+	require.ErrorIs(t, Commitment{1, 2, 3, 5, 4}.WithBonus(0).ValidateFor(config), ErrCommitmentOrder)
+
+	// Without a bonus ball the last byte is just another pick
+	config.BonusMax = 0
+	require.Equal(t, c, c.Picks(config))
+	_, ok = c.Bonus(config)
+	require.False(t, ok)
+}
+
+func TestCommitmentMatchFor(t *testing.T) {
+
+	config := GameConfig{
+		Picks: 5,
+		MaxNumber: 70,
+		TicketPrice: 1,
+		BonusMax: 26,
+	}
+	draw := Commitment{10, 20, 30, 40, 50}.WithBonus(7)
+
+	testCases := []struct{
+		Name string
+		Ticket Commitment
+		Expected Match
+	}{
+		{
+			Name: "jackpot",
+			Ticket: draw,
+			Expected: Match{Numbers: 5, Bonus: true},
+		},
+		{
+			Name: "picks without the bonus",
+			Ticket: Commitment{10, 20, 30, 40, 50}.WithBonus(8),
+			Expected: Match{Numbers: 5},
+		},
+		{
+			Name: "bonus alone",
+			Ticket: Commitment{1, 2, 3, 4, 5}.WithBonus(7),
+			Expected: Match{Bonus: true},
+		},
+		{
+			Name: "bonus isn't a pick",
+			Ticket: Commitment{7, 20, 30, 41, 51}.WithBonus(1),
+			Expected: Match{Numbers: 2},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			require.Equal(t, test.Expected, test.Ticket.MatchFor(config, draw))
+
+			bits, err := test.Ticket.Picks(config).ToBitmask()
+			require.NoError(t, err)
+			bonus, _ := test.Ticket.Bonus(config)
+			require.Equal(t, test.Expected, bits.WithBonus(bonus).MatchFor(config, draw))
+		})
+	}
+}
+
+func TestSeal(t *testing.T) {
+
+	c := Commitment{1, 2, 3, 4, 5, 6}
+	salt := []byte("salt")
+
+	sealed := Seal(c, salt)
+	require.True(t, sealed.IsSealed())
+	require.False(t, c.IsSealed())
+	require.Equal(t, Commitment(sha512.New512_256().Sum(nil)), Seal(nil, nil))
+	require.Equal(t, sealed, Seal(Commitment{1, 2, 3}, []byte{4, 5, 6, 's', 'a', 'l', 't'}))
+	require.NotEqual(t, sealed, Seal(c, []byte("pepper")))
+
+	// Sealed commitments aren't valid numbers, they need revealing first
+	require.ErrorIs(t, sealed.Validate(), ErrCommitmentLength)
+}
+
+func TestCommitmentString(t *testing.T) {
+
+	require.Equal(t, "AQIDBAUG", Commitment{1, 2, 3, 4, 5, 6}.String())
+	require.Equal(t, "", Commitment{}.String())
+}
+
+func FuzzCommitmentValidate(f *testing.F) {
+
+	f.Add([]byte{1, 2, 3, 4, 5, 6})
+	f.Add([]byte{1, 2, 3, 4, 5, 6, 7})
+	f.Add([]byte{1, 2, 3, 4, 8, 8})
+	f.Add([]byte{1, 2, 3, 4, 10, 64})
+	f.Add([]byte{})
+	f.Add([]byte{BitmaskVersion, 0x80, 0, 0, 0, 0x02, 0x10, 0x84, 0x01})
+	f.Add([]byte{BitmaskVersion, 0, 0, 0, 0, 0, 0, 0, 0x3f})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+
+		c := Commitment(b)
+		err := c.Validate()
+		if err != nil {
+			require.True(
+				t,
+				errors.Is(err, ErrCommitmentLength) ||
+					errors.Is(err, ErrCommitmentOrder) ||
+					errors.Is(err, ErrCommitmentRange),
+				"unexpected error: %v",
+				err,
+			)
+			return
+		}
+
+		nums := c.Numbers()
+		require.Len(t, nums, NumPicks)
+		for i, n := range nums {
+			require.Less(t, n, uint8(MaxNumber))
+			if i > 0 {
+				require.Less(t, nums[i-1], n)
+			}
+		}
+		require.Equal(t, NumPicks, c.Matches(c))
+
+		nc, err := NewCommitment(nums...)
+		require.NoError(t, err)
+		require.Equal(t, NumPicks, c.Matches(nc))
+
+		bc, err := NewBitmaskCommitment(nums...)
+		require.NoError(t, err)
+		require.Equal(t, c.Bitmap(), bc.Bitmap())
+	})
+}
+
+// BenchmarkMatches compares counting matches against a draw with both
+// commitments byte encoded, which walks them in step, to with both bitmask
+// encoded, which is a single popcount
+func BenchmarkMatches(b *testing.B) {
+
+	c := Commitment{1, 10, 20, 25, 62, 63}
+	draw := Commitment{0, 10, 15, 20, 25, 63}
+
+	cBits, err := c.ToBitmask()
+	require.NoError(b, err)
+	drawBits, err := draw.ToBitmask()
+	require.NoError(b, err)
+
+	b.Run("bytes", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			c.Matches(draw)
+		}
+	})
+	b.Run("bitmask", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cBits.Matches(drawBits)
+		}
+	})
+}
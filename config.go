@@ -0,0 +1,294 @@
+package algokeno
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno/lotto"
+)
+
+const (
+	// MaxPicks is the largest pick count an app can be created with. Each
+	// tier takes three of the app's global keys, which with the game
+	// parameters have to fit in the 64 of an app.
+	MaxPicks = 7
+
+	// MaxBonusPicks is the largest pick count a bonus game can be created
+	// with, so that its picks+1 bonus tiers take no more global keys than
+	// the tiers
+	MaxBonusPicks = MaxPicks - 1
+
+	// MaxNumberLimit is the largest MaxNumber an app can be created with,
+	// as each number is encoded in a single byte
+	MaxNumberLimit = 256
+
+	// MaxHouseFeeBps is the whole of the escrow balance in basis points
+	MaxHouseFeeBps = 10_000
+
+	// MaxBeneficiaries is the most accounts the house fee can be split
+	// between. With the next app's address they're the 4 account args of
+	// set_draw.
+	MaxBeneficiaries = 3
+
+	// RevealRounds is how many rounds sealed tickets have to be revealed
+	// in once the draw is published, before the draw can be set without them
+	RevealRounds = 1000
+
+	// beneficiaryLength is the length of an encoded Beneficiary, its address
+	// followed by its share as a uint64
+	beneficiaryLength = 40
+
+	// opUpBudget is the opcode budget of an app call, which each inner app
+	// call it makes adds to
+	opUpBudget = 700
+
+	// set_draw's opcode cost is bounded by setDrawCost plus setDrawTierCost
+	// per tier and bonus tier, and setDrawBeneficiaryCost per beneficiary
+	setDrawCost = 400
+	setDrawTierCost = 150
+	setDrawBeneficiaryCost = 70
+)
+
+var (
+	ErrConfigPicks = errors.New("pick count out of range")
+	ErrConfigMaxNumber = errors.New("max number out of range")
+	ErrConfigTicketPrice = errors.New("ticket price must be positive")
+	ErrConfigHouseFee = errors.New("house fee above 100%")
+	ErrConfigTreasury = errors.New("invalid treasury")
+	ErrConfigBonus = errors.New("invalid bonus ball")
+	ErrCreateArgs = errors.New("not the args of a lotto app's creation")
+)
+
+// Beneficiary is an account paid a share of the house fee on SetDraw
+type Beneficiary struct {
+	Address types.Address
+
+	// ShareBps is the beneficiary's share of the house fee in basis points
+	ShareBps uint64
+}
+
+// GameConfig holds the game parameters a lotto app is created with. They're
+// passed as the app's creation args and stored in its global state.
+type GameConfig struct {
+	// Picks is the number of numbers making up a ticket (and a draw)
+	Picks int
+
+	// MaxNumber is the exclusive upper bound of any number in a ticket
+	MaxNumber int
+
+	// TicketPrice is the minimum wager (in microalgos) a ticket needs to be
+	// eligible to claim
+	TicketPrice uint64
+
+	// HouseFeeBps is the share of the escrow balance, in basis points, sent
+	// to the treasury on SetDraw to cover running costs
+	HouseFeeBps uint64
+
+	// Treasury is the beneficiaries the house fee is split between, whose
+	// shares add up to MaxHouseFeeBps. The last is paid what's left of the
+	// fee once the others are paid their share, so none of it is lost to
+	// rounding. If empty the whole fee is paid to the creator.
+	Treasury []Beneficiary
+
+	// RolloverThreshold is the amount (in microalgos) the rollover has to
+	// exceed for it to be sent to the next app
+	RolloverThreshold uint64
+
+	// AssetID is the ASA wagers are taken and prizes paid out in, or 0 for
+	// Algo. TicketPrice and RolloverThreshold are then in the asset's base
+	// units, and the app has to be opted in to the asset (`opt_in_asset`)
+	// before it can take wagers.
+	AssetID uint64
+
+	// BonusMax is the exclusive upper bound of the bonus number, drawn
+	// separately from the picks, or 0 for a game without a bonus ball. In a
+	// bonus game each ticket and draw is its picks followed by its bonus
+	// number, and tickets whose bonus number is drawn win from the bonus
+	// tiers (e.g. "5 + bonus") instead.
+	BonusMax int
+}
+
+// DefaultGameConfig is six numbers below 64 for 1 Algo, with a 10% house fee
+var DefaultGameConfig = GameConfig{
+	Picks: NumPicks,
+	MaxNumber: MaxNumber,
+	TicketPrice: 1_000_000,
+	HouseFeeBps: 1_000,
+	RolloverThreshold: 100_000,
+}
+
+// Validate returns an error if the contract's `init` would reject g
+func (g GameConfig) Validate() error {
+
+	if g.Picks < 1 || g.Picks > MaxPicks {
+		return fmt.Errorf("%w: %d not in [1, %d]", ErrConfigPicks, g.Picks, MaxPicks)
+	}
+
+	if g.MaxNumber < g.Picks || g.MaxNumber > MaxNumberLimit {
+		return fmt.Errorf("%w: %d not in [%d, %d]", ErrConfigMaxNumber, g.MaxNumber, g.Picks, MaxNumberLimit)
+	}
+
+	if g.TicketPrice == 0 {
+		return ErrConfigTicketPrice
+	}
+
+	if g.HouseFeeBps > MaxHouseFeeBps {
+		return fmt.Errorf("%w: %d bps", ErrConfigHouseFee, g.HouseFeeBps)
+	}
+
+	if len(g.Treasury) > MaxBeneficiaries {
+		return fmt.Errorf("%w: %d beneficiaries, max %d", ErrConfigTreasury, len(g.Treasury), MaxBeneficiaries)
+	}
+
+	var total uint64
+	for _, b := range g.Treasury {
+		if b.ShareBps > MaxHouseFeeBps {
+			return fmt.Errorf("%w: share of %d bps", ErrConfigTreasury, b.ShareBps)
+		}
+		total += b.ShareBps
+	}
+
+	if len(g.Treasury) > 0 && total != MaxHouseFeeBps {
+		return fmt.Errorf("%w: shares add up to %d bps", ErrConfigTreasury, total)
+	}
+
+	if g.BonusMax < 0 || g.BonusMax > MaxNumberLimit {
+		return fmt.Errorf("%w: bonus max %d not in [0, %d]", ErrConfigBonus, g.BonusMax, MaxNumberLimit)
+	}
+
+	if g.HasBonus() && g.Picks > MaxBonusPicks {
+		return fmt.Errorf("%w: %d picks, max %d with a bonus ball", ErrConfigBonus, g.Picks, MaxBonusPicks)
+	}
+
+	return nil
+}
+
+// HasBonus returns whether g is a bonus game, i.e. draws a bonus number
+func (g GameConfig) HasBonus() bool {
+
+	return g.BonusMax > 0
+}
+
+// SetDrawOpUps returns how many inner app calls set_draw makes in an app
+// created with g, to raise its opcode budget above a single app call's
+func (g GameConfig) SetDrawOpUps() uint64 {
+
+	tiers := uint64(g.Picks)
+	if g.HasBonus() {
+		tiers += uint64(g.Picks) + 1
+	}
+
+	cost := setDrawCost + setDrawTierCost*tiers + setDrawBeneficiaryCost*uint64(len(g.Treasury))
+	return cost / opUpBudget
+}
+
+// SetDrawFee returns the lowest fee set_draw can be sent with in an app
+// created with g, given the network's minFee. It covers the app call and its
+// inner txns: the house fee paid to each beneficiary (or the creator), the
+// rollover and the SetDrawOpUps app calls.
+func (g GameConfig) SetDrawFee(minFee uint64) uint64 {
+
+	payees := uint64(len(g.Treasury))
+	if payees == 0 {
+		payees = 1
+	}
+	return minFee * (2 + payees + g.SetDrawOpUps())
+}
+
+// CreateArgs encodes g as the app's creation args, the create method's
+// selector followed by its ABI encoded args
+func (g GameConfig) CreateArgs() [][]byte {
+
+	treasury := make([]byte, 2)
+	binary.BigEndian.PutUint16(treasury, uint16(len(g.Treasury)))
+
+	return [][]byte{
+		lotto.MethodCreate.GetSelector(),
+		itob(uint64(g.Picks)),
+		itob(uint64(g.MaxNumber)),
+		itob(g.TicketPrice),
+		itob(g.HouseFeeBps),
+		itob(g.RolloverThreshold),
+		itob(g.AssetID),
+		append(treasury, EncodeTreasury(g.Treasury)...),
+		itob(uint64(g.BonusMax)),
+	}
+}
+
+// ParseCreateArgs decodes the config a lotto app was created with from its
+// creation args, as encoded by CreateArgs. It doesn't validate the config,
+// as the app would have rejected an invalid one.
+func ParseCreateArgs(args [][]byte) (GameConfig, error) {
+
+	if len(args) != 9 || !bytes.Equal(args[0], lotto.MethodCreate.GetSelector()) {
+		return GameConfig{}, ErrCreateArgs
+	}
+
+	// every arg but the treasury is a uint64
+	uints := make([]uint64, len(args))
+	for _, i := range []int{1, 2, 3, 4, 5, 6, 8} {
+		if len(args[i]) != 8 {
+			return GameConfig{}, fmt.Errorf("%w: arg %d is %d bytes", ErrCreateArgs, i, len(args[i]))
+		}
+		uints[i] = binary.BigEndian.Uint64(args[i])
+	}
+
+	if len(args[7]) < 2 || int(binary.BigEndian.Uint16(args[7]))*beneficiaryLength != len(args[7])-2 {
+		return GameConfig{}, fmt.Errorf("%w: treasury is %d bytes", ErrCreateArgs, len(args[7]))
+	}
+	treasury, err := DecodeTreasury(args[7][2:])
+	if err != nil {
+		return GameConfig{}, err
+	}
+
+	return GameConfig{
+		Picks: int(uints[1]),
+		MaxNumber: int(uints[2]),
+		TicketPrice: uints[3],
+		HouseFeeBps: uints[4],
+		Treasury: treasury,
+		RolloverThreshold: uints[5],
+		AssetID: uints[6],
+		BonusMax: int(uints[8]),
+	}, nil
+}
+
+// EncodeTreasury encodes treasury as it's stored in the app's global state,
+// each beneficiary's address followed by its share
+func EncodeTreasury(treasury []Beneficiary) []byte {
+
+	b := make([]byte, 0, len(treasury)*beneficiaryLength)
+	for _, ben := range treasury {
+		b = append(b, ben.Address[:]...)
+		b = append(b, itob(ben.ShareBps)...)
+	}
+	return b
+}
+
+// DecodeTreasury decodes a treasury encoded with EncodeTreasury
+func DecodeTreasury(b []byte) ([]Beneficiary, error) {
+
+	if len(b)%beneficiaryLength != 0 {
+		return nil, fmt.Errorf("%w: length %d", ErrConfigTreasury, len(b))
+	}
+
+	var treasury []Beneficiary
+	for i := 0; i < len(b); i += beneficiaryLength {
+		var ben Beneficiary
+		copy(ben.Address[:], b[i:i+32])
+		ben.ShareBps = binary.BigEndian.Uint64(b[i+32:i+beneficiaryLength])
+		treasury = append(treasury, ben)
+	}
+	return treasury, nil
+}
+
+func itob(u uint64) []byte {
+
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, u)
+	return b
+}
@@ -0,0 +1,289 @@
+package algokeno
+
+import (
+	"math"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno/lotto"
+)
+
+func TestGameConfigValidate(t *testing.T) {
+
+	require.NoError(t, DefaultGameConfig.Validate())
+
+	testCases := []struct{
+		Name string
+		Modify func(*GameConfig)
+		ExpectedErr error
+	}{
+		{
+			Name: "single pick of two numbers",
+			Modify: func(g *GameConfig) { g.Picks, g.MaxNumber = 1, 2 },
+		},
+		{
+			Name: "max picks from every byte",
+			Modify: func(g *GameConfig) { g.Picks, g.MaxNumber = MaxPicks, MaxNumberLimit },
+		},
+		{
+			Name: "no house fee or rollover threshold",
+			Modify: func(g *GameConfig) { g.HouseFeeBps, g.RolloverThreshold = 0, 0 },
+		},
+		{
+			Name: "no picks",
+			Modify: func(g *GameConfig) { g.Picks = 0 },
+			ExpectedErr: ErrConfigPicks,
+		},
+		{
+			Name: "too many picks for SetDraw args",
+			Modify: func(g *GameConfig) { g.Picks = MaxPicks + 1 },
+			ExpectedErr: ErrConfigPicks,
+		},
+		{
+			Name: "fewer numbers than picks",
+			Modify: func(g *GameConfig) { g.MaxNumber = g.Picks - 1 },
+			ExpectedErr: ErrConfigMaxNumber,
+		},
+		{
+			Name: "numbers don't fit in a byte",
+			Modify: func(g *GameConfig) { g.MaxNumber = MaxNumberLimit + 1 },
+			ExpectedErr: ErrConfigMaxNumber,
+		},
+		{
+			Name: "free tickets",
+			Modify: func(g *GameConfig) { g.TicketPrice = 0 },
+			ExpectedErr: ErrConfigTicketPrice,
+		},
+		{
+			Name: "house fee above 100%",
+			Modify: func(g *GameConfig) { g.HouseFeeBps = MaxHouseFeeBps + 1 },
+			ExpectedErr: ErrConfigHouseFee,
+		},
+		{
+			Name: "treasury split three ways",
+			Modify: func(g *GameConfig) { g.Treasury = []Beneficiary{{ShareBps: 5_000}, {ShareBps: 3_000}, {ShareBps: 2_000}} },
+		},
+		{
+			Name: "treasury with too many beneficiaries",
+			Modify: func(g *GameConfig) { g.Treasury = make([]Beneficiary, MaxBeneficiaries+1) },
+			ExpectedErr: ErrConfigTreasury,
+		},
+		{
+			Name: "treasury shares not adding up to the whole fee",
+			Modify: func(g *GameConfig) { g.Treasury = []Beneficiary{{ShareBps: 5_000}, {ShareBps: 4_999}} },
+			ExpectedErr: ErrConfigTreasury,
+		},
+		{
+			Name: "treasury shares overflowing to the whole fee",
+			Modify: func(g *GameConfig) { g.Treasury = []Beneficiary{{ShareBps: math.MaxUint64}, {ShareBps: MaxHouseFeeBps + 1}} },
+			ExpectedErr: ErrConfigTreasury,
+		},
+		{
+			Name: "bonus ball",
+			Modify: func(g *GameConfig) { g.BonusMax = 26 },
+		},
+		{
+			Name: "bonus ball with most picks",
+			Modify: func(g *GameConfig) { g.Picks, g.BonusMax = MaxBonusPicks, MaxNumberLimit },
+		},
+		{
+			Name: "bonus ball with too many picks",
+			Modify: func(g *GameConfig) { g.Picks, g.BonusMax = MaxPicks, 26 },
+			ExpectedErr: ErrConfigBonus,
+		},
+		{
+			Name: "bonus max too large",
+			Modify: func(g *GameConfig) { g.BonusMax = MaxNumberLimit + 1 },
+			ExpectedErr: ErrConfigBonus,
+		},
+		{
+			Name: "bonus max negative",
+			Modify: func(g *GameConfig) { g.BonusMax = -1 },
+			ExpectedErr: ErrConfigBonus,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			g := DefaultGameConfig
+			test.Modify(&g)
+			require.ErrorIs(t, g.Validate(), test.ExpectedErr)
+		})
+	}
+}
+
+func TestGameConfigCreateArgs(t *testing.T) {
+
+	require.Equal(
+		t,
+		[][]byte{
+			lotto.MethodCreate.GetSelector(),
+			{0, 0, 0, 0, 0, 0, 0, 6},
+			{0, 0, 0, 0, 0, 0, 0, 64},
+			{0, 0, 0, 0, 0, 0x0f, 0x42, 0x40},
+			{0, 0, 0, 0, 0, 0, 0x03, 0xe8},
+			{0, 0, 0, 0, 0, 0x01, 0x86, 0xa0},
+			{0, 0, 0, 0, 0, 0, 0, 0},
+			{0, 0},
+			{0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		DefaultGameConfig.CreateArgs(),
+	)
+
+	asset := DefaultGameConfig
+	asset.AssetID = 0x0102
+	require.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0x01, 0x02}, asset.CreateArgs()[6])
+
+	bonus := DefaultGameConfig
+	bonus.BonusMax = 26
+	require.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 26}, bonus.CreateArgs()[8])
+
+	// The treasury is an ABI (address,uint64)[], prefixed with its length
+	treasury := DefaultGameConfig
+	treasury.Treasury = []Beneficiary{
+		{Address: types.Address{1}, ShareBps: 6_000},
+		{Address: types.Address{2}, ShareBps: 4_000},
+	}
+	args := treasury.CreateArgs()
+	require.Equal(t, []byte{0, 2}, args[7][:2])
+	require.Equal(t, EncodeTreasury(treasury.Treasury), args[7][2:])
+}
+
+func TestParseCreateArgs(t *testing.T) {
+
+	config := DefaultGameConfig
+	config.AssetID = 7
+	config.BonusMax = 26
+	config.Treasury = []Beneficiary{
+		{Address: types.Address{1}, ShareBps: 6_000},
+		{Address: types.Address{2}, ShareBps: 4_000},
+	}
+	for _, c := range []GameConfig{DefaultGameConfig, config} {
+		parsed, err := ParseCreateArgs(c.CreateArgs())
+		require.NoError(t, err)
+		require.Equal(t, c, parsed)
+	}
+
+	testCases := []struct {
+		Name string
+		Args func([][]byte) [][]byte
+	}{
+		{
+			Name: "wrong selector",
+			Args: func(args [][]byte) [][]byte {
+				args[0] = lotto.MethodCommit.GetSelector()
+				return args
+			},
+		},
+		{
+			Name: "missing arg",
+			Args: func(args [][]byte) [][]byte {
+				return args[:8]
+			},
+		},
+		{
+			Name: "short uint",
+			Args: func(args [][]byte) [][]byte {
+				args[3] = args[3][1:]
+				return args
+			},
+		},
+		{
+			Name: "treasury count mismatch",
+			Args: func(args [][]byte) [][]byte {
+				args[7] = args[7][:len(args[7])-1]
+				return args
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			_, err := ParseCreateArgs(test.Args(config.CreateArgs()))
+			require.ErrorIs(t, err, ErrCreateArgs)
+		})
+	}
+}
+
+func TestTreasuryEncoding(t *testing.T) {
+
+	treasury := []Beneficiary{
+		{Address: types.Address{1, 2, 3}, ShareBps: 0x0102},
+		{Address: types.Address{31: 0xff}, ShareBps: 0},
+	}
+
+	b := EncodeTreasury(treasury)
+	require.Len(t, b, 2*beneficiaryLength)
+	require.Equal(t, []byte{1, 2, 3}, b[:3])
+	require.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0x01, 0x02}, b[32:40])
+	require.Equal(t, byte(0xff), b[71])
+
+	decoded, err := DecodeTreasury(b)
+	require.NoError(t, err)
+	require.Equal(t, treasury, decoded)
+
+	decoded, err = DecodeTreasury(nil)
+	require.NoError(t, err)
+	require.Empty(t, decoded)
+
+	_, err = DecodeTreasury(b[:41])
+	require.ErrorIs(t, err, ErrConfigTreasury)
+}
+
+func TestGameConfigSetDrawFee(t *testing.T) {
+
+	treasury := []Beneficiary{{ShareBps: 3_000}, {ShareBps: 3_000}, {ShareBps: 4_000}}
+
+	testCases := []struct{
+		Name string
+		Modify func(*GameConfig)
+		ExpectedOpUps uint64
+		ExpectedFee uint64
+	}{
+		{
+			Name: "default",
+			Modify: func(g *GameConfig) {},
+			ExpectedOpUps: 1,
+			ExpectedFee: 4_000,
+		},
+		{
+			Name: "single pick fits the budget",
+			Modify: func(g *GameConfig) { g.Picks, g.MaxNumber = 1, 2 },
+			ExpectedOpUps: 0,
+			ExpectedFee: 3_000,
+		},
+		{
+			Name: "max picks",
+			Modify: func(g *GameConfig) { g.Picks = MaxPicks },
+			ExpectedOpUps: 2,
+			ExpectedFee: 5_000,
+		},
+		{
+			Name: "treasury",
+			Modify: func(g *GameConfig) { g.Treasury = treasury },
+			ExpectedOpUps: 2,
+			ExpectedFee: 7_000,
+		},
+		{
+			Name: "bonus tiers",
+			Modify: func(g *GameConfig) { g.BonusMax = 26 },
+			ExpectedOpUps: 3,
+			ExpectedFee: 6_000,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			config := DefaultGameConfig
+			test.Modify(&config)
+			require.NoError(t, config.Validate())
+			require.Equal(t, test.ExpectedOpUps, config.SetDrawOpUps())
+			require.Equal(t, test.ExpectedFee, config.SetDrawFee(1_000))
+		})
+	}
+}
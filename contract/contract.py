@@ -2,25 +2,123 @@ from pyteal import *
 from pyteal.ast.bytes import Bytes
 import json
 
+# The app's methods are ARC-4 methods, called with the first 4 bytes of the
+# sha512_256 of their signature as the first app arg. Their deposits are
+# `txn` args as they're either a payment or a transfer of the app's asset,
+# and the methods sending the asset in inner txns take it as an `asset` arg
+# so that ARC-4 clients put it in the foreign assets.
+CREATE = "create(uint64,uint64,uint64,uint64,uint64,uint64,(address,uint64)[],uint64)void"
+COMMIT = "commit(byte[],txn)void"
+SET_DRAW = "set_draw(byte[],(uint64,uint64)[],(uint64,uint64)[],application,account,account,account,account)void"
+SET_DRAW_ASSET = "set_draw_asset(byte[],(uint64,uint64)[],(uint64,uint64)[],application,account,account,account,account,asset)void"
+CLAIM = "claim()void"
+CLAIM_ASSET = "claim_asset(asset)void"
+REVEAL = "reveal(byte[],byte[])void"
+OPT_IN_ASSET = "opt_in_asset(asset)void"
+SPONSOR = "sponsor(uint64,txn)void"
+PUBLISH_DRAW = "publish_draw(byte[])void"
+
+# The app logs an ARC-28 event for what each call did: the first 4 bytes of
+# the sha512_256 of its signature followed by its args ABI encoded as a
+# tuple. Their byte[] arg is last so that its offset in the tuple is fixed.
+TICKET_PURCHASED = "TicketPurchased(address,uint64,byte[])"
+DRAW_SET = "DrawSet(uint64,byte[])"
+ROLLOVER = "Rollover(uint64,uint64)"
+PRIZE_CLAIMED = "PrizeClaimed(address,uint64,bool,uint64)"
+
+# Each tier takes three global keys and each bonus tier two, which with the
+# game parameters have to fit in the 64 of an app
+MAX_PICKS = 7
+
+# Sealed tickets commit sha512_256(numbers || salt) rather than the numbers,
+# which are revealed after the draw
+SEALED_LENGTH = 32
+
+# While sealed tickets are unrevealed the draw is published first, and the
+# tiers can only be set once they're all revealed or REVEAL_ROUNDS rounds
+# have passed. Tickets still sealed then can never claim.
+REVEAL_ROUNDS = 1000
+
+# The house fee is split between up to MAX_BENEFICIARIES accounts, each
+# encoded as its 32 byte address followed by its share in bps as a uint64
+# (an ABI (address,uint64) tuple). With the next app's address they're the 4
+# account args of SetDraw, so that the app can pay them.
+MAX_BENEFICIARIES = 3
+BENEFICIARY_LENGTH = 40
+
+# SetDraw takes the tiers, and in bonus games the bonus tiers for each of 0
+# to picks matching numbers plus the bonus, as ABI (uint64,uint64)[] arrays
+# of a (winners, prize) pair per tier. Bonus games have one pick less, so
+# their bonus tiers take no more global keys than the tiers.
+MAX_BONUS_PICKS = MAX_PICKS - 1
+TIER_LENGTH = 16
+
+# An app call can run OPUP_BUDGET opcodes, and each inner app call it makes
+# adds as many again. SetDraw costs more than that, growing with its tiers
+# and the treasury's beneficiaries, so it first makes an inner call for each
+# OPUP_BUDGET of SET_DRAW_COST, SET_DRAW_TIER_COST per tier and bonus tier
+# and SET_DRAW_BENEFICIARY_COST per beneficiary. Each creates and deletes an
+# app approving everything (`#pragma version 6; int 1`).
+OPUP_BUDGET = 700
+OPUP_PROGRAM = "068101"
+SET_DRAW_COST = 400
+SET_DRAW_TIER_COST = 150
+SET_DRAW_BENEFICIARY_COST = 70
+
+def tier_key(tier: Expr, suffix: str) -> Expr:
+  # tier is at most MAX_PICKS so its key is a single ascii digit + suffix
+  return Concat(Extract(Itob(tier + Int(ord("0"))), Int(7), Int(1)), Bytes(suffix))
+
+def event(signature: str, *args: Expr) -> Expr:
+  return Log(Concat(MethodSignature(signature), *args))
+
+# The offset of a byte[] following static args of static_length bytes in an
+# ABI tuple, past them and its own 2 byte offset
+def tail_offset(static_length: int) -> Expr:
+  return Bytes("base16", (static_length + 2).to_bytes(2, "big").hex())
+
 def approval():
 
   global_num_tickets = GlobalUint("numTickets")
 
   global_draw = GlobalByteslice("draw")
 
-  global_1_payouts_rem = GlobalUint("1s")
-  global_2_payouts_rem = GlobalUint("2s")
-  global_3_payouts_rem = GlobalUint("3s")
-  global_4_payouts_rem = GlobalUint("4s")
-  global_5_payouts_rem = GlobalUint("5s")
-  global_6_payouts_rem = GlobalUint("6s")
+  # Sealed tickets yet to be revealed, and the last round of the reveal
+  # window once the draw is published (0 until then, and once it's set)
+  global_num_sealed = GlobalUint("numSealed")
+  global_reveal_by = GlobalUint("revealBy")
+
+  # Game parameters, supplied as creation args
+  global_picks = GlobalUint("picks")
+  global_max_number = GlobalUint("maxNumber")
+  global_ticket_price = GlobalUint("price")
+  global_fee_bps = GlobalUint("feeBps")
+  global_rollover_min = GlobalUint("rolloverMin")
+  # ID of the asset wagers and payouts are in, or 0 for Algo
+  global_asset = GlobalUint("asset")
+  # Beneficiaries the house fee is split between, or empty for the creator
+  global_treasury = GlobalByteslice("treasury")
+  # Exclusive upper bound of the bonus number drawn separately from the
+  # picks, or 0 for a game without a bonus ball
+  global_bonus_max = GlobalUint("bonusMax")
 
-  global_1_prize = GlobalUint("1p")
-  global_2_prize = GlobalUint("2p")
-  global_3_prize = GlobalUint("3p")
-  global_4_prize = GlobalUint("4p")
-  global_5_prize = GlobalUint("5p")
-  global_6_prize = GlobalUint("6p")
+  # Winners ("Ns") and prize ("Np") for tickets matching N numbers, and the
+  # prize sponsors have guaranteed ("Ng"). Enough are allocated for the
+  # largest pick count, only the first `picks` are used.
+  for tier in range(1, MAX_PICKS + 1):
+    GlobalUint("%ds" % tier)
+    GlobalUint("%dp" % tier)
+    GlobalUint("%dg" % tier)
+
+  # Total sponsors have deposited, which no house fee is taken from
+  global_sponsored = GlobalUint("sponsored")
+
+  # Winners ("Nbs") and prize ("Nbp") for tickets matching N numbers and the
+  # bonus number, from 0 up to the largest pick count of a bonus game. Only
+  # allocated in bonus games.
+  for tier in range(0, MAX_BONUS_PICKS + 1):
+    GlobalUint("%dbs" % tier)
+    GlobalUint("%dbp" % tier)
 
   global_next = GlobalByteslice("next")
   global_curr = GlobalByteslice("curr")
@@ -29,27 +127,163 @@ def approval():
   local_commitment = LocalByteslice("commitment")
 
 
-  op_commit = Bytes("Commit")
-  op_set_draw = Bytes("SetDraw")
-  op_claim = Bytes("Claim")
+  op_create = MethodSignature(CREATE)
+  op_commit = MethodSignature(COMMIT)
+  op_set_draw = MethodSignature(SET_DRAW)
+  op_set_draw_asset = MethodSignature(SET_DRAW_ASSET)
+  op_claim = MethodSignature(CLAIM)
+  op_claim_asset = MethodSignature(CLAIM_ASSET)
+  op_reveal = MethodSignature(REVEAL)
+  op_opt_in_asset = MethodSignature(OPT_IN_ASSET)
+  op_sponsor = MethodSignature(SPONSOR)
+  op_publish_draw = MethodSignature(PUBLISH_DRAW)
+
+  # Checks the first txn of the group, the `txn` arg of the app call, pays
+  # into the app: a payment, or a transfer of the app's asset if it has one
+  def is_deposit() -> Expr:
+    return Or(
+      And(
+        App.globalGet(global_asset) == Int(0),
+        Gtxn[0].type_enum() == TxnType.Payment,
+        Gtxn[0].receiver() == Global.current_application_address(),
+        Gtxn[0].close_remainder_to() == Global.zero_address(),
+      ),
+      And(
+        App.globalGet(global_asset) != Int(0),
+        Gtxn[0].type_enum() == TxnType.AssetTransfer,
+        Gtxn[0].xfer_asset() == App.globalGet(global_asset),
+        Gtxn[0].asset_receiver() == Global.current_application_address(),
+        Gtxn[0].asset_close_to() == Global.zero_address(),
+        Gtxn[0].asset_sender() == Global.zero_address(),
+      ),
+    )
+
+  # ABI byte[] args are their length as a uint16 followed by the bytes
+  def is_abi_bytes(arg: Expr) -> Expr:
+    return ExtractUint16(arg, Int(0)) == Len(arg) - Int(2)
+
+  def abi_bytes(arg: Expr) -> Expr:
+    return Suffix(arg, Int(2))
+
+  # ABI arrays of tiers are their number as a uint16 followed by a (winners,
+  # prize) uint64 pair for each
+  def is_tiers(arg: Expr, n: Expr) -> Expr:
+    return And(
+      ExtractUint16(arg, Int(0)) == n,
+      Len(arg) == Int(2) + n * Int(TIER_LENGTH),
+    )
+
+  def tier_winners(arg: Expr, tier: Expr) -> Expr:
+    return ExtractUint64(arg, Int(2) + tier * Int(TIER_LENGTH))
+
+  def tier_prize(arg: Expr, tier: Expr) -> Expr:
+    return ExtractUint64(arg, Int(10) + tier * Int(TIER_LENGTH))
+
+  def has_bonus() -> Expr:
+    return App.globalGet(global_bonus_max) != Int(0)
+
+  # The inner app calls SetDraw makes for its opcode budget
+  def set_draw_op_ups() -> Expr:
+    return (
+      Int(SET_DRAW_COST) +
+      Int(SET_DRAW_TIER_COST) * (
+        App.globalGet(global_picks) +
+        has_bonus() * (App.globalGet(global_picks) + Int(1))
+      ) +
+      Int(SET_DRAW_BENEFICIARY_COST) * (Len(App.globalGet(global_treasury)) / Int(BENEFICIARY_LENGTH))
+    ) / Int(OPUP_BUDGET)
+
+  # In a bonus game tickets and draws are their picks followed by the bonus
+  # number, so the picks are all but the last byte
+  def picks_of(c: Expr) -> Expr:
+    return Substring(c, Int(0), Len(c) - has_bonus())
+
+  def bonus_of(c: Expr) -> Expr:
+    return GetByte(c, Len(c) - Int(1))
+
+  # SetDraw's args are the draw, the tiers, the bonus tiers (empty if it's
+  # not a bonus game), the next app, and the next app's address
+  tiers_arg = Txn.application_args[2]
+  bonus_tiers_arg = Txn.application_args[3]
+  next_app_arg = Btoi(Txn.application_args[4])
+  next_account_arg = Txn.accounts[Btoi(Txn.application_args[5])]
+
+  # Sets the fields of an inner txn paying amount to receiver, in Algo or in
+  # the app's asset if it has one
+  def payout_fields(receiver: Expr, amount: Expr) -> Expr:
+    return If(App.globalGet(global_asset) == Int(0)).Then(
+      InnerTxnBuilder.SetFields(
+        {
+          TxnField.type_enum: TxnType.Payment,
+          TxnField.receiver: receiver,
+          TxnField.amount: amount,
+          TxnField.fee: Int(0),
+        }
+      ),
+    ).Else(
+      InnerTxnBuilder.SetFields(
+        {
+          TxnField.type_enum: TxnType.AssetTransfer,
+          TxnField.xfer_asset: App.globalGet(global_asset),
+          TxnField.asset_receiver: receiver,
+          TxnField.asset_amount: amount,
+          TxnField.fee: Int(0),
+        }
+      ),
+    )
 
   @Subroutine(TealType.none)
   def init():
+    i = ScratchVar()
     return Seq(
+      Assert(
+        And(
+          Txn.application_args.length() == Int(9),
+          Txn.application_args[0] == op_create,
+          Btoi(Txn.application_args[1]) >= Int(1),
+          Btoi(Txn.application_args[1]) <= Int(MAX_PICKS),
+          Btoi(Txn.application_args[2]) >= Btoi(Txn.application_args[1]),
+          Btoi(Txn.application_args[2]) <= Int(256),
+          Btoi(Txn.application_args[3]) > Int(0),
+          Btoi(Txn.application_args[4]) <= Int(10000),
+          # the treasury is an ABI (address,uint64)[] array
+          ExtractUint16(Txn.application_args[7], Int(0)) * Int(BENEFICIARY_LENGTH) == Len(Txn.application_args[7]) - Int(2),
+          is_valid_treasury(Suffix(Txn.application_args[7], Int(2))),
+          Btoi(Txn.application_args[8]) <= Int(256),
+          Or(
+            Btoi(Txn.application_args[8]) == Int(0),
+            Btoi(Txn.application_args[1]) <= Int(MAX_BONUS_PICKS),
+          ),
+        ),
+      ),
+      App.globalPut(global_picks, Btoi(Txn.application_args[1])),
+      App.globalPut(global_max_number, Btoi(Txn.application_args[2])),
+      App.globalPut(global_ticket_price, Btoi(Txn.application_args[3])),
+      App.globalPut(global_fee_bps, Btoi(Txn.application_args[4])),
+      App.globalPut(global_rollover_min, Btoi(Txn.application_args[5])),
+      App.globalPut(global_asset, Btoi(Txn.application_args[6])),
+      App.globalPut(global_treasury, Suffix(Txn.application_args[7], Int(2))),
+      App.globalPut(global_bonus_max, Btoi(Txn.application_args[8])),
       App.globalPut(global_num_tickets, Int(0)),
       App.globalPut(global_draw, Bytes("base64", "")),
-      App.globalPut(global_1_payouts_rem, Int(0)),
-      App.globalPut(global_2_payouts_rem, Int(0)),
-      App.globalPut(global_3_payouts_rem, Int(0)),
-      App.globalPut(global_4_payouts_rem, Int(0)),
-      App.globalPut(global_5_payouts_rem, Int(0)),
-      App.globalPut(global_6_payouts_rem, Int(0)),
-      App.globalPut(global_1_prize, Int(0)),
-      App.globalPut(global_2_prize, Int(0)),
-      App.globalPut(global_3_prize, Int(0)),
-      App.globalPut(global_4_prize, Int(0)),
-      App.globalPut(global_5_prize, Int(0)),
-      App.globalPut(global_6_prize, Int(0)),
+      App.globalPut(global_num_sealed, Int(0)),
+      App.globalPut(global_reveal_by, Int(0)),
+      App.globalPut(global_sponsored, Int(0)),
+      For(i.store(Int(1)), i.load() <= App.globalGet(global_picks), i.store(i.load() + Int(1))).Do(
+        Seq(
+          App.globalPut(tier_key(i.load(), "s"), Int(0)),
+          App.globalPut(tier_key(i.load(), "p"), Int(0)),
+          App.globalPut(tier_key(i.load(), "g"), Int(0)),
+        ),
+      ),
+      If(has_bonus()).Then(
+        For(i.store(Int(0)), i.load() <= App.globalGet(global_picks), i.store(i.load() + Int(1))).Do(
+          Seq(
+            App.globalPut(tier_key(i.load(), "bs"), Int(0)),
+            App.globalPut(tier_key(i.load(), "bp"), Int(0)),
+          ),
+        ),
+      ),
       Approve(),
     )
 
@@ -61,31 +295,67 @@ def approval():
       Approve(),
     )
 
+  # Commitments are either `picks` numbers one per byte, or bitmask encoded:
+  # a version byte of 1 followed by a uint64 with bit n set for number n
+  @Subroutine(TealType.uint64)
+  def is_bitmask(c: Expr):
+    return Seq(
+      If(Len(c) == Int(9)).Then(Return(GetByte(c, Int(0)) == Int(1))),
+      Return(Int(0)),
+    )
+
+  # Counts the set bits of x without multiplying, as TEAL's `*` fails on
+  # overflow. Each step sums bit counts over wider and wider fields.
+  @Subroutine(TealType.uint64)
+  def popcount(x: Expr):
+    v = ScratchVar()
+    return Seq(
+      v.store(x - (ShiftRight(x, Int(1)) & Int(0x5555555555555555))),
+      v.store((v.load() & Int(0x3333333333333333)) + (ShiftRight(v.load(), Int(2)) & Int(0x3333333333333333))),
+      v.store((v.load() + ShiftRight(v.load(), Int(4))) & Int(0x0F0F0F0F0F0F0F0F)),
+      v.store(v.load() + ShiftRight(v.load(), Int(8))),
+      v.store(v.load() + ShiftRight(v.load(), Int(16))),
+      Return((v.load() + ShiftRight(v.load(), Int(32))) & Int(0x7F)),
+    )
+
+  # Numbers of 64 and above can't be set in a bitmap so are left out, which
+  # can only lower the count of matches against a bitmask commitment
+  @Subroutine(TealType.uint64)
+  def to_bitmap(c: Expr):
+    bm = ScratchVar()
+    i = ScratchVar()
+    return Seq(
+      If(is_bitmask(c)).Then(Return(ExtractUint64(c, Int(1)))),
+      bm.store(Int(0)),
+      For(i.store(Int(0)), i.load() < Len(c), i.store(i.load() + Int(1))).Do(
+        If(GetByte(c, i.load()) < Int(64)).Then(
+          bm.store(bm.load() | ShiftLeft(Int(1), GetByte(c, i.load()))),
+        ),
+      ),
+      Return(bm.load()),
+    )
+
   @Subroutine(TealType.uint64)
   def is_valid_commitment(c: Expr):
-    num_0 = ScratchVar()
-    num_1 = ScratchVar()
-    num_2 = ScratchVar()
-    num_3 = ScratchVar()
-    num_4 = ScratchVar()
-    num_5 = ScratchVar()
+    i = ScratchVar()
+    bm = ScratchVar()
     return Seq(
-      num_0.store(GetByte(c, Int(0))),
-      num_1.store(GetByte(c, Int(1))),
-      num_2.store(GetByte(c, Int(2))),
-      num_3.store(GetByte(c, Int(3))),
-      num_4.store(GetByte(c, Int(4))),
-      num_5.store(GetByte(c, Int(5))),
-      Return(
-        And(
-          num_0.load() < num_1.load(),
-          num_1.load() < num_2.load(),
-          num_2.load() < num_3.load(),
-          num_3.load() < num_4.load(),
-          num_4.load() < num_5.load(),
-          num_5.load() < Int(64),
+      If(is_bitmask(c)).Then(
+        Seq(
+          bm.store(ExtractUint64(c, Int(1))),
+          Return(
+            And(
+              popcount(bm.load()) == App.globalGet(global_picks),
+              BitLen(bm.load()) <= App.globalGet(global_max_number),
+            ),
+          ),
         ),
       ),
+      If(Len(c) != App.globalGet(global_picks)).Then(Return(Int(0))),
+      For(i.store(Int(1)), i.load() < Len(c), i.store(i.load() + Int(1))).Do(
+        If(GetByte(c, i.load() - Int(1)) >= GetByte(c, i.load())).Then(Return(Int(0))),
+      ),
+      Return(GetByte(c, Len(c) - Int(1)) < App.globalGet(global_max_number)),
     )
 
   @Subroutine(TealType.none)
@@ -94,39 +364,77 @@ def approval():
       Assert(
         And(
           Global.group_size() == Int(2),
-          Txn.group_index() == Int(0),
+          Txn.group_index() == Int(1),
           *[Gtxn[i].rekey_to() == Global.zero_address() for i in range(2)],
 
-          # second transaction is wager payment
-          Gtxn[1].type_enum() == TxnType.Payment,
-          Gtxn[1].receiver() == Global.current_application_address(),
-          Gtxn[1].close_remainder_to() == Global.zero_address(),
+          # first transaction is wager payment, or transfer of the app's asset
+          is_deposit(),
 
           Txn.application_args.length() == Int(2),
+          is_abi_bytes(Txn.application_args[1]),
+
+          Or(
+            is_valid_ticket(abi_bytes(Txn.application_args[1])),
+            Len(abi_bytes(Txn.application_args[1])) == Int(SEALED_LENGTH),
+          ),
 
-          is_valid_commitment(Txn.application_args[1]),
+          # tickets can only be bought until the draw is set
+          App.globalGet(global_draw) == Bytes(""),
         ),
       ),
-      App.localPut(Txn.sender(), local_wager, Gtxn[1].amount()),
-      App.localPut(Txn.sender(), local_commitment, Txn.application_args[1]),
+      If(App.globalGet(global_asset) == Int(0))
+      .Then(App.localPut(Txn.sender(), local_wager, Gtxn[0].amount()))
+      .Else(App.localPut(Txn.sender(), local_wager, Gtxn[0].asset_amount())),
+      # Count the sealed tickets, which replace the sender's last ticket
+      If(Len(App.localGet(Txn.sender(), local_commitment)) == Int(SEALED_LENGTH)).Then(
+        App.globalPut(global_num_sealed, App.globalGet(global_num_sealed) - Int(1)),
+      ),
+      If(Len(abi_bytes(Txn.application_args[1])) == Int(SEALED_LENGTH)).Then(
+        App.globalPut(global_num_sealed, App.globalGet(global_num_sealed) + Int(1)),
+      ),
+      App.localPut(Txn.sender(), local_commitment, abi_bytes(Txn.application_args[1])),
       App.globalPut(
         global_num_tickets,
         App.globalGet(global_num_tickets) + Int(1),
       ),
+      event(
+        TICKET_PURCHASED,
+        Txn.sender(),
+        Itob(App.localGet(Txn.sender(), local_wager)),
+        tail_offset(40),
+        Txn.application_args[1],
+      ),
       Approve(),
     )
 
   @Subroutine(TealType.uint64)
   def rollover_amount():
     rollover_amt = ScratchVar()
+    i = ScratchVar()
     return Seq(
       rollover_amt.store(Int(0)),
-      If(Btoi(Txn.application_args[2]) == Int(0)).Then(rollover_amt.store(rollover_amt.load() + Btoi(Txn.application_args[3]))),
-      If(Btoi(Txn.application_args[4]) == Int(0)).Then(rollover_amt.store(rollover_amt.load() + Btoi(Txn.application_args[5]))),
-      If(Btoi(Txn.application_args[6]) == Int(0)).Then(rollover_amt.store(rollover_amt.load() + Btoi(Txn.application_args[7]))),
-      If(Btoi(Txn.application_args[8]) == Int(0)).Then(rollover_amt.store(rollover_amt.load() + Btoi(Txn.application_args[9]))),
-      If(Btoi(Txn.application_args[10]) == Int(0)).Then(rollover_amt.store(rollover_amt.load() + Btoi(Txn.application_args[11]))),
-      If(Btoi(Txn.application_args[12]) == Int(0)).Then(rollover_amt.store(rollover_amt.load() + Btoi(Txn.application_args[13]))),
+      For(i.store(Int(0)), i.load() < App.globalGet(global_picks), i.store(i.load() + Int(1))).Do(
+        # Tiers without winners roll over their whole prize, the rest the
+        # dust left once it's split evenly between their winners
+        If(tier_winners(tiers_arg, i.load()) == Int(0))
+        .Then(
+          rollover_amt.store(rollover_amt.load() + tier_prize(tiers_arg, i.load())),
+        )
+        .Else(
+          rollover_amt.store(
+            rollover_amt.load() +
+            tier_prize(tiers_arg, i.load()) %
+            tier_winners(tiers_arg, i.load()),
+          ),
+        ),
+      ),
+      If(has_bonus()).Then(
+        For(i.store(Int(0)), i.load() <= App.globalGet(global_picks), i.store(i.load() + Int(1))).Do(
+          If(tier_winners(bonus_tiers_arg, i.load()) == Int(0))
+          .Then(rollover_amt.store(rollover_amt.load() + tier_prize(bonus_tiers_arg, i.load())))
+          .Else(rollover_amt.store(rollover_amt.load() + tier_prize(bonus_tiers_arg, i.load()) % tier_winners(bonus_tiers_arg, i.load()))),
+        ),
+      ),
       Return(rollover_amt.load()),
     )
 
@@ -134,79 +442,175 @@ def approval():
   def set_draw():
 
     escrow_bal = AccountParam.balance(Global.current_application_address())
-    next_app_address = AppParam.address(Int(1))
+    asset_bal = AssetHolding.balance(Global.current_application_address(), App.globalGet(global_asset))
+    next_app_address = AppParam.address(next_app_arg)
+    next_app_asset = App.globalGetEx(next_app_arg, global_asset)
     running_costs = ScratchVar()
     ro_amount = ScratchVar()
+    i = ScratchVar()
 
     return Seq(
+      op_up(set_draw_op_ups()),
       next_app_address,
+      next_app_asset,
       Assert(
         And(
           Txn.sender() == Global.creator_address(),
           Global.group_size() == Int(1),
           Txn.group_index() == Int(0),
           Gtxn[0].rekey_to() == Global.zero_address(),
-          Txn.application_args.length() == Int(14),
+          is_abi_bytes(Txn.application_args[1]),
+          is_tiers(tiers_arg, App.globalGet(global_picks)),
+          is_tiers(bonus_tiers_arg, has_bonus() * (App.globalGet(global_picks) + Int(1))),
+
+          # one inner txn per beneficiary (or the creator), the rollover
+          # and the inner app calls for the opcode budget
+          Txn.fee() >= Global.min_txn_fee() * (
+            Int(2) +
+            Len(App.globalGet(global_treasury)) / Int(BENEFICIARY_LENGTH) +
+            (Len(App.globalGet(global_treasury)) == Int(0)) +
+            set_draw_op_ups()
+          ),
+          Txn.applications[next_app_arg] != Txn.application_id(),
 
-          Txn.fee() >= Global.min_txn_fee() * Int(3),
-          Txn.applications.length() == Int(1),
-          Txn.applications[1] != Txn.application_id(),
+          next_account_arg == next_app_address.value(),
+          # the rollover has to be in the next app's currency
+          next_app_asset.value() == App.globalGet(global_asset),
 
-          Txn.accounts[Int(1)] == next_app_address.value(),
+          # Without sealed tickets the draw can be set straight away,
+          # otherwise it has to be the one published, and the tiers wait
+          # for the reveal window unless every ticket is revealed
+          Or(
+            And(
+              App.globalGet(global_draw) == Bytes(""),
+              App.globalGet(global_num_sealed) == Int(0),
+            ),
+            And(
+              App.globalGet(global_reveal_by) != Int(0),
+              App.globalGet(global_draw) == abi_bytes(Txn.application_args[1]),
+              Or(
+                App.globalGet(global_num_sealed) == Int(0),
+                Global.round() > App.globalGet(global_reveal_by),
+              ),
+            ),
+          ),
+        ),
+      ),
+      App.globalPut(global_draw, abi_bytes(Txn.application_args[1])),
+      # closes the reveal window, and keeps the draw from being set again
+      App.globalPut(global_reveal_by, Int(0)),
+      For(i.store(Int(0)), i.load() < App.globalGet(global_picks), i.store(i.load() + Int(1))).Do(
+        Seq(
+          # Sponsored prizes are guaranteed
+          Assert(tier_prize(tiers_arg, i.load()) >= App.globalGet(tier_key(i.load() + Int(1), "g"))),
+          App.globalPut(tier_key(i.load() + Int(1), "s"), tier_winners(tiers_arg, i.load())),
+          # The prize kept for a tier's winners is a multiple of their
+          # number, as its dust is rolled over
+          If(tier_winners(tiers_arg, i.load()) == Int(0))
+          .Then(
+            App.globalPut(tier_key(i.load() + Int(1), "p"), tier_prize(tiers_arg, i.load())),
+          )
+          .Else(
+            App.globalPut(
+              tier_key(i.load() + Int(1), "p"),
+              tier_prize(tiers_arg, i.load()) -
+              tier_prize(tiers_arg, i.load()) %
+              tier_winners(tiers_arg, i.load()),
+            ),
+          ),
+        ),
+      ),
+      If(has_bonus()).Then(
+        For(i.store(Int(0)), i.load() <= App.globalGet(global_picks), i.store(i.load() + Int(1))).Do(
+          Seq(
+            App.globalPut(tier_key(i.load(), "bs"), tier_winners(bonus_tiers_arg, i.load())),
+            If(tier_winners(bonus_tiers_arg, i.load()) == Int(0))
+            .Then(App.globalPut(tier_key(i.load(), "bp"), tier_prize(bonus_tiers_arg, i.load())))
+            .Else(
+              App.globalPut(
+                tier_key(i.load(), "bp"),
+                tier_prize(bonus_tiers_arg, i.load()) - tier_prize(bonus_tiers_arg, i.load()) % tier_winners(bonus_tiers_arg, i.load()),
+              ),
+            ),
+          ),
         ),
       ),
-      App.globalPut(global_draw, Txn.application_args[1]),
-      App.globalPut(global_1_payouts_rem, Btoi(Txn.application_args[2])),
-      App.globalPut(global_1_prize, Btoi(Txn.application_args[3])),
-      App.globalPut(global_2_payouts_rem, Btoi(Txn.application_args[4])),
-      App.globalPut(global_2_prize, Btoi(Txn.application_args[5])),
-      App.globalPut(global_3_payouts_rem, Btoi(Txn.application_args[6])),
-      App.globalPut(global_3_prize, Btoi(Txn.application_args[7])),
-      App.globalPut(global_4_payouts_rem, Btoi(Txn.application_args[8])),
-      App.globalPut(global_4_prize, Btoi(Txn.application_args[9])),
-      App.globalPut(global_5_payouts_rem, Btoi(Txn.application_args[10])),
-      App.globalPut(global_5_prize, Btoi(Txn.application_args[11])),
-      App.globalPut(global_6_payouts_rem, Btoi(Txn.application_args[12])),
-      App.globalPut(global_6_prize, Btoi(Txn.application_args[13])),
-
-      escrow_bal,
-      running_costs.store(
-        escrow_bal.value() / Int(10),
+
+      # The escrow is the app's asset holding if it has one, which can
+      # only be read once the asset is checked to be set. Sponsors'
+      # deposits are prizes, so the fee is only taken from the rest
+      If(App.globalGet(global_asset) == Int(0))
+      .Then(
+        Seq(
+          escrow_bal,
+          running_costs.store(fee_share(escrow_bal.value() - App.globalGet(global_sponsored), App.globalGet(global_fee_bps))),
+        ),
+      )
+      .Else(
+        Seq(
+          asset_bal,
+          running_costs.store(fee_share(asset_bal.value() - App.globalGet(global_sponsored), App.globalGet(global_fee_bps))),
+        ),
       ),
 
       ro_amount.store(rollover_amount()),
 
       InnerTxnBuilder.Begin(),
-      InnerTxnBuilder.SetFields(
-        {
-          TxnField.type_enum: TxnType.Payment,
-          TxnField.receiver: Global.creator_address(),
-          TxnField.amount: running_costs.load(),
-          TxnField.fee: Int(0),
-        }
-      ),
-      If(ro_amount.load() > Int(100000))
+      pay_house_fee(running_costs.load()),
+      If(ro_amount.load() > App.globalGet(global_rollover_min))
       .Then(
         Seq(
           InnerTxnBuilder.Next(),
-          InnerTxnBuilder.SetFields(
-            {
-              TxnField.type_enum: TxnType.Payment,
-              TxnField.receiver: Txn.accounts[Int(1)],
-              #TxnField.amount: Int(200000),
-              TxnField.amount: ro_amount.load(),
-              TxnField.fee: Int(0),
-            }
-          ),
+          payout_fields(next_account_arg, ro_amount.load()),
         ),
       ),
       InnerTxnBuilder.Submit(),
 
+      event(DRAW_SET, Itob(running_costs.load()), tail_offset(8), Txn.application_args[1]),
+      If(ro_amount.load() > App.globalGet(global_rollover_min)).Then(
+        event(ROLLOVER, Itob(Txn.applications[next_app_arg]), Itob(ro_amount.load())),
+      ),
+
       Approve(),
     )
 
+  # Counts the numbers c has in common with the draw d. If either is bitmask
+  # encoded it's a popcount, otherwise both are sorted so they're merged.
+  @Subroutine(TealType.uint64)
+  def matches(c: Expr, d: Expr):
+    i = ScratchVar()
+    j = ScratchVar()
+    n = ScratchVar()
+    return Seq(
+      If(Or(is_bitmask(c), is_bitmask(d))).Then(
+        Return(popcount(to_bitmap(c) & to_bitmap(d))),
+      ),
+      n.store(Int(0)),
+      i.store(Int(0)),
+      j.store(Int(0)),
+      While(And(i.load() < Len(c), j.load() < Len(d))).Do(
+        If(GetByte(c, i.load()) == GetByte(d, j.load()))
+        .Then(
+          Seq(
+            n.store(n.load() + Int(1)),
+            i.store(i.load() + Int(1)),
+            j.store(j.load() + Int(1)),
+          ),
+        )
+        .ElseIf(GetByte(c, i.load()) < GetByte(d, j.load()))
+        .Then(i.store(i.load() + Int(1)))
+        .Else(j.store(j.load() + Int(1))),
+      ),
+      Return(n.load()),
+    )
+
   @Subroutine(TealType.none)
   def claim():
+    m = ScratchVar()
+    bonus = ScratchVar()
+    share = ScratchVar()
+    winners_key = ScratchVar()
+    prize_key = ScratchVar()
     return Seq(
       Assert(
         And(
@@ -216,28 +620,277 @@ def approval():
 
           Txn.fee() >= Global.min_txn_fee() * Int(2),
 
-          App.localGet(Int(0), local_wager) >= Int(1000000),
+          App.localGet(Int(0), local_wager) >= App.globalGet(global_ticket_price),
           App.localGet(Int(0), local_commitment) != Bytes(""),
+          # sealed tickets have to be revealed first
+          Len(App.localGet(Int(0), local_commitment)) != Int(SEALED_LENGTH),
+        ),
+      ),
 
-          App.localGet(Int(0), local_commitment) == App.globalGet(global_draw),
+      # The ticket claims from the tier of the number of picks it matched,
+      # which has to have winners left to claim. If its bonus number was
+      # drawn it claims from the bonus tier, which can be of no picks.
+      m.store(matches(picks_of(App.localGet(Int(0), local_commitment)), picks_of(App.globalGet(global_draw)))),
+      If(
+        And(
+          has_bonus(),
+          bonus_of(App.localGet(Int(0), local_commitment)) == bonus_of(App.globalGet(global_draw)),
+        ),
+      )
+      .Then(
+        Seq(
+          bonus.store(Int(1)),
+          winners_key.store(tier_key(m.load(), "bs")),
+          prize_key.store(tier_key(m.load(), "bp")),
+        ),
+      )
+      .Else(
+        Seq(
+          bonus.store(Int(0)),
+          Assert(m.load() >= Int(1)),
+          winners_key.store(tier_key(m.load(), "s")),
+          prize_key.store(tier_key(m.load(), "p")),
         ),
       ),
+      Assert(App.globalGet(winners_key.load()) > Int(0)),
+
+      # Each winner left gets an equal share of what's left of the prize
+      share.store(App.globalGet(prize_key.load()) / App.globalGet(winners_key.load())),
+      App.globalPut(prize_key.load(), App.globalGet(prize_key.load()) - share.load()),
+      App.globalPut(winners_key.load(), App.globalGet(winners_key.load()) - Int(1)),
 
-      # TODO: Check how many number match and select prize amount based on this
+      # Clear the ticket so it can only claim once
+      App.localPut(Int(0), local_wager, Int(0)),
+      App.localPut(Int(0), local_commitment, Bytes("base64", "")),
+
+      InnerTxnBuilder.Begin(),
+      payout_fields(Txn.accounts[Int(0)], share.load()),
+      InnerTxnBuilder.Submit(),
+
+      event(
+        PRIZE_CLAIMED,
+        Txn.sender(),
+        Itob(m.load()),
+        # an ABI bool is the top bit of its byte
+        SetBit(Bytes("base16", "00"), Int(0), bonus.load()),
+        Itob(share.load()),
+      ),
+      Approve(),
+    )
+
+
+  @Subroutine(TealType.none)
+  def reveal():
+    return Seq(
+      Assert(
+        And(
+          Global.group_size() == Int(1),
+          Txn.group_index() == Int(0),
+          Gtxn[0].rekey_to() == Global.zero_address(),
+
+          # args are the numbers and the salt
+          Txn.application_args.length() == Int(3),
+          is_abi_bytes(Txn.application_args[1]),
+          is_abi_bytes(Txn.application_args[2]),
+
+          Global.round() <= App.globalGet(global_reveal_by),
+          Len(App.localGet(Int(0), local_commitment)) == Int(SEALED_LENGTH),
+          Sha512_256(Concat(abi_bytes(Txn.application_args[1]), abi_bytes(Txn.application_args[2]))) == App.localGet(Int(0), local_commitment),
+          is_valid_ticket(abi_bytes(Txn.application_args[1])),
+        ),
+      ),
+      App.localPut(Int(0), local_commitment, abi_bytes(Txn.application_args[1])),
+      App.globalPut(global_num_sealed, App.globalGet(global_num_sealed) - Int(1)),
+      Approve(),
+    )
+
+  # Opts the app into its asset, so it can take wagers in it. The creator
+  # has to fund the escrow with the extra min balance first.
+  @Subroutine(TealType.none)
+  def opt_in_asset():
+    return Seq(
+      Assert(
+        And(
+          Txn.sender() == Global.creator_address(),
+          Global.group_size() == Int(1),
+          Txn.group_index() == Int(0),
+          Gtxn[0].rekey_to() == Global.zero_address(),
+
+          App.globalGet(global_asset) != Int(0),
+          Txn.fee() >= Global.min_txn_fee() * Int(2),
+        ),
+      ),
       InnerTxnBuilder.Begin(),
       InnerTxnBuilder.SetFields(
         {
-          TxnField.type_enum: TxnType.Payment,
-          TxnField.receiver: Txn.accounts[Int(0)],
-          TxnField.amount: App.globalGet(global_6_prize),
+          TxnField.type_enum: TxnType.AssetTransfer,
+          TxnField.xfer_asset: App.globalGet(global_asset),
+          TxnField.asset_receiver: Global.current_application_address(),
+          TxnField.asset_amount: Int(0),
           TxnField.fee: Int(0),
         }
       ),
       InnerTxnBuilder.Submit(),
+      Approve(),
+    )
+
+  # The treasury is empty, or up to MAX_BENEFICIARIES beneficiaries whose
+  # shares add up to the whole fee
+  @Subroutine(TealType.uint64)
+  def is_valid_treasury(t: Expr):
+    i = ScratchVar()
+    total = ScratchVar()
+    return Seq(
+      If(Len(t) == Int(0)).Then(Return(Int(1))),
+      If(
+        Or(
+          Len(t) % Int(BENEFICIARY_LENGTH) != Int(0),
+          Len(t) > Int(BENEFICIARY_LENGTH * MAX_BENEFICIARIES),
+        ),
+      ).Then(Return(Int(0))),
+      total.store(Int(0)),
+      For(i.store(Int(0)), i.load() < Len(t), i.store(i.load() + Int(BENEFICIARY_LENGTH))).Do(
+        total.store(total.load() + ExtractUint64(t, i.load() + Int(32))),
+      ),
+      Return(total.load() == Int(10000)),
+    )
 
+  # Sets the fields of the inner txns paying each beneficiary their share of
+  # fee, or the creator all of it if there's no treasury. The last
+  # beneficiary is paid what's left, so none of the fee is lost to rounding.
+  @Subroutine(TealType.none)
+  def pay_house_fee(fee: Expr):
+    n = ScratchVar()
+    paid = ScratchVar()
+    share = ScratchVar()
+    i = ScratchVar()
+    return Seq(
+      If(Len(App.globalGet(global_treasury)) == Int(0)).Then(
+        Seq(
+          payout_fields(Global.creator_address(), fee),
+          Return(),
+        ),
+      ),
+      n.store(Len(App.globalGet(global_treasury)) / Int(BENEFICIARY_LENGTH)),
+      paid.store(Int(0)),
+      For(i.store(Int(0)), i.load() < n.load(), i.store(i.load() + Int(1))).Do(
+        Seq(
+          If(i.load() > Int(0)).Then(InnerTxnBuilder.Next()),
+          If(i.load() == n.load() - Int(1))
+          .Then(share.store(fee - paid.load()))
+          .Else(
+            share.store(
+              fee_share(
+                fee,
+                ExtractUint64(App.globalGet(global_treasury), i.load() * Int(BENEFICIARY_LENGTH) + Int(32)),
+              ),
+            ),
+          ),
+          paid.store(paid.load() + share.load()),
+          payout_fields(
+            Extract(App.globalGet(global_treasury), i.load() * Int(BENEFICIARY_LENGTH), Int(32)),
+            share.load(),
+          ),
+        ),
+      ),
+    )
+
+  # Adds its deposit arg to the guaranteed prize of the tier in its other
+  # arg, which SetDraw then can't set any lower. Anyone can sponsor a
+  # tier until the draw is set, attributing it in the app call's note.
+  @Subroutine(TealType.none)
+  def sponsor():
+    tier = ScratchVar()
+    amount = ScratchVar()
+    return Seq(
+      Assert(
+        And(
+          Global.group_size() == Int(2),
+          Txn.group_index() == Int(1),
+          *[Gtxn[i].rekey_to() == Global.zero_address() for i in range(2)],
+          is_deposit(),
+
+          Txn.application_args.length() == Int(2),
+          App.globalGet(global_draw) == Bytes(""),
+        ),
+      ),
+      tier.store(Btoi(Txn.application_args[1])),
+      Assert(
+        And(
+          tier.load() >= Int(1),
+          tier.load() <= App.globalGet(global_picks),
+        ),
+      ),
+      If(App.globalGet(global_asset) == Int(0))
+      .Then(amount.store(Gtxn[0].amount()))
+      .Else(amount.store(Gtxn[0].asset_amount())),
+      App.globalPut(tier_key(tier.load(), "g"), App.globalGet(tier_key(tier.load(), "g")) + amount.load()),
+      App.globalPut(global_sponsored, App.globalGet(global_sponsored) + amount.load()),
       Approve(),
     )
 
+  # In a bonus game a ticket is a valid commitment followed by its bonus
+  # number, which has to be below bonusMax
+  @Subroutine(TealType.uint64)
+  def is_valid_ticket(c: Expr):
+    return Seq(
+      If(App.globalGet(global_bonus_max) == Int(0)).Then(Return(is_valid_commitment(c))),
+      If(Len(c) == Int(0)).Then(Return(Int(0))),
+      Return(
+        And(
+          is_valid_commitment(picks_of(c)),
+          bonus_of(c) < App.globalGet(global_bonus_max),
+        ),
+      ),
+    )
+
+  # Publishes the draw while there are sealed tickets, which closes the app
+  # to commits and opens the reveal window. SetDraw then has to set the
+  # same draw.
+  @Subroutine(TealType.none)
+  def publish_draw():
+    return Seq(
+      Assert(
+        And(
+          Txn.sender() == Global.creator_address(),
+          Global.group_size() == Int(1),
+          Txn.group_index() == Int(0),
+          Gtxn[0].rekey_to() == Global.zero_address(),
+
+          Txn.application_args.length() == Int(2),
+          is_abi_bytes(Txn.application_args[1]),
+          Len(abi_bytes(Txn.application_args[1])) > Int(0),
+          App.globalGet(global_draw) == Bytes(""),
+        ),
+      ),
+      App.globalPut(global_draw, abi_bytes(Txn.application_args[1])),
+      App.globalPut(global_reveal_by, Global.round() + Int(REVEAL_ROUNDS)),
+      Approve(),
+    )
+
+  # Is amount*bps/10000, whose product can be wider than a uint64 where
+  # TEAL's `*` would fail, so it's multiplied into 128 bits
+  @Subroutine(TealType.uint64)
+  def fee_share(amount: Expr, bps: Expr):
+    return MulW(amount, bps).outputReducer(lambda hi, lo: DivW(hi, lo, Int(10000)))
+
+  # Makes n inner app calls, each adding OPUP_BUDGET to the opcode budget.
+  # Their fees are pooled from the app call's, so the escrow doesn't pay.
+  @Subroutine(TealType.none)
+  def op_up(n: Expr):
+    i = ScratchVar()
+    return For(i.store(Int(0)), i.load() < n, i.store(i.load() + Int(1))).Do(
+      InnerTxnBuilder.Execute(
+        {
+          TxnField.type_enum: TxnType.ApplicationCall,
+          TxnField.on_completion: OnComplete.DeleteApplication,
+          TxnField.approval_program: Bytes("base16", OPUP_PROGRAM),
+          TxnField.clear_state_program: Bytes("base16", OPUP_PROGRAM),
+          TxnField.fee: Int(0),
+        }
+      ),
+    )
+
 
   return program(
     init=Seq(
@@ -255,13 +908,35 @@ def approval():
           commit(),
         ],
         [
-          Txn.application_args[0] == op_set_draw,
+          Or(
+            Txn.application_args[0] == op_set_draw,
+            Txn.application_args[0] == op_set_draw_asset,
+          ),
           set_draw(),
         ],
         [
-          Txn.application_args[0] == op_claim,
+          Or(
+            Txn.application_args[0] == op_claim,
+            Txn.application_args[0] == op_claim_asset,
+          ),
           claim(),
         ],
+        [
+          Txn.application_args[0] == op_reveal,
+          reveal(),
+        ],
+        [
+          Txn.application_args[0] == op_opt_in_asset,
+          opt_in_asset(),
+        ],
+        [
+          Txn.application_args[0] == op_sponsor,
+          sponsor(),
+        ],
+        [
+          Txn.application_args[0] == op_publish_draw,
+          publish_draw(),
+        ],
       ),
       Reject()
     ),
@@ -270,6 +945,110 @@ def approval():
 def clear():
   return Approve()
 
+# Descriptions of the app's methods and the names of their args, which with
+# their signatures make up its ARC-4 contract
+METHODS = [
+  (CREATE, "Creates the app with its game parameters", [
+    "picks", "max_number", "price", "fee_bps", "rollover_min", "asset", "treasury", "bonus_max",
+  ]),
+  (COMMIT, "Buys the ticket (or sealed ticket) for the wager deposited", [
+    "ticket", "wager",
+  ]),
+  (SET_DRAW, "Sets the draw and the winners and prize of each tier, paying the house fee and rolling over what's unclaimed into the next app", [
+    "draw", "tiers", "bonus_tiers", "next_app", "next_app_address", "beneficiary_1", "beneficiary_2", "beneficiary_3",
+  ]),
+  (SET_DRAW_ASSET, "Sets the draw of an app taking wagers in asset, as set_draw does", [
+    "draw", "tiers", "bonus_tiers", "next_app", "next_app_address", "beneficiary_1", "beneficiary_2", "beneficiary_3", "asset",
+  ]),
+  (CLAIM, "Pays the sender's ticket its share of the prize of the tier it won", []),
+  (CLAIM_ASSET, "Pays the sender's ticket its share of the prize of the tier it won, in asset", [
+    "asset",
+  ]),
+  (REVEAL, "Replaces the sender's sealed ticket with its numbers, while the draw is published", [
+    "numbers", "salt",
+  ]),
+  (OPT_IN_ASSET, "Opts the app into the asset it takes wagers in", [
+    "asset",
+  ]),
+  (SPONSOR, "Adds the deposit to the prize guaranteed for tier", [
+    "tier", "deposit",
+  ]),
+  (PUBLISH_DRAW, "Publishes the draw before it's set, so that sealed tickets can be revealed", [
+    "draw",
+  ]),
+]
+
+# Descriptions of the events the app logs and the names of their args
+EVENTS = [
+  (TICKET_PURCHASED, "A ticket (or sealed ticket) was bought", [
+    "player", "wager", "ticket",
+  ]),
+  (DRAW_SET, "The draw was set and the house fee paid", [
+    "house_fee", "draw",
+  ]),
+  (ROLLOVER, "What's unclaimed was rolled over into the next app", [
+    "next_app", "amount",
+  ]),
+  (PRIZE_CLAIMED, "A ticket matching picks of the draw, and its bonus if bonus is set, claimed amount", [
+    "player", "picks", "bonus", "amount",
+  ]),
+]
+
+def abi_arg_types(signature: str) -> list:
+  # Splits the args of signature on the commas outside tuples
+  args = signature[signature.index("(") + 1:signature.rindex(")")]
+  types, depth, start = [], 0, 0
+  for i, c in enumerate(args):
+    if c == "(":
+      depth += 1
+    elif c == ")":
+      depth -= 1
+    elif c == "," and depth == 0:
+      types.append(args[start:i])
+      start = i + 1
+  if args:
+    types.append(args[start:])
+  return types
+
+# The ARC-32 app spec: the ARC-4 contract, how each method can be called,
+# and the app's state schema
+def app_spec() -> dict:
+  methods = []
+  hints = {}
+  for signature, desc, names in METHODS:
+    methods.append({
+      "name": signature[:signature.index("(")],
+      "desc": desc,
+      "args": [{"type": t, "name": n} for t, n in zip(abi_arg_types(signature), names)],
+      "returns": {"type": signature[signature.rindex(")") + 1:]},
+    })
+    hints[signature] = {
+      "call_config": {"no_op": "CREATE" if signature == CREATE else "CALL"},
+    }
+
+  return {
+    "hints": hints,
+    "state": {
+      "global": {"num_byte_slices": numGlobalByteslices, "num_uints": numGlobalUints},
+      "local": {"num_byte_slices": numLocalByteslices, "num_uints": numLocalUints},
+    },
+    "contract": {
+      "name": "algokeno",
+      "desc": "A lotto of rounds of tickets of picks numbers, each round its own app",
+      "methods": methods,
+      # ARC-28
+      "events": [
+        {
+          "name": signature[:signature.index("(")],
+          "desc": desc,
+          "args": [{"type": t, "name": n} for t, n in zip(abi_arg_types(signature), names)],
+        }
+        for signature, desc, names in EVENTS
+      ],
+    },
+    "bare_call_config": {"opt_in": "CALL"},
+  }
+
 def program(
     init: Expr = Reject(),
     delete: Expr = Reject(),
@@ -331,3 +1110,6 @@ if __name__ == "__main__":
     schema = json.dumps(schemad)
     f.write(schema)
 
+  with open("application.json", "w") as f:
+    f.write(json.dumps(app_spec(), indent=2))
+
@@ -0,0 +1,267 @@
+from pyteal import *
+import json
+
+import contract
+from contract import program, GlobalUint, GlobalByteslice, LocalUint, LocalByteslice
+
+MIN_SPOTS = 1
+MAX_SPOTS = 10
+MAX_NUMBER = 80
+NUM_DRAWN = 20
+MIN_WAGER = 1000000
+
+def approval():
+
+  global_num_tickets = GlobalUint("numTickets")
+
+  global_draw = GlobalByteslice("draw")
+
+  # One paytable row per number of spots, keyed by "pt" followed by the
+  # spots as a single byte. Each row holds a uint16 multiplier of the wager
+  # for each number of hits, from 0 up to the number of spots.
+  for spots in range(MIN_SPOTS, MAX_SPOTS + 1):
+    GlobalByteslice("pt%d" % spots)
+
+  local_wager = LocalUint("wager")
+  local_picks = LocalByteslice("picks")
+
+
+  op_set_paytable = Bytes("SetPaytable")
+  op_commit = Bytes("Commit")
+  op_set_draw = Bytes("SetDraw")
+  op_claim = Bytes("Claim")
+
+  def paytable_key(spots: Expr) -> Expr:
+    return Concat(Bytes("pt"), Extract(Itob(spots), Int(7), Int(1)))
+
+  @Subroutine(TealType.none)
+  def init():
+    return Seq(
+      App.globalPut(global_num_tickets, Int(0)),
+      App.globalPut(global_draw, Bytes("base64", "")),
+      Approve(),
+    )
+
+  @Subroutine(TealType.none)
+  def opt_in():
+    return Seq(
+      App.localPut(Int(0), local_wager, Int(0)),
+      App.localPut(Int(0), local_picks, Bytes("base64", "")),
+      Approve(),
+    )
+
+  # Numbers are valid if there are between min_len and max_len of them,
+  # each from 1 to MAX_NUMBER, in strictly increasing order
+  @Subroutine(TealType.uint64)
+  def is_valid_numbers(n: Expr, min_len: Expr, max_len: Expr):
+    i = ScratchVar()
+    prev = ScratchVar()
+    return Seq(
+      If(Or(Len(n) < min_len, Len(n) > max_len)).Then(Return(Int(0))),
+      prev.store(Int(0)),
+      For(i.store(Int(0)), i.load() < Len(n), i.store(i.load() + Int(1))).Do(
+        Seq(
+          If(
+            Or(
+              GetByte(n, i.load()) <= prev.load(),
+              GetByte(n, i.load()) > Int(MAX_NUMBER),
+            )
+          ).Then(Return(Int(0))),
+          prev.store(GetByte(n, i.load())),
+        ),
+      ),
+      Return(Int(1)),
+    )
+
+  # Counts the numbers in both picks and draw, which are both sorted
+  @Subroutine(TealType.uint64)
+  def count_hits(picks: Expr, draw: Expr):
+    i = ScratchVar()
+    j = ScratchVar()
+    hits = ScratchVar()
+    return Seq(
+      i.store(Int(0)),
+      j.store(Int(0)),
+      hits.store(Int(0)),
+      While(And(i.load() < Len(picks), j.load() < Len(draw))).Do(
+        If(GetByte(picks, i.load()) == GetByte(draw, j.load()))
+        .Then(
+          Seq(
+            hits.store(hits.load() + Int(1)),
+            i.store(i.load() + Int(1)),
+            j.store(j.load() + Int(1)),
+          ),
+        )
+        .ElseIf(GetByte(picks, i.load()) < GetByte(draw, j.load()))
+        .Then(i.store(i.load() + Int(1)))
+        .Else(j.store(j.load() + Int(1))),
+      ),
+      Return(hits.load()),
+    )
+
+  @Subroutine(TealType.none)
+  def set_paytable():
+    return Seq(
+      Assert(
+        And(
+          Txn.sender() == Global.creator_address(),
+          Global.group_size() == Int(1),
+          Gtxn[0].rekey_to() == Global.zero_address(),
+          Txn.application_args.length() == Int(3),
+
+          # The paytable can't change under tickets already bought
+          App.globalGet(global_num_tickets) == Int(0),
+
+          Btoi(Txn.application_args[1]) >= Int(MIN_SPOTS),
+          Btoi(Txn.application_args[1]) <= Int(MAX_SPOTS),
+          Len(Txn.application_args[2]) == (Btoi(Txn.application_args[1]) + Int(1)) * Int(2),
+        ),
+      ),
+      App.globalPut(paytable_key(Btoi(Txn.application_args[1])), Txn.application_args[2]),
+      Approve(),
+    )
+
+  @Subroutine(TealType.none)
+  def commit():
+    paytable_row = App.globalGetEx(Int(0), paytable_key(Len(Txn.application_args[1])))
+    return Seq(
+      paytable_row,
+      Assert(
+        And(
+          Global.group_size() == Int(2),
+          Txn.group_index() == Int(0),
+          *[Gtxn[i].rekey_to() == Global.zero_address() for i in range(2)],
+
+          # second transaction is wager payment
+          Gtxn[1].type_enum() == TxnType.Payment,
+          Gtxn[1].receiver() == Global.current_application_address(),
+          Gtxn[1].close_remainder_to() == Global.zero_address(),
+          Gtxn[1].amount() >= Int(MIN_WAGER),
+
+          Txn.application_args.length() == Int(2),
+          App.globalGet(global_draw) == Bytes(""),
+
+          is_valid_numbers(Txn.application_args[1], Int(MIN_SPOTS), Int(MAX_SPOTS)),
+          paytable_row.hasValue(),
+        ),
+      ),
+      App.localPut(Txn.sender(), local_wager, Gtxn[1].amount()),
+      App.localPut(Txn.sender(), local_picks, Txn.application_args[1]),
+      App.globalPut(
+        global_num_tickets,
+        App.globalGet(global_num_tickets) + Int(1),
+      ),
+      Approve(),
+    )
+
+  @Subroutine(TealType.none)
+  def set_draw():
+    return Seq(
+      Assert(
+        And(
+          Txn.sender() == Global.creator_address(),
+          Global.group_size() == Int(1),
+          Gtxn[0].rekey_to() == Global.zero_address(),
+          Txn.application_args.length() == Int(2),
+          App.globalGet(global_draw) == Bytes(""),
+
+          is_valid_numbers(Txn.application_args[1], Int(NUM_DRAWN), Int(NUM_DRAWN)),
+        ),
+      ),
+      App.globalPut(global_draw, Txn.application_args[1]),
+      Approve(),
+    )
+
+  @Subroutine(TealType.none)
+  def claim():
+    paytable_row = App.globalGetEx(Int(0), paytable_key(Len(App.localGet(Int(0), local_picks))))
+    hits = ScratchVar()
+    payout = ScratchVar()
+    return Seq(
+      Assert(
+        And(
+          Global.group_size() == Int(1),
+          Gtxn[0].rekey_to() == Global.zero_address(),
+
+          Txn.fee() >= Global.min_txn_fee() * Int(2),
+
+          App.globalGet(global_draw) != Bytes(""),
+          App.localGet(Int(0), local_wager) >= Int(MIN_WAGER),
+          App.localGet(Int(0), local_picks) != Bytes(""),
+        ),
+      ),
+      paytable_row,
+      Assert(paytable_row.hasValue()),
+
+      hits.store(count_hits(App.localGet(Int(0), local_picks), App.globalGet(global_draw))),
+      payout.store(
+        App.localGet(Int(0), local_wager) * ExtractUint16(paytable_row.value(), hits.load() * Int(2)),
+      ),
+      Assert(payout.load() > Int(0)),
+
+      # Each ticket can only be claimed once
+      App.localPut(Int(0), local_wager, Int(0)),
+      App.localPut(Int(0), local_picks, Bytes("base64", "")),
+
+      InnerTxnBuilder.Begin(),
+      InnerTxnBuilder.SetFields(
+        {
+          TxnField.type_enum: TxnType.Payment,
+          TxnField.receiver: Txn.accounts[Int(0)],
+          TxnField.amount: payout.load(),
+          TxnField.fee: Int(0),
+        }
+      ),
+      InnerTxnBuilder.Submit(),
+
+      Approve(),
+    )
+
+
+  return program(
+    init=Seq(
+      init(),
+      Approve(),
+    ),
+    opt_in=Seq(
+      opt_in(),
+      Approve(),
+    ),
+    no_op=Seq(
+      Cond(
+        [
+          Txn.application_args[0] == op_set_paytable,
+          set_paytable(),
+        ],
+        [
+          Txn.application_args[0] == op_commit,
+          commit(),
+        ],
+        [
+          Txn.application_args[0] == op_set_draw,
+          set_draw(),
+        ],
+        [
+          Txn.application_args[0] == op_claim,
+          claim(),
+        ],
+      ),
+      Reject()
+    ),
+  )
+
+if __name__ == "__main__":
+
+  with open("keno_approval.teal", "w") as f:
+    compiled = compileTeal(approval(), mode=Mode.Application, version=MAX_TEAL_VERSION)
+    f.write(compiled)
+
+  with open("keno_schema.json", "w") as f:
+    schemad = {
+      "global_byte_slices": contract.numGlobalByteslices,
+      "global_uints": contract.numGlobalUints,
+      "local_byte_slices": contract.numLocalByteslices,
+      "local_uints": contract.numLocalUints,
+    }
+    schema = json.dumps(schemad)
+    f.write(schema)
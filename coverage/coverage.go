@@ -0,0 +1,288 @@
+// Package coverage maps the execution traces returned by algod's dryrun
+// endpoint back onto the lines of a TEAL source file, to report which
+// instructions and branch outcomes of a contract have been exercised.
+//
+// Dryrun traces refer to lines of the program's disassembly rather than the
+// source. The two are matched up by instruction order, which works because
+// each instruction in the source assembles to exactly one opcode. Traces of
+// programs whose instructions don't match the source are ignored.
+//
+// PyTeal (at the version this repo compiles with) doesn't emit a source map,
+// but it does precede each subroutine with a `// name` comment naming the
+// Python function, so coverage is also summarised per subroutine.
+package coverage
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+)
+
+// Source is a parsed TEAL source file
+type Source struct {
+	Path string
+	Lines []string
+
+	// instrs holds the index in Lines of each instruction, in order
+	instrs []int
+
+	// subroutines holds the `// name` comment preceding each line, if any
+	subroutines []string
+}
+
+// Load reads and parses the TEAL source file at path
+func Load(path string) (*Source, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, string(b)), nil
+}
+
+// Parse parses TEAL source text, path is only used for reporting
+func Parse(path, text string) *Source {
+
+	s := &Source{
+		Path: path,
+		Lines: strings.Split(strings.TrimRight(text, "\n"), "\n"),
+	}
+
+	var subroutine string
+	for i, line := range s.Lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "//"):
+			subroutine = strings.TrimSpace(strings.TrimPrefix(line, "//"))
+		case strings.HasPrefix(line, "#"), strings.HasSuffix(line, ":"):
+		default:
+			s.instrs = append(s.instrs, i)
+		}
+		s.subroutines = append(s.subroutines, subroutine)
+	}
+	return s
+}
+
+// opcode returns the opcode name of the instruction on line i
+func (s *Source) opcode(i int) string {
+
+	return opcode(s.Lines[i])
+}
+
+func opcode(line string) string {
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// sameOpcode returns whether the source opcode src can assemble to the
+// disassembled opcode dis, allowing for the assembler's constant blocks
+func sameOpcode(src, dis string) bool {
+
+	switch src {
+	case "int":
+		return dis == "pushint" || strings.HasPrefix(dis, "intc")
+	case "byte", "addr", "method":
+		return dis == "pushbytes" || strings.HasPrefix(dis, "bytec")
+	}
+	return src == dis
+}
+
+// Profile accumulates coverage of a Source
+type Profile struct {
+	Source *Source
+
+	// Hits counts how many times each line was executed
+	Hits []uint64
+
+	// Taken and NotTaken count the outcomes of each conditional branch line
+	Taken []uint64
+	NotTaken []uint64
+
+	// Failed counts how many times execution failed on each line, e.g. for
+	// an `assert` or `err`
+	Failed []uint64
+}
+
+// New returns an empty profile of s
+func New(s *Source) *Profile {
+
+	return &Profile{
+		Source: s,
+		Hits: make([]uint64, len(s.Lines)),
+		Taken: make([]uint64, len(s.Lines)),
+		NotTaken: make([]uint64, len(s.Lines)),
+		Failed: make([]uint64, len(s.Lines)),
+	}
+}
+
+// Record adds the trace of a dryrun app call to the profile. It returns
+// false, without recording anything, if the traced program isn't the source.
+func (p *Profile) Record(res models.DryrunTxnResult) bool {
+
+	lineMap, ok := p.mapDisassembly(res.Disassembly)
+	if !ok {
+		return false
+	}
+
+	var lines []int
+	for _, state := range res.AppCallTrace {
+		line, ok := lineMap[int(state.Line)]
+		if !ok {
+			continue
+		}
+		lines = append(lines, line)
+		p.Hits[line]++
+		if state.Error != "" {
+			p.Failed[line]++
+		}
+	}
+
+	for i, line := range lines {
+		if i+1 >= len(lines) || !isConditionalBranch(p.Source.opcode(line)) {
+			continue
+		}
+		if lines[i+1] == p.fallthroughLine(line) {
+			p.NotTaken[line]++
+		} else {
+			p.Taken[line]++
+		}
+	}
+	return true
+}
+
+// mapDisassembly maps each instruction line of the disassembly onto the
+// source line of the same instruction
+func (p *Profile) mapDisassembly(disassembly []string) (map[int]int, bool) {
+
+	lineMap := make(map[int]int)
+	var n int
+	for i, line := range disassembly {
+		op := opcode(line)
+		switch {
+		case op == "", strings.HasPrefix(op, "#"), strings.HasPrefix(op, "//"), strings.HasSuffix(op, ":"):
+			continue
+		case (op == "intcblock" || op == "bytecblock") && n == 0:
+			// Added by the assembler ahead of the source's first instruction
+			continue
+		}
+
+		if n >= len(p.Source.instrs) {
+			return nil, false
+		}
+		srcLine := p.Source.instrs[n]
+		if !sameOpcode(p.Source.opcode(srcLine), op) {
+			return nil, false
+		}
+		lineMap[i] = srcLine
+		n++
+	}
+
+	if n != len(p.Source.instrs) {
+		return nil, false
+	}
+	return lineMap, true
+}
+
+// fallthroughLine returns the line of the instruction following line
+func (p *Profile) fallthroughLine(line int) int {
+
+	for _, l := range p.Source.instrs {
+		if l > line {
+			return l
+		}
+	}
+	return -1
+}
+
+func isConditionalBranch(op string) bool {
+
+	return op == "bnz" || op == "bz"
+}
+
+// Summary is the coverage of a set of lines
+type Summary struct {
+	Instrs int
+	InstrsCovered int
+	Branches int
+	BranchesCovered int
+}
+
+func (s Summary) String() string {
+
+	return fmt.Sprintf(
+		"%s of instructions (%d/%d), %s of branch outcomes (%d/%d)",
+		percent(s.InstrsCovered, s.Instrs),
+		s.InstrsCovered,
+		s.Instrs,
+		percent(s.BranchesCovered, s.Branches),
+		s.BranchesCovered,
+		s.Branches,
+	)
+}
+
+func percent(n, total int) string {
+
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(total))
+}
+
+// Summary returns the coverage of the whole source
+func (p *Profile) Summary() Summary {
+
+	return p.summary(func(string) bool { return true })
+}
+
+// Subroutines returns the names of the source's subroutines in order
+func (p *Profile) Subroutines() []string {
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, l := range p.Source.instrs {
+		name := p.Source.subroutines[l]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// SubroutineSummary returns the coverage of the named subroutine, where ""
+// is the program's entry point preceding any subroutine
+func (p *Profile) SubroutineSummary(name string) Summary {
+
+	return p.summary(func(subroutine string) bool { return subroutine == name })
+}
+
+func (p *Profile) summary(include func(subroutine string) bool) Summary {
+
+	var s Summary
+	for _, l := range p.Source.instrs {
+		if !include(p.Source.subroutines[l]) {
+			continue
+		}
+		s.Instrs++
+		if p.Hits[l] > 0 {
+			s.InstrsCovered++
+		}
+		if isConditionalBranch(p.Source.opcode(l)) {
+			s.Branches += 2
+			if p.Taken[l] > 0 {
+				s.BranchesCovered++
+			}
+			if p.NotTaken[l] > 0 {
+				s.BranchesCovered++
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,140 @@
+package coverage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/stretchr/testify/require"
+)
+
+const testSource = `#pragma version 6
+txn ApplicationID
+int 0
+==
+bnz main_l2
+int 0
+return
+main_l2:
+callsub init_0
+int 1
+return
+
+// init
+init_0:
+byte "x"
+int 1
+app_global_put
+retsub
+`
+
+var testDisassembly = []string{
+	"#pragma version 6",
+	"intcblock 0 1",
+	"bytecblock 0x78",
+	"txn ApplicationID",
+	"intc_0 // 0",
+	"==",
+	"bnz label1",
+	"intc_0 // 0",
+	"return",
+	"label1:",
+	"callsub label2",
+	"intc_1 // 1",
+	"return",
+	"label2:",
+	"bytec_0 // \"x\"",
+	"intc_1 // 1",
+	"app_global_put",
+	"retsub",
+	"",
+}
+
+func trace(lines ...uint64) []models.DryrunState {
+
+	var states []models.DryrunState
+	for _, l := range lines {
+		states = append(states, models.DryrunState{Line: l})
+	}
+	return states
+}
+
+func TestRecord(t *testing.T) {
+
+	p := New(Parse("test.teal", testSource))
+
+	// App creation: takes the branch into init
+	require.True(t, p.Record(models.DryrunTxnResult{
+		Disassembly: testDisassembly,
+		AppCallTrace: trace(1, 2, 3, 4, 5, 6, 10, 14, 15, 16, 17, 11, 12),
+	}))
+
+	require.Equal(t, Summary{Instrs: 13, InstrsCovered: 11, Branches: 2, BranchesCovered: 1}, p.Summary())
+	require.Equal(t, []string{"", "init"}, p.Subroutines())
+	require.Equal(t, Summary{Instrs: 4, InstrsCovered: 4}, p.SubroutineSummary("init"))
+
+	// App call: falls through and fails on the final return
+	failing := trace(1, 2, 3, 4, 5, 6, 7, 8)
+	failing[len(failing)-1].Error = "return"
+	require.True(t, p.Record(models.DryrunTxnResult{
+		Disassembly: testDisassembly,
+		AppCallTrace: failing,
+	}))
+
+	require.Equal(t, Summary{Instrs: 13, InstrsCovered: 13, Branches: 2, BranchesCovered: 2}, p.Summary())
+	require.Equal(t, uint64(2), p.Hits[4])
+	require.Equal(t, uint64(1), p.Taken[4])
+	require.Equal(t, uint64(1), p.NotTaken[4])
+	require.Equal(t, uint64(1), p.Failed[6])
+}
+
+func TestRecordIgnoresOtherPrograms(t *testing.T) {
+
+	p := New(Parse("test.teal", testSource))
+
+	require.False(t, p.Record(models.DryrunTxnResult{
+		Disassembly: []string{
+			"#pragma version 6",
+			"pushint 1",
+			"return",
+		},
+		AppCallTrace: trace(1, 2),
+	}))
+
+	changed := append([]string{}, testDisassembly...)
+	changed[16] = "app_local_put"
+	require.False(t, p.Record(models.DryrunTxnResult{
+		Disassembly: changed,
+		AppCallTrace: trace(1, 2),
+	}))
+
+	require.Equal(t, 0, p.Summary().InstrsCovered)
+}
+
+func TestWriteText(t *testing.T) {
+
+	p := New(Parse("test.teal", testSource))
+	require.True(t, p.Record(models.DryrunTxnResult{
+		Disassembly: testDisassembly,
+		AppCallTrace: trace(1, 2, 3, 4, 5, 6, 10, 14, 15, 16, 17, 11, 12),
+	}))
+
+	var buf bytes.Buffer
+	require.NoError(t, p.WriteText(&buf))
+	lines := strings.Split(buf.String(), "\n")
+
+	require.Equal(t, "test.teal: 84.6% of instructions (11/13), 50.0% of branch outcomes (1/2)", lines[0])
+	require.Contains(t, buf.String(), "      1       1/0        | bnz main_l2\n")
+	require.Contains(t, buf.String(), "  #####                  | int 0\n")
+}
+
+func TestWriteHTML(t *testing.T) {
+
+	p := New(Parse("test.teal", testSource))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, p))
+	require.Contains(t, buf.String(), `<tr class="uncovered"><td>#####</td><td>0/0</td><td></td><td>bnz main_l2</td></tr>`)
+	require.Contains(t, buf.String(), `<td>byte &#34;x&#34;</td>`)
+}
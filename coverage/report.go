@@ -0,0 +1,156 @@
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteText writes the profile as an annotated copy of the source. Each
+// instruction is prefixed with its hit count (or ##### if it never ran),
+// branches with their taken/not-taken counts and lines that failed with
+// their failure count.
+func (p *Profile) WriteText(w io.Writer) error {
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %v\n", p.Source.Path, p.Summary())
+	for _, name := range p.Subroutines() {
+		fmt.Fprintf(&b, "  %-20s %v\n", subroutineName(name), p.SubroutineSummary(name))
+	}
+	b.WriteString("\n")
+
+	for i, line := range p.Source.Lines {
+		l := p.line(i)
+		fmt.Fprintf(&b, "%7s %9s %6s | %s\n", l.Hits, l.Branch, l.Failed, line)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteHTML writes the profiles as an HTML page highlighting uncovered
+// instructions and partially covered branches
+func WriteHTML(w io.Writer, profiles ...*Profile) error {
+
+	type subroutine struct {
+		Name string
+		Summary Summary
+	}
+
+	type source struct {
+		Path string
+		Summary Summary
+		Subroutines []subroutine
+		Lines []line
+	}
+
+	var data []source
+	for _, p := range profiles {
+		src := source{
+			Path: p.Source.Path,
+			Summary: p.Summary(),
+		}
+		for _, name := range p.Subroutines() {
+			src.Subroutines = append(src.Subroutines, subroutine{
+				Name: subroutineName(name),
+				Summary: p.SubroutineSummary(name),
+			})
+		}
+		for i := range p.Source.Lines {
+			src.Lines = append(src.Lines, p.line(i))
+		}
+		data = append(data, src)
+	}
+
+	return htmlReport.Execute(w, data)
+}
+
+// line is the annotation of a single source line in a report
+type line struct {
+	Text string
+	Hits string
+	Branch string
+	Failed string
+
+	// Class is one of "", "covered", "uncovered" or "partial"
+	Class string
+}
+
+func (p *Profile) line(i int) line {
+
+	l := line{
+		Text: p.Source.Lines[i],
+	}
+
+	if !p.isInstr(i) {
+		return l
+	}
+
+	if p.Hits[i] == 0 {
+		l.Hits = "#####"
+		l.Class = "uncovered"
+	} else {
+		l.Hits = strconv.FormatUint(p.Hits[i], 10)
+		l.Class = "covered"
+	}
+
+	if isConditionalBranch(p.Source.opcode(i)) {
+		l.Branch = fmt.Sprintf("%d/%d", p.Taken[i], p.NotTaken[i])
+		if p.Hits[i] > 0 && (p.Taken[i] == 0 || p.NotTaken[i] == 0) {
+			l.Class = "partial"
+		}
+	}
+
+	if p.Failed[i] > 0 {
+		l.Failed = fmt.Sprintf("!%d", p.Failed[i])
+	}
+	return l
+}
+
+func (p *Profile) isInstr(i int) bool {
+
+	for _, l := range p.Source.instrs {
+		if l == i {
+			return true
+		}
+	}
+	return false
+}
+
+func subroutineName(name string) string {
+
+	if name == "" {
+		return "(main)"
+	}
+	return name
+}
+
+var htmlReport = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>TEAL coverage</title>
+<style>
+body { font-family: sans-serif; }
+td { padding: 0 0.5em; font-family: monospace; white-space: pre; }
+tr.covered { background: #dfd; }
+tr.uncovered { background: #fdd; }
+tr.partial { background: #ffd; }
+</style>
+</head>
+<body>
+{{range .}}<h1>{{.Path}}</h1>
+<p>{{.Summary}}</p>
+<table>
+{{range .Subroutines}}<tr><td>{{.Name}}</td><td>{{.Summary}}</td></tr>
+{{end}}</table>
+<h2>Source</h2>
+<table>
+<tr><th>hits</th><th>taken/not</th><th>failed</th><th></th></tr>
+{{range .Lines}}<tr class="{{.Class}}"><td>{{.Hits}}</td><td>{{.Branch}}</td><td>{{.Failed}}</td><td>{{.Text}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,403 @@
+// Package dispenser funds sandnet accounts on demand from the accounts held
+// in KMD, and reclaims whatever is left in them once they're done with.
+//
+// Each KMD account is used as a separate funder with its own lock, so tests
+// running in parallel don't race on a single funding account.
+package dispenser
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno/client"
+)
+
+const (
+	// MinBalance is the minimum balance of an account with no apps or assets
+	MinBalance = 100_000
+
+	// Per app/asset opted into or created, extra page or schema entry, see
+	// https://developer.algorand.org/docs/get-details/parameter_tables/
+	minBalancePerAsset = 100_000
+	minBalancePerApp = 100_000
+	minBalancePerExtraPage = 100_000
+	minBalancePerUint = 28_500
+	minBalancePerByteSlice = 50_000
+
+	// maxGroupSize is the most transactions allowed in an atomic group
+	maxGroupSize = 16
+
+	waitRounds = 4
+)
+
+var (
+	ErrNoFunders = errors.New("no funder accounts in KMD")
+	ErrDrained = errors.New("no funder has enough balance")
+)
+
+// Config holds the dispenser's configurable amounts
+type Config struct {
+
+	// DefaultAmount is funded when Fund is called with an amount of 0
+	DefaultAmount uint64
+
+	// FunderReserve is left in each funder, on top of what's being sent,
+	// so the funders are never drained completely
+	FunderReserve uint64
+}
+
+// DefaultConfig funds 20 Algo per account, as the tests always have
+var DefaultConfig = Config{
+	DefaultAmount: 20_000_000,
+	FunderReserve: 1_000_000,
+}
+
+type funder struct {
+	mu sync.Mutex
+	acc crypto.Account
+}
+
+// Dispenser funds accounts from a pool of KMD accounts and tracks how much
+// each account was sent
+type Dispenser struct {
+	cl *client.Context
+	config Config
+	funders []*funder
+
+	mu sync.Mutex
+	next int
+	funded map[types.Address]uint64
+	reclaimed map[types.Address]uint64
+}
+
+// New returns a dispenser which funds from every account in the first
+// wallet of cl's KMD
+func New(cl *client.Context, walletPassword string, config Config) (*Dispenser, error) {
+
+	kmdCl := cl.KMD
+
+	w, err := kmdCl.ListWallets()
+	if err != nil {
+		return nil, err
+	}
+	if len(w.Wallets) == 0 {
+		return nil, ErrNoFunders
+	}
+
+	handleResp, err := kmdCl.InitWalletHandle(w.Wallets[0].ID, walletPassword)
+	if err != nil {
+		return nil, err
+	}
+	handle := handleResp.WalletHandleToken
+	defer kmdCl.ReleaseWalletHandle(handle)
+
+	keys, err := kmdCl.ListKeys(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	var funders []crypto.Account
+	for _, addr := range keys.Addresses {
+		res, err := kmdCl.ExportKey(handle, walletPassword, addr)
+		if err != nil {
+			return nil, err
+		}
+		acc, err := crypto.AccountFromPrivateKey(res.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		funders = append(funders, acc)
+	}
+
+	return NewWithFunders(cl, config, funders...)
+}
+
+// NewWithFunders returns a dispenser which funds from the given accounts,
+// sending through cl's algod with its cached suggested params
+func NewWithFunders(cl *client.Context, config Config, funders ...crypto.Account) (*Dispenser, error) {
+
+	if len(funders) == 0 {
+		return nil, ErrNoFunders
+	}
+
+	d := &Dispenser{
+		cl: cl,
+		config: config,
+		funded: make(map[types.Address]uint64),
+		reclaimed: make(map[types.Address]uint64),
+	}
+	for _, acc := range funders {
+		d.funders = append(d.funders, &funder{acc: acc})
+	}
+	return d, nil
+}
+
+// Fund sends amount microalgos (or the configured default if amount is 0)
+// to each of addrs, and waits for the payments to be confirmed
+func (d *Dispenser) Fund(ctx context.Context, amount uint64, addrs ...types.Address) error {
+
+	if amount == 0 {
+		amount = d.config.DefaultAmount
+	}
+
+	for len(addrs) > 0 {
+		n := len(addrs)
+		if n > maxGroupSize {
+			n = maxGroupSize
+		}
+		if err := d.fundGroup(ctx, amount, addrs[:n]); err != nil {
+			return err
+		}
+		addrs = addrs[n:]
+	}
+	return nil
+}
+
+func (d *Dispenser) fundGroup(ctx context.Context, amount uint64, addrs []types.Address) error {
+
+	params, err := d.cl.SuggestedParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	total := (amount + params.MinFee) * uint64(len(addrs))
+	f, err := d.lockFunder(ctx, total)
+	if err != nil {
+		return err
+	}
+	defer f.mu.Unlock()
+
+	var atc future.AtomicTransactionComposer
+	for _, addr := range addrs {
+		tx, err := future.MakePaymentTxn(
+			f.acc.Address.String(),
+			addr.String(),
+			amount,
+			nonce(),
+			"",
+			params,
+		)
+		if err != nil {
+			return err
+		}
+		err = atc.AddTransaction(future.TransactionWithSigner{
+			Txn: tx,
+			Signer: future.BasicAccountTransactionSigner{Account: f.acc},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := atc.Execute(d.cl.Algod, ctx, waitRounds); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, addr := range addrs {
+		d.funded[addr] += amount
+	}
+	return nil
+}
+
+// lockFunder locks and returns the next funder, in round robin order, which
+// can send total and keep its reserve
+func (d *Dispenser) lockFunder(ctx context.Context, total uint64) (*funder, error) {
+
+	d.mu.Lock()
+	start := d.next
+	d.next = (d.next + 1) % len(d.funders)
+	d.mu.Unlock()
+
+	for i := range d.funders {
+		f := d.funders[(start + i) % len(d.funders)]
+		f.mu.Lock()
+
+		balance, err := d.Balance(ctx, f.acc.Address)
+		if err != nil {
+			f.mu.Unlock()
+			return nil, err
+		}
+		if balance >= total + d.config.FunderReserve {
+			return f, nil
+		}
+		f.mu.Unlock()
+	}
+	return nil, ErrDrained
+}
+
+// Balance returns the balance of addr in microalgos
+func (d *Dispenser) Balance(ctx context.Context, addr types.Address) (uint64, error) {
+
+	info, err := d.cl.Algod.AccountInformation(addr.String()).Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return info.Amount, nil
+}
+
+// Funded returns how much each account has been funded with
+func (d *Dispenser) Funded() map[types.Address]uint64 {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	res := make(map[types.Address]uint64, len(d.funded))
+	for addr, amount := range d.funded {
+		res[addr] = amount
+	}
+	return res
+}
+
+// Reclaimed returns how much has been reclaimed from each account
+func (d *Dispenser) Reclaimed() map[types.Address]uint64 {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	res := make(map[types.Address]uint64, len(d.reclaimed))
+	for addr, amount := range d.reclaimed {
+		res[addr] = amount
+	}
+	return res
+}
+
+// Reclaim returns the leftover funds of each account to a funder. Accounts
+// with no apps or assets are closed out with a close-remainder payment;
+// others can't be closed, so everything above their minimum balance is sent
+// back instead. All accounts are attempted even if some fail, and the first
+// error is returned.
+func (d *Dispenser) Reclaim(ctx context.Context, accounts ...crypto.Account) error {
+
+	var firstErr error
+	var failed int
+	for _, acc := range accounts {
+		if err := d.reclaim(ctx, acc); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("reclaiming from %s: %w", acc.Address, err)
+			}
+			failed++
+		}
+	}
+	if failed > 1 {
+		return fmt.Errorf("%w (and %d more)", firstErr, failed-1)
+	}
+	return firstErr
+}
+
+func (d *Dispenser) reclaim(ctx context.Context, acc crypto.Account) error {
+
+	info, err := d.cl.Algod.AccountInformation(acc.Address.String()).Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	params, err := d.cl.SuggestedParams(ctx)
+	if err != nil {
+		return err
+	}
+	params.FlatFee = true
+	params.Fee = types.MicroAlgos(params.MinFee)
+
+	closeTo := canClose(info)
+	amount, ok := reclaimAmount(info, params.MinFee, closeTo)
+	if !ok {
+		return nil
+	}
+
+	to := d.funders[0].acc.Address
+	closeRemainderTo := ""
+	if closeTo {
+		closeRemainderTo = to.String()
+	}
+
+	tx, err := future.MakePaymentTxn(
+		acc.Address.String(),
+		to.String(),
+		amount,
+		nonce(),
+		closeRemainderTo,
+		params,
+	)
+	if err != nil {
+		return err
+	}
+
+	var atc future.AtomicTransactionComposer
+	err = atc.AddTransaction(future.TransactionWithSigner{
+		Txn: tx,
+		Signer: future.BasicAccountTransactionSigner{Account: acc},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := atc.Execute(d.cl.Algod, ctx, waitRounds); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if closeTo {
+		amount = info.Amount - params.MinFee
+	}
+	d.reclaimed[acc.Address] += amount
+	return nil
+}
+
+// canClose returns whether the account can be closed out, which needs it
+// to have no apps or assets
+func canClose(info models.Account) bool {
+
+	return info.TotalAppsOptedIn == 0 &&
+		info.TotalCreatedApps == 0 &&
+		info.TotalAssetsOptedIn == 0 &&
+		info.TotalCreatedAssets == 0
+}
+
+// reclaimAmount returns the amount to send back from the account. When
+// closing, the remainder goes with the close so the amount is 0. Returns
+// false if there's nothing worth sending.
+func reclaimAmount(info models.Account, fee uint64, close bool) (uint64, bool) {
+
+	if close {
+		return 0, info.Amount > fee
+	}
+
+	keep := MinAccountBalance(info) + fee
+	if info.Amount <= keep {
+		return 0, false
+	}
+	return info.Amount - keep, true
+}
+
+// MinAccountBalance returns the minimum balance the account has to keep
+// for the apps and assets it holds
+func MinAccountBalance(info models.Account) uint64 {
+
+	return MinBalance +
+		minBalancePerAsset * (info.TotalAssetsOptedIn + info.TotalCreatedAssets) +
+		minBalancePerApp * (info.TotalAppsOptedIn + info.TotalCreatedApps) +
+		minBalancePerExtraPage * info.AppsTotalExtraPages +
+		minBalancePerUint * info.AppsTotalSchema.NumUint +
+		minBalancePerByteSlice * info.AppsTotalSchema.NumByteSlice
+}
+
+// nonce returns a random note so identical payments sent in the same round
+// don't have the same transaction ID
+func nonce() []byte {
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
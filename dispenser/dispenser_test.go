@@ -0,0 +1,97 @@
+package dispenser
+
+import (
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinAccountBalance(t *testing.T) {
+
+	testCases := []struct{
+		Name string
+		Info models.Account
+		Expected uint64
+	}{
+		{
+			Name: "empty account",
+			Expected: 100_000,
+		},
+		{
+			Name: "player opted into the lotto app",
+			Info: models.Account{
+				TotalAppsOptedIn: 1,
+				AppsTotalSchema: models.ApplicationStateSchema{NumUint: 1, NumByteSlice: 1},
+			},
+			Expected: 100_000 + 100_000 + 28_500 + 50_000,
+		},
+		{
+			Name: "creator of two apps with an extra page",
+			Info: models.Account{
+				TotalCreatedApps: 2,
+				AppsTotalExtraPages: 1,
+				AppsTotalSchema: models.ApplicationStateSchema{NumUint: 10, NumByteSlice: 2},
+			},
+			Expected: 100_000 + 2*100_000 + 100_000 + 10*28_500 + 2*50_000,
+		},
+		{
+			Name: "asset holder",
+			Info: models.Account{
+				TotalAssetsOptedIn: 2,
+				TotalCreatedAssets: 1,
+			},
+			Expected: 100_000 + 3*100_000,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			require.Equal(t, test.Expected, MinAccountBalance(test.Info))
+		})
+	}
+}
+
+func TestReclaimAmount(t *testing.T) {
+
+	fee := uint64(1000)
+
+	// Accounts without apps are closed, so everything goes with the close
+	empty := models.Account{Amount: 20_000_000}
+	require.True(t, canClose(empty))
+	amount, ok := reclaimAmount(empty, fee, true)
+	require.True(t, ok)
+	require.Equal(t, uint64(0), amount)
+
+	// Accounts with apps keep their minimum balance
+	player := models.Account{
+		Amount: 20_000_000,
+		TotalAppsOptedIn: 1,
+		AppsTotalSchema: models.ApplicationStateSchema{NumUint: 1, NumByteSlice: 1},
+	}
+	require.False(t, canClose(player))
+	amount, ok = reclaimAmount(player, fee, false)
+	require.True(t, ok)
+	require.Equal(t, uint64(20_000_000 - 278_500 - 1000), amount)
+
+	// Nothing to send back once at the minimum balance
+	player.Amount = 278_500 + 1000
+	_, ok = reclaimAmount(player, fee, false)
+	require.False(t, ok)
+
+	_, ok = reclaimAmount(models.Account{Amount: fee}, fee, true)
+	require.False(t, ok)
+}
+
+func TestNewWithFunders(t *testing.T) {
+
+	_, err := NewWithFunders(nil, DefaultConfig)
+	require.ErrorIs(t, err, ErrNoFunders)
+
+	d, err := NewWithFunders(nil, DefaultConfig, crypto.GenerateAccount(), crypto.GenerateAccount())
+	require.NoError(t, err)
+	require.Len(t, d.funders, 2)
+	require.Empty(t, d.Funded())
+	require.Empty(t, d.Reclaimed())
+}
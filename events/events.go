@@ -0,0 +1,279 @@
+// Package events decodes the ARC-28 events the lotto app logs, so bots,
+// analytics and tests can learn what a call did without diffing the app's
+// state or reading its inner transactions.
+//
+// Each event is logged as the first 4 bytes of the sha512_256 of its
+// signature followed by its args ABI encoded as a tuple. Logs which aren't
+// one of the app's events are an error, as the app logs nothing else.
+package events
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/model"
+)
+
+// Signatures of the app's events, as in its ARC-32 app spec
+const (
+	TicketPurchasedSignature = "TicketPurchased(address,uint64,byte[])"
+	DrawSetSignature = "DrawSet(uint64,byte[])"
+	RolloverSignature = "Rollover(uint64,uint64)"
+	PrizeClaimedSignature = "PrizeClaimed(address,uint64,bool,uint64)"
+)
+
+var (
+	ErrUnknownEvent = errors.New("unknown event")
+	ErrMalformedEvent = errors.New("malformed event")
+)
+
+// Event is one of TicketPurchased, DrawSet, Rollover or PrizeClaimed
+type Event interface {
+	// Signature returns the ARC-28 signature of the event
+	Signature() string
+}
+
+// TicketPurchased is logged by commit
+type TicketPurchased struct {
+	Player types.Address
+
+	Wager uint64
+
+	// Ticket is the ticket's numbers, or its sealed commitment
+	Ticket algokeno.Commitment
+}
+
+func (TicketPurchased) Signature() string {
+
+	return TicketPurchasedSignature
+}
+
+// DrawSet is logged by set_draw
+type DrawSet struct {
+	// HouseFee is the fee paid to the beneficiaries (or the creator)
+	HouseFee uint64
+
+	Draw algokeno.Commitment
+}
+
+func (DrawSet) Signature() string {
+
+	return DrawSetSignature
+}
+
+// Rollover is logged by set_draw when what's unclaimed is over the app's
+// rollover minimum and so is paid into the next app
+type Rollover struct {
+	NextApp uint64
+
+	Amount uint64
+}
+
+func (Rollover) Signature() string {
+
+	return RolloverSignature
+}
+
+// PrizeClaimed is logged by claim
+type PrizeClaimed struct {
+	Player types.Address
+
+	// Picks is the number of picks the ticket matched
+	Picks int
+
+	// Bonus is whether the ticket matched the bonus number, so claimed from
+	// the bonus tier of Picks
+	Bonus bool
+
+	Amount uint64
+}
+
+func (PrizeClaimed) Signature() string {
+
+	return PrizeClaimedSignature
+}
+
+// Logged is an event logged by a confirmed app call
+type Logged struct {
+	Event Event
+
+	// TxID is the ID of the app call which logged the event
+	TxID string
+
+	// Round is the round the app call was confirmed in
+	Round uint64
+}
+
+type eventType struct {
+	selector []byte
+	decode func(args []byte) (Event, error)
+}
+
+var eventTypes = []eventType{
+	{Selector(TicketPurchasedSignature), decodeTicketPurchased},
+	{Selector(DrawSetSignature), decodeDrawSet},
+	{Selector(RolloverSignature), decodeRollover},
+	{Selector(PrizeClaimedSignature), decodePrizeClaimed},
+}
+
+// Selector returns the first 4 bytes of the sha512_256 of signature, which
+// the app logs the event of signature with
+func Selector(signature string) []byte {
+
+	h := sha512.Sum512_256([]byte(signature))
+	return h[:4:4]
+}
+
+// Decode decodes an event the app logged
+func Decode(log []byte) (Event, error) {
+
+	if len(log) < 4 {
+		return nil, fmt.Errorf("%w: %d byte log", ErrMalformedEvent, len(log))
+	}
+
+	for _, et := range eventTypes {
+		if !bytes.Equal(log[:4], et.selector) {
+			continue
+		}
+
+		ev, err := et.decode(log[4:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedEvent, err)
+		}
+		return ev, nil
+	}
+	return nil, fmt.Errorf("%w: selector %x", ErrUnknownEvent, log[:4])
+}
+
+// DecodeLogs decodes each of the logs of an app call in order
+func DecodeLogs(logs [][]byte) ([]Event, error) {
+
+	var evs []Event
+	for i, log := range logs {
+		ev, err := Decode(log)
+		if err != nil {
+			return nil, fmt.Errorf("log %d: %w", i, err)
+		}
+		evs = append(evs, ev)
+	}
+	return evs, nil
+}
+
+// FromPending decodes the events logged by an app call from algod's
+// PendingTransactionInformation
+func FromPending(res models.PendingTransactionInfoResponse) ([]Event, error) {
+
+	return DecodeLogs(res.Logs)
+}
+
+// FromTransactions decodes the events logged by the app calls of txns, as
+// returned by the indexer, in order. Transactions without logs, like
+// deposits, are skipped.
+func FromTransactions(txns []models.Transaction) ([]Logged, error) {
+
+	var logged []Logged
+	for _, txn := range txns {
+		evs, err := DecodeLogs(txn.Logs)
+		if err != nil {
+			return nil, fmt.Errorf("txn %s: %w", txn.Id, err)
+		}
+
+		for _, ev := range evs {
+			logged = append(logged, Logged{
+				Event: ev,
+				TxID: txn.Id,
+				Round: txn.ConfirmedRound,
+			})
+		}
+	}
+	return logged, nil
+}
+
+func decodeTicketPurchased(args []byte) (Event, error) {
+
+	ticket, err := bytesTail(args, 40)
+	if err != nil {
+		return nil, err
+	}
+	return TicketPurchased{
+		Player: address(args),
+		Wager: binary.BigEndian.Uint64(args[32:]),
+		Ticket: algokeno.Commitment(ticket),
+	}, nil
+}
+
+func decodeDrawSet(args []byte) (Event, error) {
+
+	draw, err := bytesTail(args, 8)
+	if err != nil {
+		return nil, err
+	}
+	return DrawSet{
+		HouseFee: binary.BigEndian.Uint64(args),
+		Draw: algokeno.Commitment(draw),
+	}, nil
+}
+
+func decodeRollover(args []byte) (Event, error) {
+
+	if len(args) != 16 {
+		return nil, fmt.Errorf("%d bytes of args", len(args))
+	}
+	return Rollover{
+		NextApp: binary.BigEndian.Uint64(args),
+		Amount: binary.BigEndian.Uint64(args[8:]),
+	}, nil
+}
+
+func decodePrizeClaimed(args []byte) (Event, error) {
+
+	if len(args) != 49 {
+		return nil, fmt.Errorf("%d bytes of args", len(args))
+	}
+
+	// an ABI bool is the top bit of its byte
+	if args[40]&^0x80 != 0 {
+		return nil, fmt.Errorf("bad bool %#x", args[40])
+	}
+
+	picks := binary.BigEndian.Uint64(args[32:])
+	if picks > math.MaxInt32 {
+		return nil, fmt.Errorf("%d picks", picks)
+	}
+
+	return PrizeClaimed{
+		Player: address(args),
+		Picks: int(picks),
+		Bonus: args[40] == 0x80,
+		Amount: binary.BigEndian.Uint64(args[41:]),
+	}, nil
+}
+
+// bytesTail returns the byte[] of args following static args of
+// staticLength bytes, which the app logs as the last arg so its offset
+// follows them
+func bytesTail(args []byte, staticLength int) ([]byte, error) {
+
+	if len(args) < staticLength+2 {
+		return nil, fmt.Errorf("%d bytes of args", len(args))
+	}
+	if offset := binary.BigEndian.Uint16(args[staticLength:]); int(offset) != staticLength+2 {
+		return nil, fmt.Errorf("byte[] at offset %d", offset)
+	}
+	return model.ParseBytesArg(args[staticLength+2:])
+}
+
+func address(args []byte) types.Address {
+
+	var a types.Address
+	copy(a[:], args)
+	return a
+}
@@ -0,0 +1,162 @@
+package events
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+)
+
+// TestSignaturesMatchAppSpec checks the events decoded are the ones in the
+// app spec
+func TestSignaturesMatchAppSpec(t *testing.T) {
+
+	b, err := os.ReadFile("../contract/application.json")
+	require.NoError(t, err)
+
+	var spec struct {
+		Contract struct {
+			Events []struct {
+				Name string `json:"name"`
+				Args []struct {
+					Type string `json:"type"`
+				} `json:"args"`
+			} `json:"events"`
+		} `json:"contract"`
+	}
+	require.NoError(t, json.Unmarshal(b, &spec))
+
+	var sigs []string
+	for _, ev := range spec.Contract.Events {
+		sig := ev.Name + "("
+		for i, arg := range ev.Args {
+			if i > 0 {
+				sig += ","
+			}
+			sig += arg.Type
+		}
+		sigs = append(sigs, sig+")")
+	}
+
+	want := []string{
+		TicketPurchasedSignature,
+		DrawSetSignature,
+		RolloverSignature,
+		PrizeClaimedSignature,
+	}
+	require.Equal(t, want, sigs)
+	for i, et := range eventTypes {
+		require.Equal(t, Selector(want[i]), et.selector)
+	}
+}
+
+func TestDecode(t *testing.T) {
+
+	player := crypto.GenerateAccount().Address
+
+	// Logs as the app encodes them, with the offset of a trailing byte[]
+	// following the static args
+	ticketPurchased := append(Selector(TicketPurchasedSignature), player[:]...)
+	ticketPurchased = append(ticketPurchased, 0, 0, 0, 0, 0, 0, 0, 150, 0, 42, 0, 3, 1, 2, 3)
+
+	drawSet := append(Selector(DrawSetSignature), 0, 0, 0, 0, 0, 0, 0, 100, 0, 10, 0, 3, 4, 5, 6)
+
+	rollover := append(Selector(RolloverSignature), 0, 0, 0, 0, 0, 0, 0, 87, 0, 0, 0, 0, 0, 0, 0, 30)
+
+	prizeClaimed := append(Selector(PrizeClaimedSignature), player[:]...)
+	prizeClaimed = append(prizeClaimed, 0, 0, 0, 0, 0, 0, 0, 3, 0x80, 0, 0, 0, 0, 0, 0, 0, 20)
+
+	testCases := []struct {
+		Name string
+		Log []byte
+		Expected Event
+		ExpectedErr error
+	}{
+		{
+			Name: "ticket purchased",
+			Log: ticketPurchased,
+			Expected: TicketPurchased{Player: player, Wager: 150, Ticket: algokeno.Commitment{1, 2, 3}},
+		},
+		{
+			Name: "draw set",
+			Log: drawSet,
+			Expected: DrawSet{HouseFee: 100, Draw: algokeno.Commitment{4, 5, 6}},
+		},
+		{
+			Name: "rollover",
+			Log: rollover,
+			Expected: Rollover{NextApp: 87, Amount: 30},
+		},
+		{
+			Name: "bonus prize claimed",
+			Log: prizeClaimed,
+			Expected: PrizeClaimed{Player: player, Picks: 3, Bonus: true, Amount: 20},
+		},
+		{
+			Name: "short log",
+			Log: []byte{1, 2},
+			ExpectedErr: ErrMalformedEvent,
+		},
+		{
+			Name: "unknown selector",
+			Log: []byte{1, 2, 3, 4, 5},
+			ExpectedErr: ErrUnknownEvent,
+		},
+		{
+			Name: "truncated args",
+			Log: rollover[:len(rollover)-1],
+			ExpectedErr: ErrMalformedEvent,
+		},
+		{
+			Name: "byte[] length past end of log",
+			Log: append(Selector(DrawSetSignature), 0, 0, 0, 0, 0, 0, 0, 100, 0, 10, 0, 4, 4, 5, 6),
+			ExpectedErr: ErrMalformedEvent,
+		},
+		{
+			Name: "bad bool",
+			Log: append(append([]byte{}, prizeClaimed[:len(prizeClaimed)-9]...), append([]byte{1}, prizeClaimed[len(prizeClaimed)-8:]...)...),
+			ExpectedErr: ErrMalformedEvent,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			ev, err := Decode(test.Log)
+			if test.ExpectedErr != nil {
+				require.ErrorIs(t, err, test.ExpectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.Expected, ev)
+		})
+	}
+}
+
+func TestFromTransactions(t *testing.T) {
+
+	rollover := append(Selector(RolloverSignature), 0, 0, 0, 0, 0, 0, 0, 87, 0, 0, 0, 0, 0, 0, 0, 30)
+	drawSet := append(Selector(DrawSetSignature), 0, 0, 0, 0, 0, 0, 0, 100, 0, 10, 0, 0)
+
+	logged, err := FromTransactions([]models.Transaction{
+		{Id: "deposit", ConfirmedRound: 5},
+		{Id: "set_draw", ConfirmedRound: 6, Logs: [][]byte{drawSet, rollover}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Logged{
+		{Event: DrawSet{HouseFee: 100, Draw: algokeno.Commitment{}}, TxID: "set_draw", Round: 6},
+		{Event: Rollover{NextApp: 87, Amount: 30}, TxID: "set_draw", Round: 6},
+	}, logged)
+
+	_, err = FromTransactions([]models.Transaction{{Id: "other", Logs: [][]byte{{1, 2, 3, 4}}}})
+	require.ErrorIs(t, err, ErrUnknownEvent)
+
+	evs, err := FromPending(models.PendingTransactionInfoResponse{Logs: [][]byte{rollover}})
+	require.NoError(t, err)
+	require.Equal(t, []Event{Rollover{NextApp: 87, Amount: 30}}, evs)
+}
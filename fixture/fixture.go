@@ -0,0 +1,158 @@
+// Package fixture derives test accounts deterministically from a seed and
+// records what a test did with them (funding and deployed apps), so that a
+// failing scenario can be replayed or inspected against the same addresses.
+package fixture
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+)
+
+// Fixture is the set of accounts derived from a seed, along with the state
+// they were set up with
+type Fixture struct {
+	mu sync.Mutex
+
+	Seed string `json:"seed"`
+
+	// Accounts maps each account name to its address
+	Accounts map[string]string `json:"accounts"`
+
+	// Funding maps each account name to the microalgos it was funded with
+	Funding map[string]uint64 `json:"funding,omitempty"`
+
+	// Apps holds the IDs of the apps deployed for the fixture, in order
+	Apps []uint64 `json:"apps,omitempty"`
+}
+
+// New returns an empty fixture for seed
+func New(seed string) *Fixture {
+
+	return &Fixture{
+		Seed: seed,
+		Accounts: make(map[string]string),
+		Funding: make(map[string]uint64),
+	}
+}
+
+// Load reads a fixture saved with Save
+func Load(path string) (*Fixture, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := New("")
+	if err := json.Unmarshal(b, f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	// Check the accounts derive to the saved addresses, which guards
+	// against the derivation scheme changing under saved fixtures
+	for name, addr := range f.Accounts {
+		if derived := DeriveAccount(f.Seed, name).Address.String(); derived != addr {
+			return nil, fmt.Errorf("fixture account %s derives to %s rather than %s", name, derived, addr)
+		}
+	}
+	return f, nil
+}
+
+// Save writes the fixture to path as JSON, creating its directory if needed
+func (f *Fixture) Save(path string) error {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// Account returns the account called name, deriving it from the seed
+func (f *Fixture) Account(name string) crypto.Account {
+
+	acc := DeriveAccount(f.Seed, name)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Accounts[name] = acc.Address.String()
+	return acc
+}
+
+// Name returns the name of the fixture account with address addr
+func (f *Fixture) Name(addr types.Address) (string, bool) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for name, a := range f.Accounts {
+		if a == addr.String() {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Names returns the names of all the fixture's accounts, sorted
+func (f *Fixture) Names() []string {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var names []string
+	for name := range f.Accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AddFunding records that addr was funded with amount microalgos. Addresses
+// which aren't fixture accounts are ignored.
+func (f *Fixture) AddFunding(addr types.Address, amount uint64) {
+
+	name, ok := f.Name(addr)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Funding[name] += amount
+}
+
+// AddApps records deployed app IDs
+func (f *Fixture) AddApps(appIDs ...uint64) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Apps = append(f.Apps, appIDs...)
+}
+
+// DeriveAccount deterministically derives the account called name from seed
+func DeriveAccount(seed, name string) crypto.Account {
+
+	h := sha256.Sum256([]byte("algokeno/" + seed + "/" + name))
+	acc, err := crypto.AccountFromPrivateKey(ed25519.NewKeyFromSeed(h[:]))
+	if err != nil {
+		// Only fails for keys of the wrong length, which can't happen here
+		panic(err)
+	}
+	return acc
+}
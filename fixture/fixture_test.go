@@ -0,0 +1,56 @@
+package fixture
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveAccount(t *testing.T) {
+
+	a := DeriveAccount("seed", "creator")
+	require.Equal(t, a, DeriveAccount("seed", "creator"))
+	require.NotEqual(t, a.Address, DeriveAccount("seed", "acc1").Address)
+	require.NotEqual(t, a.Address, DeriveAccount("other seed", "creator").Address)
+}
+
+func TestSaveAndLoad(t *testing.T) {
+
+	f := New("TestSomething/1")
+	creator := f.Account("creator")
+	acc1 := f.Account("acc1")
+	f.AddFunding(creator.Address, 20_000_000)
+	f.AddFunding(acc1.Address, 20_000_000)
+	f.AddFunding(acc1.Address, 5_000_000)
+	f.AddFunding(DeriveAccount("other", "acc").Address, 1)
+	f.AddApps(86, 87)
+
+	require.Equal(t, []string{"acc1", "creator"}, f.Names())
+	name, ok := f.Name(acc1.Address)
+	require.True(t, ok)
+	require.Equal(t, "acc1", name)
+
+	path := filepath.Join(t.TempDir(), "fixtures", "TestSomething.json")
+	require.NoError(t, f.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "TestSomething/1", loaded.Seed)
+	require.Equal(t, map[string]uint64{"creator": 20_000_000, "acc1": 25_000_000}, loaded.Funding)
+	require.Equal(t, []uint64{86, 87}, loaded.Apps)
+	require.Equal(t, creator, loaded.Account("creator"))
+}
+
+func TestLoadRejectsMismatchedAccounts(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"seed": "s",
+		"accounts": {"creator": "OCTAWZ77S7VISVSQREH5MONA4J5BU7SNHEL74XHVUGWNYC6U4NQGWANWTE"}
+	}`), 0644))
+
+	_, err := Load(path)
+	require.Error(t, err)
+}
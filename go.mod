@@ -1,6 +1,6 @@
 module github.com/neurotempest/algokeno
 
-go 1.17
+go 1.18
 
 require (
 	github.com/algorand/go-algorand-sdk v1.14.0
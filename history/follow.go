@@ -0,0 +1,202 @@
+package history
+
+import (
+	"context"
+	"sort"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/algod"
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/client/v2/indexer"
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno/client"
+	"github.com/neurotempest/algokeno/settlement"
+)
+
+// DefaultBatchRounds is the most rounds indexed in one batch by default
+const DefaultBatchRounds = 1000
+
+// Follower keeps a store up to date, indexing the app txns of each new
+// block from the indexer once it has processed it
+type Follower struct {
+	algod *algod.Client
+	idx *indexer.Client
+	store *Store
+
+	// BatchRounds is the most rounds indexed in one batch while catching up
+	BatchRounds uint64
+
+	// StartRound is the round an empty store starts indexing from, e.g.
+	// the round the first lotto app of interest was created in, so the
+	// rounds before it aren't searched
+	StartRound uint64
+
+	// Creators, if set, are the only accounts whose lotto apps are indexed.
+	// Rather than every app call, each batch then searches their app txns
+	// for creations, followed by the calls to each app created so far.
+	Creators []types.Address
+}
+
+// NewFollower returns a follower updating store, which needs cl's algod
+// and indexer clients
+func NewFollower(cl *client.Context, store *Store) *Follower {
+
+	return &Follower{
+		algod: cl.Algod,
+		idx: cl.Indexer,
+		store: store,
+		BatchRounds: DefaultBatchRounds,
+	}
+}
+
+// Run syncs the store, then again after each new block, until ctx is done
+// or syncing fails
+func (f *Follower) Run(ctx context.Context) error {
+
+	for {
+		if err := f.Sync(ctx); err != nil {
+			return err
+		}
+
+		// Returns once there's a block after the store's last round, or
+		// after algod's timeout if there isn't one yet
+		if _, err := f.algod.StatusAfterBlock(f.store.LastRound()).Do(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// Sync indexes the rounds after the store's last round up to algod's last
+// round, in batches of at most BatchRounds
+func (f *Follower) Sync(ctx context.Context) error {
+
+	status, err := f.algod.Status().Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	for from := f.next(); from <= status.LastRound; from = f.next() {
+		to := status.LastRound
+		if f.BatchRounds > 0 && to-from+1 > f.BatchRounds {
+			to = from + f.BatchRounds - 1
+		}
+
+		if err := settlement.WaitForIndexerRound(ctx, f.idx, to); err != nil {
+			return err
+		}
+
+		txns, err := f.appTxns(ctx, from, to)
+		if err != nil {
+			return err
+		}
+
+		if err := f.store.Apply(to, txns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// next returns the first round to index
+func (f *Follower) next() uint64 {
+
+	if next := f.store.LastRound() + 1; next > f.StartRound {
+		return next
+	}
+	return f.StartRound
+}
+
+// appTxns returns the app calls confirmed from round from to round to, in
+// the order they were confirmed: every app call, or only the calls of the
+// creators and to their apps
+func (f *Follower) appTxns(ctx context.Context, from, to uint64) ([]models.Transaction, error) {
+
+	search := func() *indexer.SearchForTransactions {
+
+		return f.idx.SearchForTransactions().MinRound(from).MaxRound(to).TxType("appl")
+	}
+	if len(f.Creators) == 0 {
+		return f.search(ctx, search)
+	}
+
+	var lists [][]models.Transaction
+	appIDs := f.store.AppIDs()
+	for _, creator := range f.Creators {
+		txns, err := f.search(ctx, func() *indexer.SearchForTransactions {
+
+			return search().AddressString(creator.String()).AddressRole("sender")
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, txn := range txns {
+			if isCreation(txn) {
+				appIDs = append(appIDs, txn.CreatedApplicationIndex)
+			}
+		}
+		lists = append(lists, txns)
+	}
+
+	for _, appID := range appIDs {
+		txns, err := f.search(ctx, func() *indexer.SearchForTransactions {
+
+			return search().ApplicationId(appID)
+		})
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, txns)
+	}
+	return mergeTxns(lists...), nil
+}
+
+// search returns every page of the results of the search query returns
+func (f *Follower) search(ctx context.Context, query func() *indexer.SearchForTransactions) ([]models.Transaction, error) {
+
+	var txns []models.Transaction
+	var next string
+	for {
+		q := query()
+		if next != "" {
+			q.NextToken(next)
+		}
+
+		res, err := q.Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, res.Transactions...)
+
+		if res.NextToken == "" || len(res.Transactions) == 0 {
+			return txns, nil
+		}
+		next = res.NextToken
+	}
+}
+
+// mergeTxns merges lists of txns, each in the order they were confirmed,
+// into one in the order they were confirmed, without the txns found by
+// more than one search
+func mergeTxns(lists ...[]models.Transaction) []models.Transaction {
+
+	var res []models.Transaction
+	seen := make(map[string]bool)
+	for _, txns := range lists {
+		for _, txn := range txns {
+			if seen[txn.Id] {
+				continue
+			}
+			seen[txn.Id] = true
+			res = append(res, txn)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+
+		if res[i].ConfirmedRound != res[j].ConfirmedRound {
+			return res[i].ConfirmedRound < res[j].ConfirmedRound
+		}
+		return res[i].IntraRoundOffset < res[j].IntraRoundOffset
+	})
+	return res
+}
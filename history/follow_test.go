@@ -0,0 +1,30 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeTxns(t *testing.T) {
+
+	txn := func(id string, round, offset uint64) models.Transaction {
+
+		return models.Transaction{Id: id, ConfirmedRound: round, IntraRoundOffset: offset}
+	}
+
+	// The creator's calls to their own apps are found by both searches
+	merged := mergeTxns(
+		[]models.Transaction{txn("create", 1, 0), txn("set_draw", 3, 1)},
+		[]models.Transaction{txn("create", 1, 0), txn("commit", 2, 4), txn("set_draw", 3, 1), txn("claim", 3, 2)},
+		[]models.Transaction{txn("other", 1, 3), txn("other_commit", 3, 0)},
+	)
+
+	var ids []string
+	for _, txn := range merged {
+		ids = append(ids, txn.Id)
+	}
+	require.Equal(t, []string{"create", "other", "commit", "other_commit", "set_draw", "claim"}, ids)
+	require.Empty(t, mergeTxns())
+}
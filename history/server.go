@@ -0,0 +1,96 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/algorand/go-algorand-sdk/types"
+)
+
+// NewHandler returns the handler serving store as JSON:
+//
+//	GET /rounds                      every round
+//	GET /rounds/{app}                the round of app
+//	GET /rounds/{app}/tickets        each player's ticket in the round
+//	GET /rounds/{app}/winners        the tickets matching a tier of its draw
+//	GET /players/{address}/tickets   the player's tickets in every round
+func NewHandler(store *Store) http.Handler {
+
+	return handler{store: store}
+}
+
+type handler struct {
+	store *Store
+}
+
+func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(path) == 1 && path[0] == "rounds":
+		writeJSON(w, h.store.Rounds())
+
+	case len(path) >= 2 && len(path) <= 3 && path[0] == "rounds":
+		h.serveRound(w, path[1], path[2:])
+
+	case len(path) == 3 && path[0] == "players" && path[2] == "tickets":
+		player, err := types.DecodeAddress(path[1])
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid address")
+			return
+		}
+		writeJSON(w, h.store.PlayerTickets(player))
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (h handler) serveRound(w http.ResponseWriter, app string, sub []string) {
+
+	appID, err := strconv.ParseUint(app, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid app ID")
+		return
+	}
+
+	var res interface{}
+	var ok bool
+	switch {
+	case len(sub) == 0:
+		res, ok = h.store.Round(appID)
+	case sub[0] == "tickets":
+		res, ok = h.store.Tickets(appID)
+	case sub[0] == "winners":
+		res, ok = h.store.Winners(appID)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown round")
+		return
+	}
+	writeJSON(w, res)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+)
+
+func TestHandler(t *testing.T) {
+
+	s, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer s.Close()
+
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	require.NoError(t, s.Apply(1, []models.Transaction{
+		createTxn(1, appID, algokeno.DefaultGameConfig),
+		callTxn("commit1", 1, appID, player1, nil, ticketPurchased(player1, 1_000_000, draw)),
+		callTxn("commit2", 1, appID, player2, nil, ticketPurchased(player2, 1_000_000, algokeno.Commitment{7, 8, 9, 10, 11, 12})),
+		callTxn("set_draw", 1, appID, creator, nil, drawSet(0, draw)),
+	}))
+
+	srv := httptest.NewServer(NewHandler(s))
+	defer srv.Close()
+
+	testCases := []struct {
+		Name string
+		Path string
+		ExpectedStatus int
+		ExpectedLen int
+	}{
+		{Name: "rounds", Path: "/rounds", ExpectedStatus: http.StatusOK, ExpectedLen: 1},
+		{Name: "round", Path: "/rounds/86", ExpectedStatus: http.StatusOK},
+		{Name: "tickets", Path: "/rounds/86/tickets", ExpectedStatus: http.StatusOK, ExpectedLen: 2},
+		{Name: "winners", Path: "/rounds/86/winners", ExpectedStatus: http.StatusOK, ExpectedLen: 1},
+		{Name: "player tickets", Path: "/players/" + player1.String() + "/tickets", ExpectedStatus: http.StatusOK, ExpectedLen: 1},
+		{Name: "unknown round", Path: "/rounds/99/tickets", ExpectedStatus: http.StatusNotFound},
+		{Name: "bad app ID", Path: "/rounds/abc", ExpectedStatus: http.StatusBadRequest},
+		{Name: "bad address", Path: "/players/abc/tickets", ExpectedStatus: http.StatusBadRequest},
+		{Name: "unknown path", Path: "/rounds/86/sponsors", ExpectedStatus: http.StatusNotFound},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			res, err := http.Get(srv.URL + test.Path)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			require.Equal(t, test.ExpectedStatus, res.StatusCode)
+			require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+			if test.ExpectedLen > 0 {
+				var items []json.RawMessage
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&items))
+				require.Len(t, items, test.ExpectedLen)
+			}
+		})
+	}
+
+	var round Round
+	res, err := http.Get(srv.URL + "/rounds/86")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&round))
+	require.Equal(t, []int{1, 2, 3, 4, 5, 6}, round.Draw.Numbers)
+
+	res, err = http.Post(srv.URL+"/rounds", "application/json", nil)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+}
@@ -0,0 +1,629 @@
+// Package history indexes the rounds of lotto apps, with their tickets and
+// winners, into an embedded store kept up to date by following new blocks,
+// and serves them as JSON over HTTP.
+//
+// Each lotto app is a single round. Apps are found by their creation txn,
+// which calls the `create` method, and what happened in them is read from
+// the events they log, along with the numbers revealed by `reveal` calls.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/events"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+const (
+	// logName is the name of the store's log under its dir
+	logName = "history.jsonl"
+
+	// CheckpointRounds is how many rounds after the last batch logged a
+	// batch without any lotto app txns is logged anyway, to save reopening
+	// the store from indexing all those rounds again
+	CheckpointRounds = 1000
+)
+
+var ErrRoundOrder = errors.New("batch isn't after the store's last round")
+
+// Round is a lotto app, along with its draw once it's set
+type Round struct {
+	AppID uint64 `json:"app_id"`
+	Creator string `json:"creator"`
+
+	// CreatedRound is the round the app was created in
+	CreatedRound uint64 `json:"created_round"`
+
+	Picks int `json:"picks"`
+	MaxNumber int `json:"max_number"`
+	TicketPrice uint64 `json:"ticket_price"`
+	HouseFeeBps uint64 `json:"house_fee_bps"`
+	RolloverThreshold uint64 `json:"rollover_threshold"`
+	AssetID uint64 `json:"asset_id"`
+	BonusMax int `json:"bonus_max"`
+
+	// NumTickets is the number of tickets bought, including those later
+	// replaced by their player's next ticket
+	NumTickets uint64 `json:"num_tickets"`
+
+	Draw *Draw `json:"draw,omitempty"`
+}
+
+// Draw is a round's draw and how its prizes were set
+type Draw struct {
+	Numbers []int `json:"numbers"`
+
+	// Bonus is the bonus number of a bonus game
+	Bonus *int `json:"bonus,omitempty"`
+
+	// Round is the round the draw was set in
+	Round uint64 `json:"round"`
+	TxID string `json:"tx_id"`
+
+	HouseFee uint64 `json:"house_fee"`
+
+	// Tiers are the tiers of 1 to picks matches, followed in a bonus game by
+	// the bonus tiers of 0 to picks matches
+	Tiers []Tier `json:"tiers"`
+
+	// RolloverApp is the app the rollover was paid into, if it was over the
+	// round's rollover threshold
+	RolloverApp uint64 `json:"rollover_app,omitempty"`
+	Rollover uint64 `json:"rollover"`
+
+	// Claimed is the total paid out to winners so far
+	Claimed uint64 `json:"claimed"`
+}
+
+// Tier is the number of winners of a tier and their prize, as set with the
+// draw
+type Tier struct {
+	Matches int `json:"matches"`
+	Bonus bool `json:"bonus"`
+	Winners uint64 `json:"winners"`
+	Prize uint64 `json:"prize"`
+}
+
+// Ticket is a player's ticket in a round, as of their latest commit
+type Ticket struct {
+	AppID uint64 `json:"app_id"`
+	Player string `json:"player"`
+
+	// Numbers are the ticket's picks, or nil for a sealed ticket which
+	// hasn't been revealed
+	Numbers []int `json:"numbers,omitempty"`
+
+	// Bonus is the ticket's bonus number in a bonus game
+	Bonus *int `json:"bonus,omitempty"`
+
+	// Sealed is the sealed commitment of a sealed ticket, base64 encoded
+	Sealed string `json:"sealed,omitempty"`
+
+	Wager uint64 `json:"wager"`
+
+	// Round is the round the ticket was bought in
+	Round uint64 `json:"round"`
+	TxID string `json:"tx_id"`
+
+	// Claimed is the prize the ticket claimed, and ClaimTxID the claim
+	Claimed uint64 `json:"claimed"`
+	ClaimTxID string `json:"claim_tx_id,omitempty"`
+}
+
+// Winner is a ticket matching a tier of its round's draw, whether or not
+// it has claimed yet
+type Winner struct {
+	Ticket
+
+	Matches int `json:"matches"`
+	BonusMatched bool `json:"bonus_matched"`
+}
+
+// batch is a line of the store's log: the txns of lotto apps confirmed
+// after the previous batch, up to and including Round
+type batch struct {
+	Round uint64 `json:"round"`
+	Txns []models.Transaction `json:"txns,omitempty"`
+}
+
+type round struct {
+	Round
+	config algokeno.GameConfig
+	draw algokeno.Commitment
+	tickets map[string]*ticket
+}
+
+type ticket struct {
+	Ticket
+	commitment algokeno.Commitment
+
+	// seq orders tickets by when they were bought
+	seq int
+}
+
+// Store is the history of the lotto apps, held in memory and persisted to
+// an append-only log of the app txns it was built from, which is replayed
+// when it's opened. It is safe for concurrent use.
+type Store struct {
+	f *os.File
+
+	mu sync.RWMutex
+	last uint64
+	// logged is the round of the last batch in the log
+	logged uint64
+	rounds map[uint64]*round
+	players map[string]map[uint64]*ticket
+	seq int
+}
+
+// Open opens the store under dir, creating it if needed. A batch only
+// partly written to the log when the process stopped is discarded, and
+// indexed again by the follower.
+func Open(dir string) (*Store, error) {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, logName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		f: f,
+		rounds: make(map[uint64]*round),
+		players: make(map[string]map[uint64]*ticket),
+	}
+	if err := s.replay(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// replay applies each complete batch in the log, and truncates it after
+// the last one
+func (s *Store) replay() error {
+
+	r := bufio.NewReader(s.f)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		var b batch
+		if err := json.Unmarshal(line, &b); err != nil {
+			return fmt.Errorf("%s at offset %d: %w", logName, offset, err)
+		}
+		s.apply(b)
+		s.logged = b.Round
+		offset += int64(len(line))
+	}
+
+	if err := s.f.Truncate(offset); err != nil {
+		return err
+	}
+	_, err := s.f.Seek(offset, io.SeekStart)
+	return err
+}
+
+// Close closes the store's log
+func (s *Store) Close() error {
+
+	return s.f.Close()
+}
+
+// LastRound returns the last round indexed
+func (s *Store) LastRound() uint64 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.last
+}
+
+// Apply indexes txns, the txns confirmed after the last round indexed up
+// to and including round, in the order they were confirmed. Only the
+// creations of lotto apps and the calls to them are kept, and they're
+// durably logged before they're applied. A batch with none of them is only
+// logged every CheckpointRounds, so following empty blocks doesn't grow the
+// log, and reopening the store picks up from the last batch logged.
+func (s *Store) Apply(round uint64, txns []models.Transaction) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if round <= s.last {
+		return fmt.Errorf("%w: %d after %d", ErrRoundOrder, round, s.last)
+	}
+
+	b := batch{Round: round}
+	created := make(map[uint64]bool)
+	for _, txn := range txns {
+		appID := txn.ApplicationTransaction.ApplicationId
+		switch {
+		case isCreation(txn):
+			created[txn.CreatedApplicationIndex] = true
+		case appID == 0 || (s.rounds[appID] == nil && !created[appID]):
+			continue
+		}
+		b.Txns = append(b.Txns, txn)
+	}
+
+	if len(b.Txns) > 0 || round-s.logged >= CheckpointRounds {
+		if err := s.log(b); err != nil {
+			return err
+		}
+	}
+
+	s.apply(b)
+	return nil
+}
+
+// log durably appends b to the log
+func (s *Store) log(b batch) error {
+
+	line, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := s.f.Sync(); err != nil {
+		return err
+	}
+
+	s.logged = b.Round
+	return nil
+}
+
+func (s *Store) apply(b batch) {
+
+	for _, txn := range b.Txns {
+		if isCreation(txn) {
+			s.create(txn)
+			continue
+		}
+
+		r := s.rounds[txn.ApplicationTransaction.ApplicationId]
+		if r == nil {
+			continue
+		}
+
+		args := txn.ApplicationTransaction.ApplicationArgs
+		if len(args) == 3 && bytes.Equal(args[0], lotto.MethodReveal.GetSelector()) {
+			s.reveal(r, txn)
+		}
+
+		// Apps only log their events, but ones created before they did log
+		// nothing, and so have no tickets or draw
+		evs, err := events.DecodeLogs(txn.Logs)
+		if err != nil {
+			continue
+		}
+		for _, ev := range evs {
+			s.applyEvent(r, txn, ev)
+		}
+	}
+	s.last = b.Round
+}
+
+// isCreation returns whether txn creates a lotto app
+func isCreation(txn models.Transaction) bool {
+
+	if txn.CreatedApplicationIndex == 0 {
+		return false
+	}
+	_, err := algokeno.ParseCreateArgs(txn.ApplicationTransaction.ApplicationArgs)
+	return err == nil
+}
+
+func (s *Store) create(txn models.Transaction) {
+
+	config, _ := algokeno.ParseCreateArgs(txn.ApplicationTransaction.ApplicationArgs)
+	s.rounds[txn.CreatedApplicationIndex] = &round{
+		Round: Round{
+			AppID: txn.CreatedApplicationIndex,
+			Creator: txn.Sender,
+			CreatedRound: txn.ConfirmedRound,
+			Picks: config.Picks,
+			MaxNumber: config.MaxNumber,
+			TicketPrice: config.TicketPrice,
+			HouseFeeBps: config.HouseFeeBps,
+			RolloverThreshold: config.RolloverThreshold,
+			AssetID: config.AssetID,
+			BonusMax: config.BonusMax,
+		},
+		config: config,
+		tickets: make(map[string]*ticket),
+	}
+}
+
+func (s *Store) reveal(r *round, txn models.Transaction) {
+
+	t := r.tickets[txn.Sender]
+	if t == nil {
+		return
+	}
+
+	numbers, err := model.ParseBytesArg(txn.ApplicationTransaction.ApplicationArgs[1])
+	if err != nil {
+		return
+	}
+	t.commitment = numbers
+	t.Ticket = r.ticketView(t.Ticket, numbers)
+}
+
+func (s *Store) applyEvent(r *round, txn models.Transaction, ev events.Event) {
+
+	switch ev := ev.(type) {
+	case events.TicketPurchased:
+		player := ev.Player.String()
+		s.seq++
+		t := &ticket{
+			Ticket: r.ticketView(Ticket{
+				AppID: r.AppID,
+				Player: player,
+				Wager: ev.Wager,
+				Round: txn.ConfirmedRound,
+				TxID: txn.Id,
+			}, ev.Ticket),
+			commitment: ev.Ticket,
+			seq: s.seq,
+		}
+		r.tickets[player] = t
+		r.NumTickets++
+		if s.players[player] == nil {
+			s.players[player] = make(map[uint64]*ticket)
+		}
+		s.players[player][r.AppID] = t
+
+	case events.DrawSet:
+		r.draw = ev.Draw
+		r.Draw = &Draw{
+			Round: txn.ConfirmedRound,
+			TxID: txn.Id,
+			HouseFee: ev.HouseFee,
+			Tiers: r.tiers(txn.ApplicationTransaction.ApplicationArgs),
+		}
+		r.Draw.Numbers = numbers(ev.Draw.Picks(r.config))
+		if bonus, ok := ev.Draw.Bonus(r.config); ok {
+			b := int(bonus)
+			r.Draw.Bonus = &b
+		}
+
+	case events.Rollover:
+		if r.Draw != nil {
+			r.Draw.RolloverApp = ev.NextApp
+			r.Draw.Rollover = ev.Amount
+		}
+
+	case events.PrizeClaimed:
+		if r.Draw != nil {
+			r.Draw.Claimed += ev.Amount
+		}
+		if t := r.tickets[ev.Player.String()]; t != nil {
+			t.Claimed = ev.Amount
+			t.ClaimTxID = txn.Id
+		}
+	}
+}
+
+// ticketView returns t with the numbers, or the sealed commitment, of c
+func (r *round) ticketView(t Ticket, c algokeno.Commitment) Ticket {
+
+	t.Numbers, t.Bonus, t.Sealed = nil, nil, ""
+	if c.IsSealed() {
+		t.Sealed = c.String()
+		return t
+	}
+
+	t.Numbers = numbers(c.Picks(r.config))
+	if bonus, ok := c.Bonus(r.config); ok {
+		b := int(bonus)
+		t.Bonus = &b
+	}
+	return t
+}
+
+// tiers returns the tiers set by the set_draw call with args
+func (r *round) tiers(args [][]byte) []Tier {
+
+	if len(args) < 1+model.NumSetDrawArgs {
+		return nil
+	}
+	_, tiers, bonusTiers, err := model.ParseSetDrawArgs(args[1:1+model.NumSetDrawArgs], r.config.Picks, r.config.HasBonus())
+	if err != nil {
+		return nil
+	}
+
+	var res []Tier
+	for i, tier := range tiers {
+		res = append(res, Tier{Matches: i + 1, Winners: tier.Winners, Prize: tier.Prize})
+	}
+	for i, tier := range bonusTiers {
+		res = append(res, Tier{Matches: i, Bonus: true, Winners: tier.Winners, Prize: tier.Prize})
+	}
+	return res
+}
+
+func numbers(c algokeno.Commitment) []int {
+
+	nums := make([]int, 0, len(c))
+	for _, n := range c.Numbers() {
+		nums = append(nums, int(n))
+	}
+	return nums
+}
+
+// Rounds returns every round, in the order of their app IDs
+func (s *Store) Rounds() []Round {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rounds := make([]Round, 0, len(s.rounds))
+	for _, r := range s.rounds {
+		rounds = append(rounds, r.view())
+	}
+	sort.Slice(rounds, func(i, j int) bool {
+
+		return rounds[i].AppID < rounds[j].AppID
+	})
+	return rounds
+}
+
+// AppIDs returns the IDs of the lotto apps indexed so far, in order
+func (s *Store) AppIDs() []uint64 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]uint64, 0, len(s.rounds))
+	for id := range s.rounds {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// Round returns the round of the app appID, if it's a lotto app
+func (s *Store) Round(appID uint64) (Round, bool) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.rounds[appID]
+	if !ok {
+		return Round{}, false
+	}
+	return r.view(), true
+}
+
+// view returns a copy of r which is safe to use without the store's lock
+func (r *round) view() Round {
+
+	v := r.Round
+	if r.Draw != nil {
+		d := *r.Draw
+		d.Tiers = append([]Tier(nil), d.Tiers...)
+		v.Draw = &d
+	}
+	return v
+}
+
+// Tickets returns each player's ticket in the round of the app appID, in
+// the order they were bought, if it's a lotto app
+func (s *Store) Tickets(appID uint64) ([]Ticket, bool) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.rounds[appID]
+	if !ok {
+		return nil, false
+	}
+	return sortedTickets(r.ticketList()), true
+}
+
+// Winners returns the tickets in the round of the app appID which match a
+// tier of its draw, in the order they were bought, if it's a lotto app.
+// Sealed tickets only win once they're revealed, tickets wagering less than
+// the ticket price can't claim so never win, and there are none until the
+// draw is set.
+func (s *Store) Winners(appID uint64) ([]Winner, bool) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.rounds[appID]
+	if !ok {
+		return nil, false
+	}
+
+	winners := []Winner{}
+	if r.draw == nil {
+		return winners, true
+	}
+	for _, t := range sortedTickets(r.ticketList()) {
+		c := r.tickets[t.Player].commitment
+		if c.IsSealed() || t.Wager < r.config.TicketPrice {
+			continue
+		}
+
+		// As in claim, a ticket matching the bonus wins a bonus tier, which
+		// can be of no matches, and others need at least one match
+		m := c.MatchFor(r.config, r.draw)
+		if m.Numbers == 0 && !m.Bonus {
+			continue
+		}
+		winners = append(winners, Winner{
+			Ticket: t,
+			Matches: m.Numbers,
+			BonusMatched: m.Bonus,
+		})
+	}
+	return winners, true
+}
+
+// PlayerTickets returns player's tickets in every round, in the order they
+// were bought
+func (s *Store) PlayerTickets(player types.Address) []Ticket {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ts []*ticket
+	for _, t := range s.players[player.String()] {
+		ts = append(ts, t)
+	}
+	return sortedTickets(ts)
+}
+
+func (r *round) ticketList() []*ticket {
+
+	ts := make([]*ticket, 0, len(r.tickets))
+	for _, t := range r.tickets {
+		ts = append(ts, t)
+	}
+	return ts
+}
+
+// sortedTickets returns copies of ts in the order they were bought
+func sortedTickets(ts []*ticket) []Ticket {
+
+	sort.Slice(ts, func(i, j int) bool {
+
+		return ts[i].seq < ts[j].seq
+	})
+
+	res := make([]Ticket, 0, len(ts))
+	for _, t := range ts {
+		view := t.Ticket
+		view.Numbers = append([]int(nil), view.Numbers...)
+		res = append(res, view)
+	}
+	return res
+}
@@ -0,0 +1,256 @@
+package history
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/events"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+const appID = 86
+
+var (
+	creator = crypto.GenerateAccount().Address
+	player1 = crypto.GenerateAccount().Address
+	player2 = crypto.GenerateAccount().Address
+	player3 = crypto.GenerateAccount().Address
+)
+
+func TestStore(t *testing.T) {
+
+	dir := t.TempDir()
+	s, err := Open(dir)
+	require.NoError(t, err)
+
+	salt := []byte("salt")
+	sealed := algokeno.Seal(algokeno.Commitment{1, 2, 3, 4, 5, 7}, salt)
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	tiers := make([]model.Tier, algokeno.NumPicks)
+	tiers[algokeno.NumPicks-1] = model.Tier{Winners: 1, Prize: 500_000}
+	tiers[algokeno.NumPicks-2] = model.Tier{Winners: 1, Prize: 200_000}
+
+	require.NoError(t, s.Apply(10, []models.Transaction{
+		createTxn(10, appID, algokeno.DefaultGameConfig),
+		// calls to other apps are ignored
+		callTxn("other", 10, 99, player1, nil, ticketPurchased(player1, 1_000_000, draw)),
+		callTxn("commit1", 10, appID, player1, nil, ticketPurchased(player1, 1_000_000, algokeno.Commitment{1, 2, 3, 4, 5, 9})),
+	}))
+	require.NoError(t, s.Apply(12, []models.Transaction{
+		// a later ticket replaces the player's earlier one
+		callTxn("commit2", 11, appID, player1, nil, ticketPurchased(player1, 2_000_000, draw)),
+		callTxn("commit3", 12, appID, player2, nil, ticketPurchased(player2, 1_000_000, sealed)),
+	}))
+
+	winners, ok := s.Winners(appID)
+	require.True(t, ok)
+	require.Empty(t, winners)
+
+	setDrawArgs := append([][]byte{lotto.MethodSetDraw.GetSelector()}, model.SetDrawArgs(draw, tiers, nil)...)
+	require.NoError(t, s.Apply(13, []models.Transaction{
+		callTxn("set_draw", 13, appID, creator, setDrawArgs, drawSet(100_000, draw), rollover(87, 300_000)),
+		callTxn("reveal", 13, appID, player2, [][]byte{
+			lotto.MethodReveal.GetSelector(),
+			model.BytesArg(algokeno.Commitment{1, 2, 3, 4, 5, 7}),
+			model.BytesArg(salt),
+		}),
+		callTxn("claim", 13, appID, player1, nil, prizeClaimed(player1, 6, 500_000)),
+	}))
+	require.Equal(t, uint64(13), s.LastRound())
+
+	round, ok := s.Round(appID)
+	require.True(t, ok)
+	require.Equal(t, uint64(3), round.NumTickets)
+	require.Equal(t, creator.String(), round.Creator)
+	require.Equal(t, algokeno.NumPicks, round.Picks)
+	require.Equal(t, []int{1, 2, 3, 4, 5, 6}, round.Draw.Numbers)
+	require.Equal(t, uint64(100_000), round.Draw.HouseFee)
+	require.Equal(t, uint64(87), round.Draw.RolloverApp)
+	require.Equal(t, uint64(300_000), round.Draw.Rollover)
+	require.Equal(t, uint64(500_000), round.Draw.Claimed)
+	require.Len(t, round.Draw.Tiers, algokeno.NumPicks)
+	require.Equal(t, Tier{Matches: 6, Winners: 1, Prize: 500_000}, round.Draw.Tiers[5])
+
+	tickets, ok := s.Tickets(appID)
+	require.True(t, ok)
+	require.Len(t, tickets, 2)
+	require.Equal(t, player1.String(), tickets[0].Player)
+	require.Equal(t, uint64(2_000_000), tickets[0].Wager)
+	require.Equal(t, uint64(500_000), tickets[0].Claimed)
+	require.Equal(t, "claim", tickets[0].ClaimTxID)
+	require.Equal(t, []int{1, 2, 3, 4, 5, 7}, tickets[1].Numbers)
+	require.Empty(t, tickets[1].Sealed)
+
+	winners, ok = s.Winners(appID)
+	require.True(t, ok)
+	require.Len(t, winners, 2)
+	require.Equal(t, 6, winners[0].Matches)
+	require.Equal(t, 5, winners[1].Matches)
+
+	require.Equal(t, tickets[1:], s.PlayerTickets(player2))
+	require.Empty(t, s.PlayerTickets(creator))
+
+	_, ok = s.Round(99)
+	require.False(t, ok)
+
+	// Rounds have to be applied in order
+	require.ErrorIs(t, s.Apply(13, nil), ErrRoundOrder)
+
+	// Reopening replays the log, discarding a partly written last batch
+	require.NoError(t, s.Close())
+	f, err := os.OpenFile(filepath.Join(dir, logName), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"round":14,"txns":[`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened, err := Open(dir)
+	require.NoError(t, err)
+	defer reopened.Close()
+	require.Equal(t, uint64(13), reopened.LastRound())
+	require.Equal(t, s.Rounds(), reopened.Rounds())
+	reopenedTickets, _ := reopened.Tickets(appID)
+	require.Equal(t, tickets, reopenedTickets)
+	require.NoError(t, reopened.Apply(14, nil))
+}
+
+func TestStoreCheckpoints(t *testing.T) {
+
+	dir := t.TempDir()
+	s, err := Open(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Apply(10, []models.Transaction{
+		createTxn(10, appID, algokeno.DefaultGameConfig),
+	}))
+
+	// Empty batches are indexed but not logged, until one is CheckpointRounds
+	// after the last batch logged
+	require.NoError(t, s.Apply(20, nil))
+	require.NoError(t, s.Apply(10+CheckpointRounds-1, nil))
+	require.Equal(t, uint64(10+CheckpointRounds-1), s.LastRound())
+	require.NoError(t, s.Close())
+
+	reopened, err := Open(dir)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), reopened.LastRound())
+
+	require.NoError(t, reopened.Apply(10+CheckpointRounds, nil))
+	require.NoError(t, reopened.Close())
+
+	reopened, err = Open(dir)
+	require.NoError(t, err)
+	defer reopened.Close()
+	require.Equal(t, uint64(10+CheckpointRounds), reopened.LastRound())
+	_, ok := reopened.Round(appID)
+	require.True(t, ok)
+}
+
+func TestStoreBonusWinners(t *testing.T) {
+
+	s, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer s.Close()
+
+	config := algokeno.DefaultGameConfig
+	config.Picks = 2
+	config.MaxNumber = 10
+	config.BonusMax = 5
+	draw := algokeno.Commitment{1, 2, 4}
+
+	require.NoError(t, s.Apply(1, []models.Transaction{
+		createTxn(1, appID, config),
+		callTxn("commit1", 1, appID, player1, nil, ticketPurchased(player1, 1_000_000, algokeno.Commitment{5, 6, 4})),
+		callTxn("commit2", 1, appID, player2, nil, ticketPurchased(player2, 1_000_000, algokeno.Commitment{5, 6, 3})),
+		// matches the draw, but wagers less than the ticket price
+		callTxn("commit3", 1, appID, player3, nil, ticketPurchased(player3, 999_999, algokeno.Commitment{1, 2, 4})),
+		callTxn("set_draw", 1, appID, creator, nil, drawSet(0, draw)),
+	}))
+
+	round, _ := s.Round(appID)
+	require.Equal(t, []int{1, 2}, round.Draw.Numbers)
+	require.Equal(t, 4, *round.Draw.Bonus)
+
+	// Matching just the bonus wins the bonus tier of no matches, and
+	// underpaid tickets never win
+	winners, _ := s.Winners(appID)
+	require.Len(t, winners, 1)
+	require.Equal(t, player1.String(), winners[0].Player)
+	require.Equal(t, 0, winners[0].Matches)
+	require.True(t, winners[0].BonusMatched)
+	require.Equal(t, 4, *winners[0].Bonus)
+}
+
+func createTxn(round, id uint64, config algokeno.GameConfig) models.Transaction {
+
+	return models.Transaction{
+		Id: "create",
+		ConfirmedRound: round,
+		Sender: creator.String(),
+		CreatedApplicationIndex: id,
+		ApplicationTransaction: models.TransactionApplication{
+			ApplicationArgs: config.CreateArgs(),
+		},
+	}
+}
+
+func callTxn(txID string, round, id uint64, sender types.Address, args [][]byte, logs ...[]byte) models.Transaction {
+
+	return models.Transaction{
+		Id: txID,
+		ConfirmedRound: round,
+		Sender: sender.String(),
+		ApplicationTransaction: models.TransactionApplication{
+			ApplicationId: id,
+			ApplicationArgs: args,
+		},
+		Logs: logs,
+	}
+}
+
+// ticketPurchased, drawSet, rollover and prizeClaimed return their event as
+// the app logs it, with a trailing byte[] following its offset
+func ticketPurchased(player types.Address, wager uint64, ticket algokeno.Commitment) []byte {
+
+	log := append(events.Selector(events.TicketPurchasedSignature), player[:]...)
+	log = append(log, itob(wager)...)
+	log = append(log, 0, 42)
+	return append(log, model.BytesArg(ticket)...)
+}
+
+func drawSet(houseFee uint64, draw algokeno.Commitment) []byte {
+
+	log := append(events.Selector(events.DrawSetSignature), itob(houseFee)...)
+	log = append(log, 0, 10)
+	return append(log, model.BytesArg(draw)...)
+}
+
+func rollover(nextApp, amount uint64) []byte {
+
+	log := append(events.Selector(events.RolloverSignature), itob(nextApp)...)
+	return append(log, itob(amount)...)
+}
+
+func prizeClaimed(player types.Address, picks int, amount uint64) []byte {
+
+	log := append(events.Selector(events.PrizeClaimedSignature), player[:]...)
+	log = append(log, itob(uint64(picks))...)
+	log = append(log, 0)
+	return append(log, itob(amount)...)
+}
+
+func itob(u uint64) []byte {
+
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, u)
+	return b
+}
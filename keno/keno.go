@@ -0,0 +1,121 @@
+// Package keno holds the number encoding and paytable of the keno game
+// mode, in which players pick 1 to 10 spots from 1 to 80, the house draws
+// 20 numbers, and each ticket pays its wager multiplied by the paytable
+// entry for the number of spots picked and hit.
+package keno
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+const (
+	MinSpots = 1
+	MaxSpots = 10
+	MaxNumber = 80
+	NumDrawn = 20
+)
+
+var (
+	ErrSpots = errors.New("picks must have between 1 and 10 spots")
+	ErrDrawLength = errors.New("draw must have exactly 20 numbers")
+	ErrOrder = errors.New("numbers must be in strictly increasing order")
+	ErrRange = errors.New("numbers must be between 1 and 80")
+)
+
+// Picks are the spots of a ticket, encoded one number per byte in strictly
+// increasing order, as in the contract's local state
+type Picks []byte
+
+// NewPicks returns the picks of nums, which must already be valid
+func NewPicks(nums ...uint8) (Picks, error) {
+
+	p := Picks(nums)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate returns an error if the contract would reject the picks
+func (p Picks) Validate() error {
+
+	if len(p) < MinSpots || len(p) > MaxSpots {
+		return ErrSpots
+	}
+	return validateNumbers(p)
+}
+
+// Spots returns the number of spots picked
+func (p Picks) Spots() int {
+
+	return len(p)
+}
+
+// Hits returns how many of the picks were drawn
+func (p Picks) Hits(d Draw) int {
+
+	var hits int
+	for i, j := 0, 0; i < len(p) && j < len(d); {
+		switch {
+		case p[i] == d[j]:
+			hits++
+			i++
+			j++
+		case p[i] < d[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return hits
+}
+
+// String returns the picks base64 encoded, as read from algod
+func (p Picks) String() string {
+
+	return base64.StdEncoding.EncodeToString(p)
+}
+
+// Draw is the numbers drawn by the house, encoded like Picks
+type Draw []byte
+
+// NewDraw returns the draw of nums, which must already be valid
+func NewDraw(nums ...uint8) (Draw, error) {
+
+	d := Draw(nums)
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Validate returns an error if the contract would reject the draw
+func (d Draw) Validate() error {
+
+	if len(d) != NumDrawn {
+		return ErrDrawLength
+	}
+	return validateNumbers(d)
+}
+
+// String returns the draw base64 encoded, as read from algod
+func (d Draw) String() string {
+
+	return base64.StdEncoding.EncodeToString(d)
+}
+
+func validateNumbers(nums []byte) error {
+
+	var prev byte
+	for _, n := range nums {
+		if n == 0 || n > MaxNumber {
+			return ErrRange
+		}
+		if n <= prev {
+			return ErrOrder
+		}
+		prev = n
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package keno
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testDraw(t *testing.T) Draw {
+
+	d, err := NewDraw(1, 2, 3, 4, 5, 6, 7, 61, 62, 63, 64, 65, 66, 67, 68, 69, 70, 71, 72, 80)
+	require.NoError(t, err)
+	return d
+}
+
+func TestPicksValidate(t *testing.T) {
+
+	testCases := []struct{
+		Name string
+		Picks Picks
+		Expected error
+	}{
+		{
+			Name: "single spot",
+			Picks: Picks{80},
+		},
+		{
+			Name: "ten spots",
+			Picks: Picks{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+		{
+			Name: "no spots",
+			Picks: Picks{},
+			Expected: ErrSpots,
+		},
+		{
+			Name: "eleven spots",
+			Picks: Picks{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+			Expected: ErrSpots,
+		},
+		{
+			Name: "zero",
+			Picks: Picks{0, 1},
+			Expected: ErrRange,
+		},
+		{
+			Name: "above 80",
+			Picks: Picks{1, 81},
+			Expected: ErrRange,
+		},
+		{
+			Name: "duplicate",
+			Picks: Picks{1, 5, 5},
+			Expected: ErrOrder,
+		},
+		{
+			Name: "unordered",
+			Picks: Picks{5, 1},
+			Expected: ErrOrder,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			require.ErrorIs(t, test.Picks.Validate(), test.Expected)
+		})
+	}
+}
+
+func TestDrawValidate(t *testing.T) {
+
+	d := testDraw(t)
+	require.Len(t, d, NumDrawn)
+
+	_, err := NewDraw(d[:NumDrawn-1]...)
+	require.ErrorIs(t, err, ErrDrawLength)
+
+	unordered := append(Draw{}, d...)
+	unordered[0], unordered[1] = unordered[1], unordered[0]
+	require.ErrorIs(t, unordered.Validate(), ErrOrder)
+}
+
+func TestHits(t *testing.T) {
+
+	d := testDraw(t)
+
+	testCases := []struct{
+		Picks Picks
+		Expected int
+	}{
+		{Picks: Picks{7}, Expected: 1},
+		{Picks: Picks{8}, Expected: 0},
+		{Picks: Picks{1, 2, 3, 80}, Expected: 4},
+		{Picks: Picks{5, 6, 7, 8}, Expected: 3},
+		{Picks: Picks{1, 2, 40, 41}, Expected: 2},
+		{Picks: Picks{8, 9, 10, 11, 12, 13, 14, 15, 16, 17}, Expected: 0},
+		{Picks: Picks{1, 3, 5, 7, 60, 61, 63, 65, 79, 80}, Expected: 8},
+	}
+
+	for _, test := range testCases {
+		require.Equal(t, test.Expected, test.Picks.Hits(d), "picks %v", []byte(test.Picks))
+	}
+}
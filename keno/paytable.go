@@ -0,0 +1,139 @@
+package keno
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+var (
+	ErrEmptyPaytable = errors.New("paytable has no rows")
+	ErrPaytableSpots = errors.New("paytable row must be for 1 to 10 spots")
+	ErrPaytableRow = errors.New("paytable row must have a multiplier for each number of hits from 0 to spots")
+	ErrNoRow = errors.New("paytable has no row for this number of spots")
+)
+
+// Paytable maps the number of spots picked to the multiplier of the wager
+// paid for each number of hits, i.e. Paytable[spots][hits]. Multipliers are
+// stored by the contract as uint16s.
+//
+// It is stored as JSON with the spots as keys, e.g.
+//
+//   {"1": [0, 3], "2": [0, 1, 9]}
+type Paytable map[int][]uint16
+
+// LoadPaytable reads and validates the paytable at path
+func LoadPaytable(path string) (Paytable, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePaytable(b)
+}
+
+// ParsePaytable parses and validates a JSON paytable
+func ParsePaytable(b []byte) (Paytable, error) {
+
+	var p Paytable
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate returns an error if any row of the paytable would be rejected by
+// the contract's SetPaytable
+func (p Paytable) Validate() error {
+
+	if len(p) == 0 {
+		return ErrEmptyPaytable
+	}
+
+	for _, spots := range p.Spots() {
+		if spots < MinSpots || spots > MaxSpots {
+			return fmt.Errorf("%w: %d", ErrPaytableSpots, spots)
+		}
+		if len(p[spots]) != spots+1 {
+			return fmt.Errorf("%w: %d spots has %d entries", ErrPaytableRow, spots, len(p[spots]))
+		}
+	}
+	return nil
+}
+
+// Spots returns the number of spots of each row, sorted
+func (p Paytable) Spots() []int {
+
+	var spots []int
+	for s := range p {
+		spots = append(spots, s)
+	}
+	sort.Ints(spots)
+	return spots
+}
+
+// Multiplier returns the multiplier of the wager paid for hits out of spots
+func (p Paytable) Multiplier(spots, hits int) (uint64, error) {
+
+	row, ok := p[spots]
+	if !ok {
+		return 0, ErrNoRow
+	}
+	if hits < 0 || hits >= len(row) {
+		return 0, fmt.Errorf("%d hits out of %d spots", hits, spots)
+	}
+	return uint64(row[hits]), nil
+}
+
+// Payout returns what a ticket of picks with wager pays for draw
+func (p Paytable) Payout(picks Picks, draw Draw, wager uint64) (uint64, error) {
+
+	m, err := p.Multiplier(picks.Spots(), picks.Hits(draw))
+	if err != nil {
+		return 0, err
+	}
+	return m * wager, nil
+}
+
+// Row returns the row for spots as it's stored in the contract, i.e. a
+// big endian uint16 multiplier for each number of hits
+func (p Paytable) Row(spots int) ([]byte, error) {
+
+	row, ok := p[spots]
+	if !ok {
+		return nil, ErrNoRow
+	}
+
+	b := make([]byte, 2*len(row))
+	for i, m := range row {
+		binary.BigEndian.PutUint16(b[2*i:], m)
+	}
+	return b, nil
+}
+
+// SetPaytableArgs returns the app args, excluding the method name, which
+// set the row for spots
+func (p Paytable) SetPaytableArgs(spots int) ([][]byte, error) {
+
+	row, err := p.Row(spots)
+	if err != nil {
+		return nil, err
+	}
+
+	s := make([]byte, 8)
+	binary.BigEndian.PutUint64(s, uint64(spots))
+	return [][]byte{s, row}, nil
+}
+
+// RowKey returns the global state key the contract stores the row for
+// spots under
+func RowKey(spots int) string {
+
+	return "pt" + string([]byte{byte(spots)})
+}
@@ -0,0 +1,142 @@
+package keno
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePaytable(t *testing.T) {
+
+	testCases := []struct{
+		Name string
+		JSON string
+		Expected error
+	}{
+		{
+			Name: "valid rows",
+			JSON: `{"1": [0, 3], "4": [0, 0, 1, 5, 100]}`,
+		},
+		{
+			Name: "empty",
+			JSON: `{}`,
+			Expected: ErrEmptyPaytable,
+		},
+		{
+			Name: "zero spots",
+			JSON: `{"0": [1]}`,
+			Expected: ErrPaytableSpots,
+		},
+		{
+			Name: "eleven spots",
+			JSON: `{"11": [0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1]}`,
+			Expected: ErrPaytableSpots,
+		},
+		{
+			Name: "row too short",
+			JSON: `{"2": [0, 9]}`,
+			Expected: ErrPaytableRow,
+		},
+		{
+			Name: "row too long",
+			JSON: `{"1": [0, 3, 9]}`,
+			Expected: ErrPaytableRow,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := ParsePaytable([]byte(test.JSON))
+			require.ErrorIs(t, err, test.Expected)
+		})
+	}
+
+	// Multipliers are stored as uint16s
+	_, err := ParsePaytable([]byte(`{"1": [0, 65536]}`))
+	require.Error(t, err)
+}
+
+func TestLoadContractPaytable(t *testing.T) {
+
+	p, err := LoadPaytable("../contract/keno_paytable.json")
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, p.Spots())
+}
+
+func TestPayout(t *testing.T) {
+
+	p := Paytable{
+		1: {0, 3},
+		4: {0, 0, 1, 5, 100},
+	}
+	d := testDraw(t)
+
+	testCases := []struct{
+		Name string
+		Picks Picks
+		Wager uint64
+		Expected uint64
+		ExpectedErr error
+	}{
+		{
+			Name: "1 of 1",
+			Picks: Picks{7},
+			Wager: 1_000_000,
+			Expected: 3_000_000,
+		},
+		{
+			Name: "0 of 1",
+			Picks: Picks{8},
+			Wager: 1_000_000,
+		},
+		{
+			Name: "4 of 4",
+			Picks: Picks{1, 2, 3, 80},
+			Wager: 2_000_000,
+			Expected: 200_000_000,
+		},
+		{
+			Name: "3 of 4",
+			Picks: Picks{5, 6, 7, 8},
+			Wager: 1_000_000,
+			Expected: 5_000_000,
+		},
+		{
+			Name: "2 of 4",
+			Picks: Picks{1, 2, 40, 41},
+			Wager: 1_000_000,
+			Expected: 1_000_000,
+		},
+		{
+			Name: "no row for 2 spots",
+			Picks: Picks{1, 2},
+			Wager: 1_000_000,
+			ExpectedErr: ErrNoRow,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			payout, err := p.Payout(test.Picks, d, test.Wager)
+			require.ErrorIs(t, err, test.ExpectedErr)
+			require.Equal(t, test.Expected, payout)
+		})
+	}
+}
+
+func TestSetPaytableArgs(t *testing.T) {
+
+	p := Paytable{4: {0, 0, 1, 5, 100}}
+
+	args, err := p.SetPaytableArgs(4)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{
+		{0, 0, 0, 0, 0, 0, 0, 4},
+		{0, 0, 0, 0, 0, 1, 0, 5, 0, 100},
+	}, args)
+
+	_, err = p.SetPaytableArgs(1)
+	require.ErrorIs(t, err, ErrNoRow)
+
+	require.Equal(t, "pt\x04", RowKey(4))
+}
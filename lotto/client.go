@@ -0,0 +1,307 @@
+// Code generated by algokeno-bindgen. DO NOT EDIT.
+
+package lotto
+
+import (
+	"github.com/algorand/go-algorand-sdk/abi"
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+)
+
+// Signatures of the app's ARC-4 methods
+const (
+	CreateSignature       = "create(uint64,uint64,uint64,uint64,uint64,uint64,(address,uint64)[],uint64)void"
+	CommitSignature       = "commit(byte[],txn)void"
+	SetDrawSignature      = "set_draw(byte[],(uint64,uint64)[],(uint64,uint64)[],application,account,account,account,account)void"
+	SetDrawAssetSignature = "set_draw_asset(byte[],(uint64,uint64)[],(uint64,uint64)[],application,account,account,account,account,asset)void"
+	ClaimSignature        = "claim()void"
+	ClaimAssetSignature   = "claim_asset(asset)void"
+	RevealSignature       = "reveal(byte[],byte[])void"
+	OptInAssetSignature   = "opt_in_asset(asset)void"
+	SponsorSignature      = "sponsor(uint64,txn)void"
+	PublishDrawSignature  = "publish_draw(byte[])void"
+)
+
+// The app's ARC-4 methods
+var (
+	MethodCreate = abi.Method{
+		Name: "create",
+		Desc: "Creates the app with its game parameters",
+		Args: []abi.Arg{
+			{Name: "picks", Type: "uint64"},
+			{Name: "max_number", Type: "uint64"},
+			{Name: "price", Type: "uint64"},
+			{Name: "fee_bps", Type: "uint64"},
+			{Name: "rollover_min", Type: "uint64"},
+			{Name: "asset", Type: "uint64"},
+			{Name: "treasury", Type: "(address,uint64)[]"},
+			{Name: "bonus_max", Type: "uint64"},
+		},
+		Returns: abi.Return{Type: "void"},
+	}
+	MethodCommit = abi.Method{
+		Name: "commit",
+		Desc: "Buys the ticket (or sealed ticket) for the wager deposited",
+		Args: []abi.Arg{
+			{Name: "ticket", Type: "byte[]"},
+			{Name: "wager", Type: "txn"},
+		},
+		Returns: abi.Return{Type: "void"},
+	}
+	MethodSetDraw = abi.Method{
+		Name: "set_draw",
+		Desc: "Sets the draw and the winners and prize of each tier, paying the house fee and rolling over what's unclaimed into the next app",
+		Args: []abi.Arg{
+			{Name: "draw", Type: "byte[]"},
+			{Name: "tiers", Type: "(uint64,uint64)[]"},
+			{Name: "bonus_tiers", Type: "(uint64,uint64)[]"},
+			{Name: "next_app", Type: "application"},
+			{Name: "next_app_address", Type: "account"},
+			{Name: "beneficiary_1", Type: "account"},
+			{Name: "beneficiary_2", Type: "account"},
+			{Name: "beneficiary_3", Type: "account"},
+		},
+		Returns: abi.Return{Type: "void"},
+	}
+	MethodSetDrawAsset = abi.Method{
+		Name: "set_draw_asset",
+		Desc: "Sets the draw of an app taking wagers in asset, as set_draw does",
+		Args: []abi.Arg{
+			{Name: "draw", Type: "byte[]"},
+			{Name: "tiers", Type: "(uint64,uint64)[]"},
+			{Name: "bonus_tiers", Type: "(uint64,uint64)[]"},
+			{Name: "next_app", Type: "application"},
+			{Name: "next_app_address", Type: "account"},
+			{Name: "beneficiary_1", Type: "account"},
+			{Name: "beneficiary_2", Type: "account"},
+			{Name: "beneficiary_3", Type: "account"},
+			{Name: "asset", Type: "asset"},
+		},
+		Returns: abi.Return{Type: "void"},
+	}
+	MethodClaim = abi.Method{
+		Name:    "claim",
+		Desc:    "Pays the sender's ticket its share of the prize of the tier it won",
+		Args:    []abi.Arg{},
+		Returns: abi.Return{Type: "void"},
+	}
+	MethodClaimAsset = abi.Method{
+		Name: "claim_asset",
+		Desc: "Pays the sender's ticket its share of the prize of the tier it won, in asset",
+		Args: []abi.Arg{
+			{Name: "asset", Type: "asset"},
+		},
+		Returns: abi.Return{Type: "void"},
+	}
+	MethodReveal = abi.Method{
+		Name: "reveal",
+		Desc: "Replaces the sender's sealed ticket with its numbers, while the draw is published",
+		Args: []abi.Arg{
+			{Name: "numbers", Type: "byte[]"},
+			{Name: "salt", Type: "byte[]"},
+		},
+		Returns: abi.Return{Type: "void"},
+	}
+	MethodOptInAsset = abi.Method{
+		Name: "opt_in_asset",
+		Desc: "Opts the app into the asset it takes wagers in",
+		Args: []abi.Arg{
+			{Name: "asset", Type: "asset"},
+		},
+		Returns: abi.Return{Type: "void"},
+	}
+	MethodSponsor = abi.Method{
+		Name: "sponsor",
+		Desc: "Adds the deposit to the prize guaranteed for tier",
+		Args: []abi.Arg{
+			{Name: "tier", Type: "uint64"},
+			{Name: "deposit", Type: "txn"},
+		},
+		Returns: abi.Return{Type: "void"},
+	}
+	MethodPublishDraw = abi.Method{
+		Name: "publish_draw",
+		Desc: "Publishes the draw before it's set, so that sealed tickets can be revealed",
+		Args: []abi.Arg{
+			{Name: "draw", Type: "byte[]"},
+		},
+		Returns: abi.Return{Type: "void"},
+	}
+)
+
+// Contract is the app's ARC-4 contract
+var Contract = abi.Contract{
+	Name: "algokeno",
+	Desc: "A lotto of rounds of tickets of picks numbers, each round its own app",
+	Methods: []abi.Method{
+		MethodCreate,
+		MethodCommit,
+		MethodSetDraw,
+		MethodSetDrawAsset,
+		MethodClaim,
+		MethodClaimAsset,
+		MethodReveal,
+		MethodOptInAsset,
+		MethodSponsor,
+		MethodPublishDraw,
+	},
+}
+
+// The app's global and local state schema
+var (
+	GlobalSchema = types.StateSchema{NumUint: 46, NumByteSlice: 4}
+	LocalSchema  = types.StateSchema{NumUint: 1, NumByteSlice: 1}
+)
+
+// Client adds calls of the app AppID, sent by Sender and signed by Signer,
+// to atomic transaction composers
+type Client struct {
+	AppID  uint64
+	Sender types.Address
+	Signer future.TransactionSigner
+}
+
+// CallOpts are the params of a single app call
+type CallOpts struct {
+	Params types.SuggestedParams
+	Note   []byte
+}
+
+// Create adds a call of
+// create(uint64,uint64,uint64,uint64,uint64,uint64,(address,uint64)[],uint64)void
+// to atc, which creates the app with its game parameters, from the approval
+// and clear programs
+func (c Client) Create(atc *future.AtomicTransactionComposer, opts CallOpts, approval, clear []byte, picks uint64, maxNumber uint64, price uint64, feeBps uint64, rolloverMin uint64, asset uint64, treasury [][]interface{}, bonusMax uint64) error {
+
+	return atc.AddMethodCall(future.AddMethodCallParams{
+		Method:          MethodCreate,
+		MethodArgs:      []interface{}{picks, maxNumber, price, feeBps, rolloverMin, asset, treasury, bonusMax},
+		Sender:          c.Sender,
+		SuggestedParams: opts.Params,
+		OnComplete:      types.NoOpOC,
+		ApprovalProgram: approval,
+		ClearProgram:    clear,
+		GlobalSchema:    GlobalSchema,
+		LocalSchema:     LocalSchema,
+		Note:            opts.Note,
+		Signer:          c.Signer,
+	})
+}
+
+// Commit adds a call of commit(byte[],txn)void to atc, which buys the
+// ticket (or sealed ticket) for the wager deposited
+func (c Client) Commit(atc *future.AtomicTransactionComposer, opts CallOpts, ticket []byte, wager future.TransactionWithSigner) error {
+
+	return c.call(atc, opts, MethodCommit, types.NoOpOC, ticket, wager)
+}
+
+// SetDraw adds a call of
+// set_draw(byte[],(uint64,uint64)[],(uint64,uint64)[],application,account,account,account,account)void
+// to atc, which sets the draw and the winners and prize of each tier,
+// paying the house fee and rolling over what's unclaimed into the next app
+func (c Client) SetDraw(atc *future.AtomicTransactionComposer, opts CallOpts, draw []byte, tiers [][2]uint64, bonusTiers [][2]uint64, nextApp uint64, nextAppAddress types.Address, beneficiary1 types.Address, beneficiary2 types.Address, beneficiary3 types.Address) error {
+
+	return c.call(atc, opts, MethodSetDraw, types.NoOpOC, draw, tiers, bonusTiers, nextApp, nextAppAddress, beneficiary1, beneficiary2, beneficiary3)
+}
+
+// SetDrawAsset adds a call of
+// set_draw_asset(byte[],(uint64,uint64)[],(uint64,uint64)[],application,account,account,account,account,asset)void
+// to atc, which sets the draw of an app taking wagers in asset, as set_draw
+// does
+func (c Client) SetDrawAsset(atc *future.AtomicTransactionComposer, opts CallOpts, draw []byte, tiers [][2]uint64, bonusTiers [][2]uint64, nextApp uint64, nextAppAddress types.Address, beneficiary1 types.Address, beneficiary2 types.Address, beneficiary3 types.Address, asset uint64) error {
+
+	return c.call(atc, opts, MethodSetDrawAsset, types.NoOpOC, draw, tiers, bonusTiers, nextApp, nextAppAddress, beneficiary1, beneficiary2, beneficiary3, asset)
+}
+
+// Claim adds a call of claim()void to atc, which pays the sender's ticket
+// its share of the prize of the tier it won
+func (c Client) Claim(atc *future.AtomicTransactionComposer, opts CallOpts) error {
+
+	return c.call(atc, opts, MethodClaim, types.NoOpOC)
+}
+
+// ClaimAsset adds a call of claim_asset(asset)void to atc, which pays the
+// sender's ticket its share of the prize of the tier it won, in asset
+func (c Client) ClaimAsset(atc *future.AtomicTransactionComposer, opts CallOpts, asset uint64) error {
+
+	return c.call(atc, opts, MethodClaimAsset, types.NoOpOC, asset)
+}
+
+// Reveal adds a call of reveal(byte[],byte[])void to atc, which replaces
+// the sender's sealed ticket with its numbers, while the draw is published
+func (c Client) Reveal(atc *future.AtomicTransactionComposer, opts CallOpts, numbers []byte, salt []byte) error {
+
+	return c.call(atc, opts, MethodReveal, types.NoOpOC, numbers, salt)
+}
+
+// OptInAsset adds a call of opt_in_asset(asset)void to atc, which opts the
+// app into the asset it takes wagers in
+func (c Client) OptInAsset(atc *future.AtomicTransactionComposer, opts CallOpts, asset uint64) error {
+
+	return c.call(atc, opts, MethodOptInAsset, types.NoOpOC, asset)
+}
+
+// Sponsor adds a call of sponsor(uint64,txn)void to atc, which adds the
+// deposit to the prize guaranteed for tier
+func (c Client) Sponsor(atc *future.AtomicTransactionComposer, opts CallOpts, tier uint64, deposit future.TransactionWithSigner) error {
+
+	return c.call(atc, opts, MethodSponsor, types.NoOpOC, tier, deposit)
+}
+
+// PublishDraw adds a call of publish_draw(byte[])void to atc, which
+// publishes the draw before it's set, so that sealed tickets can be
+// revealed
+func (c Client) PublishDraw(atc *future.AtomicTransactionComposer, opts CallOpts, draw []byte) error {
+
+	return c.call(atc, opts, MethodPublishDraw, types.NoOpOC, draw)
+}
+
+// OptIn adds a bare opt_in call to atc, which has no args
+func (c Client) OptIn(atc *future.AtomicTransactionComposer, opts CallOpts) error {
+
+	tx, err := future.MakeApplicationCallTx(
+		c.AppID,
+		nil,
+		nil,
+		nil,
+		nil,
+		types.OptInOC,
+		nil,
+		nil,
+		types.StateSchema{},
+		types.StateSchema{},
+		opts.Params,
+		c.Sender,
+		opts.Note,
+		types.Digest{},
+		[32]byte{},
+		types.Address{},
+	)
+	if err != nil {
+		return err
+	}
+	return atc.AddTransaction(future.TransactionWithSigner{
+		Txn:    tx,
+		Signer: c.Signer,
+	})
+}
+
+func (c Client) call(
+	atc *future.AtomicTransactionComposer,
+	opts CallOpts,
+	method abi.Method,
+	onComplete types.OnCompletion,
+	args ...interface{},
+) error {
+
+	return atc.AddMethodCall(future.AddMethodCallParams{
+		AppID:           c.AppID,
+		Method:          method,
+		MethodArgs:      args,
+		Sender:          c.Sender,
+		SuggestedParams: opts.Params,
+		OnComplete:      onComplete,
+		Note:            opts.Note,
+		Signer:          c.Signer,
+	})
+}
@@ -0,0 +1,113 @@
+package lotto
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// TestContractMatchesApproval checks the approval program checks the
+// selector of each of the contract's methods, and that its schema is the one
+// apps are deployed with
+func TestContractMatchesApproval(t *testing.T) {
+
+	teal, err := os.ReadFile("../contract/approval.teal")
+	require.NoError(t, err)
+
+	for _, m := range Contract.Methods {
+		require.Contains(t, string(teal), fmt.Sprintf("method %q", m.GetSignature()))
+	}
+
+	b, err := os.ReadFile("../contract/schema.json")
+	require.NoError(t, err)
+
+	var schema struct {
+		GlobalByteSlices uint64 `json:"global_byte_slices"`
+		GlobalUints uint64 `json:"global_uints"`
+		LocalByteSlices uint64 `json:"local_byte_slices"`
+		LocalUints uint64 `json:"local_uints"`
+	}
+	require.NoError(t, json.Unmarshal(b, &schema))
+	require.Equal(t, types.StateSchema{NumUint: schema.GlobalUints, NumByteSlice: schema.GlobalByteSlices}, GlobalSchema)
+	require.Equal(t, types.StateSchema{NumUint: schema.LocalUints, NumByteSlice: schema.LocalByteSlices}, LocalSchema)
+}
+
+func TestClient(t *testing.T) {
+
+	acc := crypto.GenerateAccount()
+	params := types.SuggestedParams{
+		Fee: 1000,
+		FlatFee: true,
+		FirstRoundValid: 1,
+		LastRoundValid: 1000,
+		GenesisHash: make([]byte, 32),
+	}
+	opts := CallOpts{Params: params}
+	c := Client{
+		AppID: 86,
+		Sender: acc.Address,
+		Signer: future.BasicAccountTransactionSigner{Account: acc},
+	}
+	appAddr := crypto.GetApplicationAddress(86)
+
+	payment, err := future.MakePaymentTxn(acc.Address.String(), appAddr.String(), 1_000_000, nil, "", params)
+	require.NoError(t, err)
+	wager := future.TransactionWithSigner{Txn: payment, Signer: c.Signer}
+
+	var atc future.AtomicTransactionComposer
+	require.NoError(t, c.Commit(&atc, opts, []byte{1, 2, 3, 4, 5, 6}, wager))
+	group, err := atc.BuildGroup()
+	require.NoError(t, err)
+
+	// The wager is grouped before the call, and isn't one of its app args
+	require.Len(t, group, 2)
+	require.Equal(t, types.PaymentTx, group[0].Txn.Type)
+	call := group[1].Txn
+	require.Equal(t, types.ApplicationCallTx, call.Type)
+	require.Equal(t, types.AppIndex(86), call.ApplicationID)
+	require.Equal(t, [][]byte{MethodCommit.GetSelector(), {0, 6, 1, 2, 3, 4, 5, 6}}, call.ApplicationArgs)
+
+	// References are passed as indexes into the call's foreign arrays, the
+	// sender being account 0 and the app itself app 0
+	next := crypto.GetApplicationAddress(87)
+	beneficiary := crypto.GenerateAccount().Address
+	atc = future.AtomicTransactionComposer{}
+	require.NoError(t, c.SetDraw(
+		&atc,
+		opts,
+		[]byte{1, 2, 3},
+		[][2]uint64{{0, 10}, {1, 20}, {2, 30}},
+		nil,
+		87,
+		next,
+		beneficiary,
+		acc.Address,
+		acc.Address,
+	))
+	group, err = atc.BuildGroup()
+	require.NoError(t, err)
+	require.Len(t, group, 1)
+
+	call = group[0].Txn
+	require.Equal(t, []types.Address{next, beneficiary}, call.Accounts)
+	require.Equal(t, []types.AppIndex{87}, call.ForeignApps)
+	require.Equal(t, MethodSetDraw.GetSelector(), call.ApplicationArgs[0])
+	require.Equal(t, []byte{0, 3, 1, 2, 3}, call.ApplicationArgs[1])
+	require.Len(t, call.ApplicationArgs[2], 2+3*16)
+	require.Equal(t, []byte{0, 0}, call.ApplicationArgs[3])
+	require.Equal(t, [][]byte{{1}, {1}, {2}, {0}, {0}}, call.ApplicationArgs[4:])
+
+	// Bare calls have no args
+	atc = future.AtomicTransactionComposer{}
+	require.NoError(t, c.OptIn(&atc, opts))
+	group, err = atc.BuildGroup()
+	require.NoError(t, err)
+	require.Equal(t, types.OptInOC, group[0].Txn.OnCompletion)
+	require.Empty(t, group[0].Txn.ApplicationArgs)
+}
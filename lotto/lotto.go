@@ -0,0 +1,10 @@
+// Package lotto is the typed client of the lotto app's ARC-4 methods,
+// generated from its ARC-32 app spec by algokeno-bindgen.
+//
+// Each method of Client adds a call to an AtomicTransactionComposer, so
+// calls can be grouped with each other and with other transactions before
+// the group is signed and sent. Methods taking a deposit take it as the
+// payment (or transfer of the app's asset) to group before the call.
+package lotto
+
+//go:generate go run ../cmd/algokeno-bindgen -spec ../contract/application.json -pkg lotto -out client.go
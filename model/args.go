@@ -0,0 +1,166 @@
+package model
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/neurotempest/algokeno"
+)
+
+// tierLength is the length of each tier in an ABI (uint64,uint64)[] tiers
+// arg, its winners followed by its prize
+const tierLength = 16
+
+var (
+	ErrNumArgs = errors.New("wrong number of args")
+	ErrBytesArg = errors.New("byte[] arg length prefix doesn't match its length")
+	ErrTiersArg = errors.New("tiers arg has wrong length")
+)
+
+// NumSetDrawArgs is the number of set_draw args following the method
+// selector which aren't references: the draw, the tiers and the bonus tiers
+const NumSetDrawArgs = 3
+
+// BytesArg encodes b as an ABI byte[] arg, prefixed with its length as a
+// uint16
+func BytesArg(b []byte) []byte {
+
+	arg := make([]byte, 2, 2+len(b))
+	binary.BigEndian.PutUint16(arg, uint16(len(b)))
+	return append(arg, b...)
+}
+
+// ParseBytesArg parses the ABI byte[] arg the same way the contract does
+func ParseBytesArg(arg []byte) ([]byte, error) {
+
+	if len(arg) < 2 || int(binary.BigEndian.Uint16(arg)) != len(arg)-2 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrBytesArg, len(arg))
+	}
+	return arg[2:], nil
+}
+
+// TiersArg encodes tiers as an ABI (uint64,uint64)[] arg of a (winners,
+// prize) pair per tier
+func TiersArg(tiers []Tier) []byte {
+
+	arg := make([]byte, 2, 2+len(tiers)*tierLength)
+	binary.BigEndian.PutUint16(arg, uint16(len(tiers)))
+	for _, tier := range tiers {
+		arg = append(arg, itob(tier.Winners)...)
+		arg = append(arg, itob(tier.Prize)...)
+	}
+	return arg
+}
+
+// ParseTiersArg parses the ABI (uint64,uint64)[] arg of n tiers the same
+// way the contract does
+func ParseTiersArg(arg []byte, n int) ([]Tier, error) {
+
+	if len(arg) != 2+n*tierLength || int(binary.BigEndian.Uint16(arg)) != n {
+		return nil, fmt.Errorf("%w: %d bytes for %d tiers", ErrTiersArg, len(arg), n)
+	}
+
+	tiers := make([]Tier, n)
+	for i := range tiers {
+		b := arg[2+i*tierLength:]
+		tiers[i] = Tier{
+			Winners: binary.BigEndian.Uint64(b),
+			Prize: binary.BigEndian.Uint64(b[8:]),
+		}
+	}
+	return tiers, nil
+}
+
+// TierPairs returns tiers as the (winners, prize) pairs the lotto client
+// takes them as
+func TierPairs(tiers []Tier) [][2]uint64 {
+
+	pairs := make([][2]uint64, len(tiers))
+	for i, tier := range tiers {
+		pairs[i] = [2]uint64{tier.Winners, tier.Prize}
+	}
+	return pairs
+}
+
+// SetDrawArgs encodes draw, tiers and bonusTiers (nil if it's not a bonus
+// game) as the set_draw args following the method selector, up to the
+// reference args
+func SetDrawArgs(draw algokeno.Commitment, tiers, bonusTiers []Tier) [][]byte {
+
+	return [][]byte{
+		BytesArg(draw),
+		TiersArg(tiers),
+		TiersArg(bonusTiers),
+	}
+}
+
+// ParseSetDrawArgs parses the set_draw args following the method selector
+// (up to the reference args) for an app with picks tiers the same way the
+// contract does, so any args it rejects would also be rejected by the
+// contract. Bonus games have picks+1 bonus tiers, others none.
+func ParseSetDrawArgs(args [][]byte, picks int, bonus bool) (algokeno.Commitment, []Tier, []Tier, error) {
+
+	if len(args) != NumSetDrawArgs {
+		return nil, nil, nil, fmt.Errorf("%w: %d", ErrNumArgs, len(args))
+	}
+
+	draw, err := ParseBytesArg(args[0])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tiers, err := ParseTiersArg(args[1], picks)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var bonusTiers []Tier
+	if bonus {
+		bonusTiers, err = ParseTiersArg(args[2], picks+1)
+	} else {
+		_, err = ParseTiersArg(args[2], 0)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return algokeno.Commitment(draw), tiers, bonusTiers, nil
+}
+
+func itob(u uint64) []byte {
+
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, u)
+	return b
+}
+
+// SplitArgs turns fuzzer bytes into an arg vector, so fuzz targets can
+// generate whole arg vectors: each arg is a length byte followed by that
+// many bytes. Its last arg is cut short if data runs out.
+func SplitArgs(data []byte) [][]byte {
+
+	var args [][]byte
+	for len(data) > 0 {
+		n := int(data[0])
+		data = data[1:]
+		if n > len(data) {
+			n = len(data)
+		}
+		args = append(args, data[:n])
+		data = data[n:]
+	}
+	return args
+}
+
+// JoinArgs is the inverse of SplitArgs, for seeding fuzz targets with arg
+// vectors. Args must be shorter than 256 bytes.
+func JoinArgs(args [][]byte) []byte {
+
+	var data []byte
+	for _, arg := range args {
+		data = append(data, byte(len(arg)))
+		data = append(data, arg...)
+	}
+	return data
+}
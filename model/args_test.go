@@ -0,0 +1,181 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+)
+
+func TestParseBytesArg(t *testing.T) {
+
+	arg := BytesArg([]byte{1, 2, 3})
+	require.Equal(t, []byte{0, 3, 1, 2, 3}, arg)
+
+	b, err := ParseBytesArg(arg)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, b)
+
+	b, err = ParseBytesArg(BytesArg(nil))
+	require.NoError(t, err)
+	require.Empty(t, b)
+
+	testCases := []struct{
+		Name string
+		Arg []byte
+	}{
+		{Name: "empty", Arg: nil},
+		{Name: "short prefix", Arg: []byte{0}},
+		{Name: "prefix too long", Arg: []byte{0, 4, 1, 2, 3}},
+		{Name: "prefix too short", Arg: []byte{0, 2, 1, 2, 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+
+			_, err := ParseBytesArg(tc.Arg)
+			require.ErrorIs(t, err, ErrBytesArg)
+		})
+	}
+}
+
+func TestParseSetDrawArgs(t *testing.T) {
+
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	tiers := []Tier{
+		{6, 61},
+		{5, 51},
+		{4, 41},
+		{3, 31},
+		{2, 21},
+		{1, 500000},
+	}
+
+	args := SetDrawArgs(draw, tiers, nil)
+	require.Len(t, args, NumSetDrawArgs)
+	require.Len(t, args[1], 2+algokeno.NumPicks*tierLength)
+	require.Equal(t, []byte{0, 0}, args[2])
+
+	actualDraw, actualTiers, actualBonusTiers, err := ParseSetDrawArgs(args, algokeno.NumPicks, false)
+	require.NoError(t, err)
+	require.Equal(t, draw, actualDraw)
+	require.Equal(t, tiers, actualTiers)
+	require.Nil(t, actualBonusTiers)
+
+	_, _, _, err = ParseSetDrawArgs(args[:len(args)-1], algokeno.NumPicks, false)
+	require.ErrorIs(t, err, ErrNumArgs)
+
+	_, _, _, err = ParseSetDrawArgs(nil, algokeno.NumPicks, false)
+	require.ErrorIs(t, err, ErrNumArgs)
+
+	// The draw must be ABI encoded
+	args[0] = draw
+	_, _, _, err = ParseSetDrawArgs(args, algokeno.NumPicks, false)
+	require.ErrorIs(t, err, ErrBytesArg)
+
+	// The number of tiers follows the app's pick count
+	args = SetDrawArgs(draw, tiers[:3], nil)
+	_, actualTiers, _, err = ParseSetDrawArgs(args, 3, false)
+	require.NoError(t, err)
+	require.Equal(t, tiers[:3], actualTiers)
+	_, _, _, err = ParseSetDrawArgs(args, algokeno.NumPicks, false)
+	require.ErrorIs(t, err, ErrTiersArg)
+
+	// Both the tier count and the arg's length must match it
+	args[1] = append(args[1], 0)
+	_, _, _, err = ParseSetDrawArgs(args, 3, false)
+	require.ErrorIs(t, err, ErrTiersArg)
+
+	args[1] = TiersArg(tiers[:3])
+	args[1][1] = 2
+	_, _, _, err = ParseSetDrawArgs(args, 3, false)
+	require.ErrorIs(t, err, ErrTiersArg)
+}
+
+func TestParseBonusSetDrawArgs(t *testing.T) {
+
+	draw := algokeno.Commitment{1, 2, 3}.WithBonus(4)
+	tiers := []Tier{{3, 31}, {2, 21}, {1, 11}}
+	bonusTiers := []Tier{{4, 40}, {3, 30}, {2, 20}, {1, 10}}
+
+	args := SetDrawArgs(draw, tiers, bonusTiers)
+	require.Len(t, args, NumSetDrawArgs)
+	require.Len(t, args[2], 2+4*tierLength)
+
+	actualDraw, actualTiers, actualBonusTiers, err := ParseSetDrawArgs(args, 3, true)
+	require.NoError(t, err)
+	require.Equal(t, draw, actualDraw)
+	require.Equal(t, tiers, actualTiers)
+	require.Equal(t, bonusTiers, actualBonusTiers)
+
+	// Bonus tiers are only accepted by bonus games
+	_, _, _, err = ParseSetDrawArgs(args, 3, false)
+	require.ErrorIs(t, err, ErrTiersArg)
+
+	args = SetDrawArgs(draw, tiers, bonusTiers[1:])
+	_, _, _, err = ParseSetDrawArgs(args, 3, true)
+	require.ErrorIs(t, err, ErrTiersArg)
+
+	args = SetDrawArgs(draw, tiers, nil)
+	_, _, _, err = ParseSetDrawArgs(args, 3, true)
+	require.ErrorIs(t, err, ErrTiersArg)
+}
+
+// FuzzSetDraw runs arbitrary SetDraw arg vectors against an app holding
+// escrow microalgos and checks the app never pays out more than it holds
+func FuzzSetDraw(f *testing.F) {
+
+	f.Add(uint64(2_000_000), []byte{8, 0, 6, 1, 2, 3, 4, 5, 6, 4, 0, 1, 0, 2, 2, 0, 0})
+	f.Add(uint64(4_000_000), JoinArgs(SetDrawArgs(
+		algokeno.Commitment{0, 10, 15, 20, 25, 63},
+		[]Tier{
+			{0, 0},
+			{0, 10_001},
+			{3, 30_002},
+			{0, 60_004},
+			{1, 500_000},
+			{0, 1_000_000},
+		},
+		nil,
+	)))
+	f.Add(uint64(100_000), JoinArgs(SetDrawArgs(nil, make([]Tier, algokeno.NumPicks), nil)))
+
+	creator := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+
+	f.Fuzz(func(t *testing.T, escrow uint64, data []byte) {
+
+		draw, tiers, _, err := ParseSetDrawArgs(SplitArgs(data), algokeno.NumPicks, false)
+		if err != nil {
+			return
+		}
+
+		l := New(creator)
+		l.Escrow = escrow
+		payments, err := l.SetDraw(creator, draw, tiers, next)
+		if err != nil {
+			require.Equal(t, escrow, l.Escrow)
+			return
+		}
+
+		var paid uint64
+		for _, p := range payments {
+			require.LessOrEqual(t, p.Amount, escrow-paid)
+			paid += p.Amount
+		}
+		require.Equal(t, escrow-paid, l.Escrow)
+		require.NoError(t, checkMinBalance(l.Escrow))
+	})
+}
+
+func TestSplitArgs(t *testing.T) {
+
+	args := [][]byte{{1, 2}, {}, {3}}
+	require.Equal(t, []byte{2, 1, 2, 0, 1, 3}, JoinArgs(args))
+	require.Equal(t, args, SplitArgs(JoinArgs(args)))
+
+	// The last arg is cut short if the data runs out
+	require.Equal(t, [][]byte{{1}, {2, 3}}, SplitArgs([]byte{1, 1, 5, 2, 3}))
+}
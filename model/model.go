@@ -0,0 +1,711 @@
+// Package model is a pure Go reference implementation of the lotto contract
+// in contract/contract.py. It replicates the state machine (opt-in, commit,
+// set-draw and claim) so that tests can predict the app's state and payouts
+// without hand computing them, and so that the contract can be checked for
+// divergence against it.
+//
+// The model only covers the application logic. Transaction level checks
+// which the caller controls (group layout, fees, rekeying, foreign apps) are
+// assumed to have been satisfied.
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno"
+)
+
+const (
+	// MinBalance is the minimum balance (in microalgos) of an account
+	// holding no assets, which applies to the app escrow account
+	MinBalance = 100_000
+
+	// MinWager is the minimum wager a ticket needs to be eligible to claim,
+	// i.e. the ticket price of algokeno.DefaultGameConfig
+	MinWager = 1_000_000
+)
+
+var (
+	ErrNotOptedIn = errors.New("account not opted in")
+	ErrAlreadyOptedIn = errors.New("account already opted in")
+	ErrNotCreator = errors.New("sender is not the creator")
+	ErrNoWager = errors.New("wager below minimum")
+	ErrNoCommitment = errors.New("no commitment")
+	ErrNotWinner = errors.New("commitment does not match draw")
+	ErrNoWinnersLeft = errors.New("no winners left to claim tier")
+	ErrSealed = errors.New("ticket is sealed")
+	ErrNotSealed = errors.New("ticket is not sealed")
+	ErrNoDraw = errors.New("draw not set")
+	ErrSealMismatch = errors.New("numbers and salt do not match sealed ticket")
+	ErrNoAsset = errors.New("app has no asset")
+	ErrAssetNotOptedIn = errors.New("app not opted in to its asset")
+	ErrDrawSet = errors.New("draw already set")
+	ErrNotPublished = errors.New("draw not published while tickets are sealed")
+	ErrDrawMismatch = errors.New("draw differs from the one published")
+	ErrRevealWindow = errors.New("sealed tickets can still be revealed")
+	ErrRevealClosed = errors.New("reveal window closed")
+	ErrTierRange = errors.New("tier out of range")
+	ErrBelowGuarantee = errors.New("prize below sponsored guarantee")
+	ErrBelowMinBalance = errors.New("escrow balance below min balance")
+	ErrOverflow = errors.New("uint64 overflow")
+)
+
+// Tier holds the SetDraw arguments for tickets matching a given amount of numbers
+type Tier struct {
+	Winners uint64
+	Prize uint64
+}
+
+// Share is the prize each of the tier's winners is paid
+func (t Tier) Share() uint64 {
+
+	if t.Winners == 0 {
+		return 0
+	}
+	return t.Prize / t.Winners
+}
+
+// Dust is the part of the tier's prize which isn't paid to its winners, and
+// so is rolled over. It's the whole prize if the tier has no winners.
+func (t Tier) Dust() uint64 {
+
+	return t.Prize - t.Share()*t.Winners
+}
+
+// Local is the local state of an account opted in to the app
+type Local struct {
+	Wager uint64
+	Commitment algokeno.Commitment
+}
+
+// Payment is a payment made by the app (i.e. an inner transaction)
+type Payment struct {
+	To types.Address
+	Amount uint64
+
+	// AssetID is the asset transferred, or 0 for an Algo payment
+	AssetID uint64
+}
+
+// Lotto is the modelled state of a single deployed lotto app
+type Lotto struct {
+	Creator types.Address
+	Config algokeno.GameConfig
+
+	// Escrow is the balance of the app account, or its holding of
+	// Config.AssetID if it has one
+	Escrow uint64
+
+	// AssetOptedIn is whether the app has opted in to Config.AssetID
+	AssetOptedIn bool
+
+	NumTickets uint64
+	Draw algokeno.Commitment
+
+	// NumSealed is the number of tickets still sealed
+	NumSealed uint64
+
+	// RevealBy is the last round sealed tickets can be revealed in once the
+	// draw is published, or 0 if it isn't or the draw is set
+	RevealBy uint64
+
+	// Round is the round the next call is confirmed in, which the reveal
+	// window is checked against. Callers keep it up to date.
+	Round uint64
+
+	// Tiers[i] holds the winners and prize for tickets matching i+1 numbers,
+	// for each of Config.Picks tiers
+	Tiers []Tier
+
+	// BonusTiers[i] holds the winners and prize for tickets matching i
+	// numbers and the bonus number, for each of Config.Picks+1 bonus tiers
+	// of a bonus game
+	BonusTiers []Tier
+
+	// Guaranteed[i] is the total sponsors have added to the prize of tier
+	// i+1, which SetDraw can't set it below
+	Guaranteed []uint64
+
+	// Sponsored is the total sponsors have deposited, which no house fee is
+	// taken from
+	Sponsored uint64
+
+	Locals map[types.Address]*Local
+}
+
+// New returns the state of an app freshly created with
+// algokeno.DefaultGameConfig
+func New(creator types.Address) *Lotto {
+
+	return NewWithConfig(creator, algokeno.DefaultGameConfig)
+}
+
+// NewWithConfig returns the state of an app freshly created with config,
+// which is assumed to be valid
+func NewWithConfig(creator types.Address, config algokeno.GameConfig) *Lotto {
+
+	l := &Lotto{
+		Creator: creator,
+		Config: config,
+		Tiers: make([]Tier, config.Picks),
+		Guaranteed: make([]uint64, config.Picks),
+		Locals: make(map[types.Address]*Local),
+	}
+	if config.HasBonus() {
+		l.BonusTiers = make([]Tier, config.Picks+1)
+	}
+	return l
+}
+
+// OptIn models a TxAppOptIn from addr
+func (l *Lotto) OptIn(addr types.Address) error {
+
+	if _, ok := l.Locals[addr]; ok {
+		return ErrAlreadyOptedIn
+	}
+
+	l.Locals[addr] = &Local{}
+	return nil
+}
+
+// Commit models the `commit` app call from sender grouped with a payment of
+// amount to the app account. c is either the ticket's numbers or sealed.
+// Tickets can only be bought until the draw is set.
+func (l *Lotto) Commit(sender types.Address, c algokeno.Commitment, amount uint64) error {
+
+	local, ok := l.Locals[sender]
+	if !ok {
+		return ErrNotOptedIn
+	}
+
+	if len(l.Draw) != 0 {
+		return ErrDrawSet
+	}
+
+	if !c.IsSealed() {
+		if err := c.ValidateFor(l.Config); err != nil {
+			return err
+		}
+	}
+
+	if l.Config.AssetID != 0 && !l.AssetOptedIn {
+		return ErrAssetNotOptedIn
+	}
+
+	escrow := l.Escrow + amount
+	if err := l.checkEscrow(escrow); err != nil {
+		return err
+	}
+
+	l.Escrow = escrow
+	if local.Commitment.IsSealed() {
+		l.NumSealed--
+	}
+	if c.IsSealed() {
+		l.NumSealed++
+	}
+	local.Wager = amount
+	local.Commitment = c
+	l.NumTickets++
+	return nil
+}
+
+// PublishDraw models the `publish_draw` app call from sender, which sets the
+// draw without its tiers so that sealed tickets can be revealed against it,
+// until RevealBy
+func (l *Lotto) PublishDraw(sender types.Address, draw algokeno.Commitment) error {
+
+	if sender != l.Creator {
+		return ErrNotCreator
+	}
+
+	if len(draw) == 0 {
+		return ErrNoDraw
+	}
+
+	if len(l.Draw) != 0 {
+		return ErrDrawSet
+	}
+
+	l.Draw = draw
+	l.RevealBy = l.Round + algokeno.RevealRounds
+	return nil
+}
+
+// SetDraw models the `set_draw` app call from sender, returning the payments
+// made by the app. next is the address of the app which any rollover is sent to.
+func (l *Lotto) SetDraw(
+	sender types.Address,
+	draw algokeno.Commitment,
+	tiers []Tier,
+	next types.Address,
+) ([]Payment, error) {
+
+	return l.SetBonusDraw(sender, draw, tiers, nil, next)
+}
+
+// SetBonusDraw models the `set_draw` app call of a bonus game from sender,
+// which also sets the bonus tiers, returning the payments made by the app.
+// While tickets are sealed the draw has to have been published, and can only
+// be set once they're all revealed or the reveal window is over.
+func (l *Lotto) SetBonusDraw(
+	sender types.Address,
+	draw algokeno.Commitment,
+	tiers []Tier,
+	bonusTiers []Tier,
+	next types.Address,
+) ([]Payment, error) {
+
+	if sender != l.Creator {
+		return nil, ErrNotCreator
+	}
+
+	if len(tiers) != l.Config.Picks {
+		return nil, fmt.Errorf("%w: %d tiers", ErrNumArgs, len(tiers))
+	}
+
+	if len(bonusTiers) != len(l.BonusTiers) {
+		return nil, fmt.Errorf("%w: %d bonus tiers", ErrNumArgs, len(bonusTiers))
+	}
+
+	if err := l.checkSettle(draw); err != nil {
+		return nil, err
+	}
+
+	for i, tier := range tiers {
+		if tier.Prize < l.Guaranteed[i] {
+			return nil, fmt.Errorf("%w: tier %d prize %d, guaranteed %d", ErrBelowGuarantee, i+1, tier.Prize, l.Guaranteed[i])
+		}
+	}
+
+	payments := l.houseFeePayments(HouseFee(l.Escrow-l.Sponsored, l.Config.HouseFeeBps))
+
+	ro, err := RolloverAmount(append(append([]Tier(nil), tiers...), bonusTiers...))
+	if err != nil {
+		return nil, err
+	}
+
+	if ro > l.Config.RolloverThreshold {
+		payments = append(payments, l.payment(next, ro))
+	}
+
+	escrow, err := l.pay(payments)
+	if err != nil {
+		return nil, err
+	}
+
+	l.Escrow = escrow
+	l.Draw = draw
+	l.RevealBy = 0
+	// The prize kept for each tier with winners is a multiple of their
+	// number, so every claim is paid an equal share
+	l.Tiers = withoutDust(tiers)
+	if l.Config.HasBonus() {
+		l.BonusTiers = withoutDust(bonusTiers)
+	}
+	return payments, nil
+}
+
+// checkSettle checks the tiers of draw can be set: straight away if no
+// tickets are sealed, otherwise once the draw is published and every ticket
+// is revealed or the window to reveal them is over
+func (l *Lotto) checkSettle(draw algokeno.Commitment) error {
+
+	switch {
+	case len(l.Draw) == 0 && l.NumSealed == 0:
+		return nil
+	case len(l.Draw) == 0:
+		return ErrNotPublished
+	case l.RevealBy == 0:
+		return ErrDrawSet
+	case string(draw) != string(l.Draw):
+		return ErrDrawMismatch
+	case l.NumSealed > 0 && l.Round <= l.RevealBy:
+		return fmt.Errorf("%w: %d sealed until round %d", ErrRevealWindow, l.NumSealed, l.RevealBy)
+	}
+	return nil
+}
+
+// withoutDust returns tiers with the dust taken off the prize of each tier
+// with winners
+func withoutDust(tiers []Tier) []Tier {
+
+	res := append([]Tier(nil), tiers...)
+	for i := range res {
+		if res[i].Winners > 0 {
+			res[i].Prize -= res[i].Dust()
+		}
+	}
+	return res
+}
+
+// Claim models the `claim` app call from sender, returning the payments
+// made by the app. The ticket is paid an equal share of what's left of the
+// prize of the tier it matched, split between the winners yet to claim it,
+// and is then cleared. In a bonus game tickets whose bonus number was drawn
+// claim from the bonus tiers.
+func (l *Lotto) Claim(sender types.Address) ([]Payment, error) {
+
+	local, ok := l.Locals[sender]
+	if !ok {
+		return nil, ErrNotOptedIn
+	}
+
+	if local.Wager < l.Config.TicketPrice {
+		return nil, ErrNoWager
+	}
+
+	if len(local.Commitment) == 0 {
+		return nil, ErrNoCommitment
+	}
+
+	if local.Commitment.IsSealed() {
+		return nil, ErrSealed
+	}
+
+	var tier *Tier
+	m := local.Commitment.MatchFor(l.Config, l.Draw)
+	switch {
+	case m.Bonus && m.Numbers < len(l.BonusTiers):
+		tier = &l.BonusTiers[m.Numbers]
+	case !m.Bonus && m.Numbers >= 1 && m.Numbers <= len(l.Tiers):
+		tier = &l.Tiers[m.Numbers-1]
+	default:
+		return nil, ErrNotWinner
+	}
+
+	if tier.Winners == 0 {
+		return nil, ErrNoWinnersLeft
+	}
+
+	payments := []Payment{
+		l.payment(sender, tier.Share()),
+	}
+
+	escrow, err := l.pay(payments)
+	if err != nil {
+		return nil, err
+	}
+
+	l.Escrow = escrow
+	tier.Prize -= tier.Share()
+	tier.Winners--
+	local.Wager = 0
+	local.Commitment = nil
+	return payments, nil
+}
+
+// OptInAsset models the `opt_in_asset` app call from sender, which opts the
+// app in to Config.AssetID with a transfer of 0 to itself
+func (l *Lotto) OptInAsset(sender types.Address) error {
+
+	if sender != l.Creator {
+		return ErrNotCreator
+	}
+
+	if l.Config.AssetID == 0 {
+		return ErrNoAsset
+	}
+
+	l.AssetOptedIn = true
+	return nil
+}
+
+// Sponsor models the `sponsor` app call from sender grouped with a deposit
+// of amount to the app, which is added to the guaranteed prize of tier (the
+// tier of tickets matching that many numbers)
+func (l *Lotto) Sponsor(sender types.Address, tier int, amount uint64) error {
+
+	if len(l.Draw) != 0 {
+		return ErrDrawSet
+	}
+
+	if tier < 1 || tier > l.Config.Picks {
+		return fmt.Errorf("%w: %d", ErrTierRange, tier)
+	}
+
+	if l.Config.AssetID != 0 && !l.AssetOptedIn {
+		return ErrAssetNotOptedIn
+	}
+
+	escrow := l.Escrow + amount
+	if err := l.checkEscrow(escrow); err != nil {
+		return err
+	}
+
+	guaranteed := l.Guaranteed[tier-1] + amount
+	if guaranteed < amount {
+		return ErrOverflow
+	}
+
+	l.Escrow = escrow
+	l.Guaranteed[tier-1] = guaranteed
+	l.Sponsored += amount
+	return nil
+}
+
+// Reveal models the `reveal` app call from sender, which replaces their
+// sealed ticket with its numbers while the draw is published
+func (l *Lotto) Reveal(sender types.Address, numbers algokeno.Commitment, salt []byte) error {
+
+	local, ok := l.Locals[sender]
+	if !ok {
+		return ErrNotOptedIn
+	}
+
+	if len(l.Draw) == 0 {
+		return ErrNoDraw
+	}
+
+	if l.RevealBy == 0 || l.Round > l.RevealBy {
+		return ErrRevealClosed
+	}
+
+	if !local.Commitment.IsSealed() {
+		return ErrNotSealed
+	}
+
+	if string(algokeno.Seal(numbers, salt)) != string(local.Commitment) {
+		return ErrSealMismatch
+	}
+
+	if err := numbers.ValidateFor(l.Config); err != nil {
+		return err
+	}
+
+	local.Commitment = numbers
+	l.NumSealed--
+	return nil
+}
+
+// GlobalState returns the app's global state in the same form as it is
+// read from algod, i.e. byte slices are base64 encoded and uints are base 10
+func (l *Lotto) GlobalState() map[string]string {
+
+	state := ConfigState(l.Config)
+	state["numTickets"] = strconv.FormatUint(l.NumTickets, 10)
+	state["draw"] = l.Draw.String()
+	state["numSealed"] = strconv.FormatUint(l.NumSealed, 10)
+	state["revealBy"] = strconv.FormatUint(l.RevealBy, 10)
+	state["sponsored"] = strconv.FormatUint(l.Sponsored, 10)
+	for i, tier := range l.Tiers {
+		state[fmt.Sprintf("%ds", i+1)] = strconv.FormatUint(tier.Winners, 10)
+		state[fmt.Sprintf("%dp", i+1)] = strconv.FormatUint(tier.Prize, 10)
+		state[fmt.Sprintf("%dg", i+1)] = strconv.FormatUint(l.Guaranteed[i], 10)
+	}
+	for i, tier := range l.BonusTiers {
+		state[fmt.Sprintf("%dbs", i)] = strconv.FormatUint(tier.Winners, 10)
+		state[fmt.Sprintf("%dbp", i)] = strconv.FormatUint(tier.Prize, 10)
+	}
+	return state
+}
+
+// ConfigState returns the global state keys holding config, in the same
+// form as GlobalState
+func ConfigState(config algokeno.GameConfig) map[string]string {
+
+	return map[string]string{
+		"picks": strconv.Itoa(config.Picks),
+		"maxNumber": strconv.Itoa(config.MaxNumber),
+		"price": strconv.FormatUint(config.TicketPrice, 10),
+		"feeBps": strconv.FormatUint(config.HouseFeeBps, 10),
+		"rolloverMin": strconv.FormatUint(config.RolloverThreshold, 10),
+		"asset": strconv.FormatUint(config.AssetID, 10),
+		"treasury": base64.StdEncoding.EncodeToString(algokeno.EncodeTreasury(config.Treasury)),
+		"bonusMax": strconv.Itoa(config.BonusMax),
+	}
+}
+
+// DecodeState decodes app state read from algod into the form of
+// GlobalState and LocalState, i.e. byte slices stay base64 encoded and
+// uints are base 10
+func DecodeState(state []models.TealKeyValue) (map[string]string, error) {
+
+	res := make(map[string]string, len(state))
+	for _, kv := range state {
+		key, err := base64.StdEncoding.DecodeString(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("decoding key %q: %w", kv.Key, err)
+		}
+
+		switch kv.Value.Type {
+		case 1:
+			res[string(key)] = kv.Value.Bytes
+		case 2:
+			res[string(key)] = strconv.FormatUint(kv.Value.Uint, 10)
+		default:
+			return nil, fmt.Errorf("unknown type %d of key %q", kv.Value.Type, key)
+		}
+	}
+	return res, nil
+}
+
+// ConfigFromState decodes the config an app was created with from its
+// global state, read in the same form as GlobalState
+func ConfigFromState(state map[string]string) (algokeno.GameConfig, error) {
+
+	var (
+		config algokeno.GameConfig
+		uints = map[string]*uint64{
+			"price": &config.TicketPrice,
+			"feeBps": &config.HouseFeeBps,
+			"rolloverMin": &config.RolloverThreshold,
+			"asset": &config.AssetID,
+		}
+		picks, maxNumber, bonusMax uint64
+	)
+	uints["picks"] = &picks
+	uints["maxNumber"] = &maxNumber
+	uints["bonusMax"] = &bonusMax
+
+	for key, u := range uints {
+		v, err := strconv.ParseUint(state[key], 10, 64)
+		if err != nil {
+			return algokeno.GameConfig{}, fmt.Errorf("decoding %q: %w", key, err)
+		}
+		*u = v
+	}
+	config.Picks = int(picks)
+	config.MaxNumber = int(maxNumber)
+	config.BonusMax = int(bonusMax)
+
+	b, err := base64.StdEncoding.DecodeString(state["treasury"])
+	if err != nil {
+		return algokeno.GameConfig{}, fmt.Errorf("decoding %q: %w", "treasury", err)
+	}
+
+	config.Treasury, err = algokeno.DecodeTreasury(b)
+	if err != nil {
+		return algokeno.GameConfig{}, err
+	}
+	return config, nil
+}
+
+// LocalState returns the local state of addr in the same form as it is
+// read from algod, or nil if addr has not opted in
+func (l *Lotto) LocalState(addr types.Address) map[string]string {
+
+	local, ok := l.Locals[addr]
+	if !ok {
+		return nil
+	}
+
+	return map[string]string{
+		"wager": strconv.FormatUint(local.Wager, 10),
+		"commitment": local.Commitment.String(),
+	}
+}
+
+// RolloverAmount is the sum of the prizes of all tiers without any winners,
+// and the dust of those with winners. Like TEAL's `+` it fails rather than
+// wrapping on overflow.
+func RolloverAmount(tiers []Tier) (uint64, error) {
+
+	var ro uint64
+	for _, tier := range tiers {
+		if ro+tier.Dust() < ro {
+			return 0, ErrOverflow
+		}
+		ro += tier.Dust()
+	}
+	return ro, nil
+}
+
+// HouseFee is the share of escrow sent to the treasury on SetDraw, i.e.
+// escrow*feeBps/10000 for feeBps of at most 10000, where escrow excludes
+// sponsors' deposits. Like the app's `mulw` and `divw` it's worked out in
+// 128 bits, so it never overflows.
+func HouseFee(escrow, feeBps uint64) uint64 {
+
+	hi, lo := bits.Mul64(escrow, feeBps)
+	fee, _ := bits.Div64(hi, lo, algokeno.MaxHouseFeeBps)
+	return fee
+}
+
+// HouseFeeSplit is what each of treasury's beneficiaries is paid of fee,
+// i.e. fee*ShareBps/10000 but for the last, which is paid what's left
+func HouseFeeSplit(fee uint64, treasury []algokeno.Beneficiary) []uint64 {
+
+	shares := make([]uint64, len(treasury))
+	var paid uint64
+	for i, b := range treasury {
+		if i == len(treasury)-1 {
+			shares[i] = fee - paid
+			break
+		}
+
+		shares[i] = HouseFee(fee, b.ShareBps)
+		paid += shares[i]
+	}
+	return shares
+}
+
+// houseFeePayments returns the payments of fee to the treasury, or to the
+// creator if there isn't one
+func (l *Lotto) houseFeePayments(fee uint64) []Payment {
+
+	if len(l.Config.Treasury) == 0 {
+		return []Payment{l.payment(l.Creator, fee)}
+	}
+
+	shares := HouseFeeSplit(fee, l.Config.Treasury)
+	payments := make([]Payment, len(shares))
+	for i, share := range shares {
+		payments[i] = l.payment(l.Config.Treasury[i].Address, share)
+	}
+	return payments
+}
+
+func (l *Lotto) pay(payments []Payment) (uint64, error) {
+
+	escrow := l.Escrow
+	for _, p := range payments {
+		if p.Amount > escrow {
+			return 0, fmt.Errorf("%w: paying %d from %d", ErrBelowMinBalance, p.Amount, escrow)
+		}
+		escrow -= p.Amount
+	}
+
+	if err := l.checkEscrow(escrow); err != nil {
+		return 0, err
+	}
+	return escrow, nil
+}
+
+// payment returns a payment of amount to to, in the app's asset if it has one
+func (l *Lotto) payment(to types.Address, amount uint64) Payment {
+
+	return Payment{
+		To: to,
+		Amount: amount,
+		AssetID: l.Config.AssetID,
+	}
+}
+
+// checkEscrow checks the escrow would be left with balance. Only Algo
+// balances have a minimum, asset holdings can be emptied.
+func (l *Lotto) checkEscrow(balance uint64) error {
+
+	if l.Config.AssetID != 0 {
+		return nil
+	}
+	return checkMinBalance(balance)
+}
+
+// checkMinBalance mirrors the ledger's check which is applied to every
+// account at the end of a group: accounts may be empty, otherwise they must
+// hold at least MinBalance
+func checkMinBalance(balance uint64) error {
+
+	if balance != 0 && balance < MinBalance {
+		return fmt.Errorf("%w: %d", ErrBelowMinBalance, balance)
+	}
+	return nil
+}
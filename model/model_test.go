@@ -0,0 +1,743 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+)
+
+func TestSingleWinningTicket(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	acc1 := crypto.GenerateAccount().Address
+	acc2 := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+
+	l := New(creator)
+	require.NoError(t, l.OptIn(acc1))
+	require.NoError(t, l.OptIn(acc2))
+	require.ErrorIs(t, l.OptIn(acc1), ErrAlreadyOptedIn)
+
+	require.NoError(t, l.Commit(acc1, algokeno.Commitment{1, 2, 3, 4, 5, 6}, 1_000_000))
+	require.ErrorIs(t, l.Commit(acc2, algokeno.Commitment{3, 1, 2, 4, 5, 6}, 1_000_000), algokeno.ErrCommitmentOrder)
+	require.ErrorIs(t, l.Commit(acc2, algokeno.Commitment{1, 2, 3, 4, 8}, 1_000_000), algokeno.ErrCommitmentLength)
+	require.ErrorIs(t, l.Commit(acc2, algokeno.Commitment{1, 2, 3, 4, 10, 64}, 1_000_000), algokeno.ErrCommitmentRange)
+	require.NoError(t, l.Commit(acc2, algokeno.Commitment{10, 11, 12, 13, 14, 15}, 1_000_000))
+
+	require.Equal(t, map[string]string{"wager": "1000000", "commitment": "AQIDBAUG"}, l.LocalState(acc1))
+	require.Equal(t, map[string]string{"wager": "1000000", "commitment": "CgsMDQ4P"}, l.LocalState(acc2))
+
+	tiers := []Tier{
+		{0, 10001},
+		{0, 10002},
+		{0, 10003},
+		{0, 10004},
+		{0, 10005},
+		{1, 500000},
+	}
+
+	_, err := l.SetDraw(acc1, algokeno.Commitment{1, 2, 3, 4, 5, 6}, tiers, next)
+	require.ErrorIs(t, err, ErrNotCreator)
+
+	payments, err := l.SetDraw(creator, algokeno.Commitment{1, 2, 3, 4, 5, 6}, tiers, next)
+	require.NoError(t, err)
+	require.Equal(t, []Payment{{To: creator, Amount: 200000}}, payments)
+
+	require.Equal(
+		t,
+		map[string]string{
+			"picks": "6",
+			"maxNumber": "64",
+			"price": "1000000",
+			"feeBps": "1000",
+			"rolloverMin": "100000",
+			"asset": "0",
+			"treasury": "",
+			"bonusMax": "0",
+			"numTickets": "2",
+			"numSealed": "0",
+			"revealBy": "0",
+			"sponsored": "0",
+			"draw": "AQIDBAUG",
+			"1s": "0",
+			"1p": "10001",
+			"1g": "0",
+			"2s": "0",
+			"2p": "10002",
+			"2g": "0",
+			"3s": "0",
+			"3p": "10003",
+			"3g": "0",
+			"4s": "0",
+			"4p": "10004",
+			"4g": "0",
+			"5s": "0",
+			"5p": "10005",
+			"5g": "0",
+			"6s": "1",
+			"6p": "500000",
+			"6g": "0",
+		},
+		l.GlobalState(),
+	)
+
+	_, err = l.Claim(acc2)
+	require.ErrorIs(t, err, ErrNotWinner)
+
+	payments, err = l.Claim(acc1)
+	require.NoError(t, err)
+	require.Equal(t, []Payment{{To: acc1, Amount: 500000}}, payments)
+	require.Equal(t, uint64(1_300_000), l.Escrow)
+}
+
+func TestSetDrawRollover(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+	accs := []types.Address{
+		crypto.GenerateAccount().Address,
+		crypto.GenerateAccount().Address,
+		crypto.GenerateAccount().Address,
+		crypto.GenerateAccount().Address,
+	}
+
+	l := New(creator)
+	for _, acc := range accs {
+		require.NoError(t, l.OptIn(acc))
+		require.NoError(t, l.Commit(acc, RandomCommitment(rand.New(rand.NewSource(1)), algokeno.DefaultGameConfig), 1_000_000))
+	}
+
+	payments, err := l.SetDraw(
+		creator,
+		algokeno.Commitment{0, 10, 15, 20, 25, 63},
+		[]Tier{
+			{0, 0},
+			{0, 10_001},
+			{3, 30_002},
+			{0, 60_004},
+			{1, 500_000},
+			{0, 1_000_000},
+		},
+		next,
+	)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]Payment{
+			{To: creator, Amount: 400_000},
+			{To: next, Amount: 1_070_007},
+		},
+		payments,
+	)
+	require.Equal(t, uint64(2_529_993), l.Escrow)
+	require.Equal(t, Tier{3, 30_000}, l.Tiers[2])
+}
+
+func TestClaimSplitsTierPrize(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+
+	testCases := []struct{
+		Name string
+		Winners int
+		Prize uint64
+		ExpectedShare uint64
+		ExpectedDust uint64
+	}{
+		{Name: "single winner", Winners: 1, Prize: 500_000, ExpectedShare: 500_000},
+		{Name: "even split", Winners: 2, Prize: 500_000, ExpectedShare: 250_000},
+		{Name: "dust", Winners: 3, Prize: 30_002, ExpectedShare: 10_000, ExpectedDust: 2},
+		{Name: "five winners", Winners: 5, Prize: 1_000_004, ExpectedShare: 200_000, ExpectedDust: 4},
+		{Name: "prize below winners", Winners: 4, Prize: 3, ExpectedShare: 0, ExpectedDust: 3},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			tier := Tier{uint64(test.Winners), test.Prize}
+			require.Equal(t, test.ExpectedShare, tier.Share())
+			require.Equal(t, test.ExpectedDust, tier.Dust())
+
+			l := NewWithConfig(creator, algokeno.GameConfig{
+				Picks: 3,
+				MaxNumber: 10,
+				TicketPrice: 1_000_000,
+				RolloverThreshold: math.MaxUint64,
+			})
+
+			// One more ticket matches the tier than it was set with winners
+			var accs []types.Address
+			for i := 0; i <= test.Winners; i++ {
+				acc := crypto.GenerateAccount().Address
+				require.NoError(t, l.OptIn(acc))
+				require.NoError(t, l.Commit(acc, algokeno.Commitment{1, 2, uint8(i)+3}, 1_000_000))
+				accs = append(accs, acc)
+			}
+
+			_, err := l.SetDraw(creator, algokeno.Commitment{1, 2, 9}, []Tier{{}, tier, {}}, next)
+			require.NoError(t, err)
+			require.Equal(t, test.Prize-test.ExpectedDust, l.Tiers[1].Prize)
+
+			for i, acc := range accs[:test.Winners] {
+				payments, err := l.Claim(acc)
+				require.NoError(t, err)
+				require.Equal(t, []Payment{{To: acc, Amount: test.ExpectedShare}}, payments)
+				require.Equal(t, uint64(test.Winners-i-1), l.Tiers[1].Winners)
+				require.Equal(t, map[string]string{"wager": "0", "commitment": ""}, l.LocalState(acc))
+
+				// Each ticket can only claim once
+				_, err = l.Claim(acc)
+				require.ErrorIs(t, err, ErrNoWager)
+			}
+			require.Equal(t, Tier{}, l.Tiers[1])
+
+			_, err = l.Claim(accs[test.Winners])
+			require.ErrorIs(t, err, ErrNoWinnersLeft)
+		})
+	}
+}
+
+func TestSetDrawFailsWhenRolloverExceedsEscrow(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	acc := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+
+	l := New(creator)
+	require.NoError(t, l.OptIn(acc))
+	require.NoError(t, l.Commit(acc, algokeno.Commitment{1, 2, 3, 4, 5, 6}, 1_000_000))
+
+	_, err := l.SetDraw(
+		creator,
+		algokeno.Commitment{1, 2, 3, 4, 5, 6},
+		[]Tier{5: {0, 850_000}},
+		next,
+	)
+	require.ErrorIs(t, err, ErrBelowMinBalance)
+
+	// Failed ops must not change the state
+	require.Equal(t, uint64(1_000_000), l.Escrow)
+	require.Equal(t, "", l.GlobalState()["draw"])
+}
+
+func TestGameConfig(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	acc := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+
+	l := NewWithConfig(creator, algokeno.GameConfig{
+		Picks: 3,
+		MaxNumber: 10,
+		TicketPrice: 500_000,
+		HouseFeeBps: 250,
+		RolloverThreshold: 7,
+	})
+	require.NoError(t, l.OptIn(acc))
+
+	require.ErrorIs(t, l.Commit(acc, algokeno.Commitment{1, 2, 3, 4, 5, 6}, 500_000), algokeno.ErrCommitmentLength)
+	require.ErrorIs(t, l.Commit(acc, algokeno.Commitment{1, 2, 10}, 500_000), algokeno.ErrCommitmentRange)
+	require.NoError(t, l.Commit(acc, algokeno.Commitment{1, 2, 9}, 1_000_000))
+
+	_, err := l.SetDraw(creator, algokeno.Commitment{1, 2, 9}, make([]Tier, 6), next)
+	require.ErrorIs(t, err, ErrNumArgs)
+
+	payments, err := l.SetDraw(
+		creator,
+		algokeno.Commitment{1, 2, 9},
+		[]Tier{{0, 3}, {0, 5}, {1, 400_000}},
+		next,
+	)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]Payment{
+			{To: creator, Amount: 25_000},
+			{To: next, Amount: 8},
+		},
+		payments,
+	)
+
+	require.Equal(
+		t,
+		map[string]string{
+			"picks": "3",
+			"maxNumber": "10",
+			"price": "500000",
+			"feeBps": "250",
+			"rolloverMin": "7",
+			"asset": "0",
+			"treasury": "",
+			"bonusMax": "0",
+			"numTickets": "1",
+			"numSealed": "0",
+			"revealBy": "0",
+			"sponsored": "0",
+			"draw": "AQIJ",
+			"1s": "0",
+			"1p": "3",
+			"1g": "0",
+			"2s": "0",
+			"2p": "5",
+			"2g": "0",
+			"3s": "1",
+			"3p": "400000",
+			"3g": "0",
+		},
+		l.GlobalState(),
+	)
+
+	payments, err = l.Claim(acc)
+	require.NoError(t, err)
+	require.Equal(t, []Payment{{To: acc, Amount: 400_000}}, payments)
+}
+
+func TestClaimMixedEncodings(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+	bytesAcc := crypto.GenerateAccount().Address
+	bitmaskAcc := crypto.GenerateAccount().Address
+	loser := crypto.GenerateAccount().Address
+
+	nums := []uint8{1, 2, 3, 4, 5, 63}
+	bitmask, err := algokeno.NewBitmaskCommitment(nums...)
+	require.NoError(t, err)
+
+	l := New(creator)
+	for acc, c := range map[types.Address]algokeno.Commitment{
+		bytesAcc: nums,
+		bitmaskAcc: bitmask,
+		loser: {1, 2, 3, 4, 5, 62},
+	} {
+		require.NoError(t, l.OptIn(acc))
+		require.NoError(t, l.Commit(acc, c, 1_000_000))
+	}
+
+	// A bitmask draw pays out to the matching byte encoded ticket and vice versa
+	_, err = l.SetDraw(creator, bitmask, []Tier{5: {2, 200_000}}, next)
+	require.NoError(t, err)
+
+	for _, acc := range []types.Address{bytesAcc, bitmaskAcc} {
+		payments, err := l.Claim(acc)
+		require.NoError(t, err)
+		require.Equal(t, []Payment{{To: acc, Amount: 100_000}}, payments)
+	}
+	_, err = l.Claim(loser)
+	require.ErrorIs(t, err, ErrNoWinnersLeft)
+}
+
+func TestSealedTicket(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+	acc := crypto.GenerateAccount().Address
+	plain := crypto.GenerateAccount().Address
+	invalid := crypto.GenerateAccount().Address
+
+	numbers := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	salt := []byte("salt")
+	sealed := algokeno.Seal(numbers, salt)
+
+	// Invalid numbers can be sealed, but never revealed
+	bad := algokeno.Commitment{6, 5, 4, 3, 2, 1}
+
+	l := New(creator)
+	require.NoError(t, l.OptIn(acc))
+	require.NoError(t, l.OptIn(plain))
+	require.NoError(t, l.OptIn(invalid))
+	require.NoError(t, l.Commit(acc, sealed, 1_000_000))
+	require.NoError(t, l.Commit(plain, numbers, 1_000_000))
+	require.NoError(t, l.Commit(invalid, algokeno.Seal(bad, salt), 1_000_000))
+	require.Equal(t, sealed.String(), l.LocalState(acc)["commitment"])
+
+	require.Equal(t, uint64(2), l.NumSealed)
+
+	// Tickets can only be revealed once the draw is published, which it has
+	// to be before it's set while there are sealed tickets
+	require.ErrorIs(t, l.Reveal(acc, numbers, salt), ErrNoDraw)
+	tiers := []Tier{5: {2, 200_000}}
+	_, err := l.SetDraw(creator, numbers, tiers, next)
+	require.ErrorIs(t, err, ErrNotPublished)
+
+	l.Round = 10
+	require.ErrorIs(t, l.PublishDraw(plain, numbers), ErrNotCreator)
+	require.NoError(t, l.PublishDraw(creator, numbers))
+	require.Equal(t, uint64(10+algokeno.RevealRounds), l.RevealBy)
+	require.ErrorIs(t, l.PublishDraw(creator, numbers), ErrDrawSet)
+
+	// Nor can tickets be bought once the draw is known, sealed or not
+	escrow := l.Escrow
+	require.ErrorIs(t, l.Commit(plain, algokeno.Seal(numbers, []byte("late")), 1_000_000), ErrDrawSet)
+	require.ErrorIs(t, l.Commit(plain, numbers, 1_000_000), ErrDrawSet)
+	require.Equal(t, escrow, l.Escrow)
+	require.Equal(t, uint64(3), l.NumTickets)
+
+	_, err = l.Claim(acc)
+	require.ErrorIs(t, err, ErrSealed)
+
+	require.ErrorIs(t, l.Reveal(plain, numbers, salt), ErrNotSealed)
+	require.ErrorIs(t, l.Reveal(acc, numbers, []byte("pepper")), ErrSealMismatch)
+	require.ErrorIs(t, l.Reveal(acc, algokeno.Commitment{1, 2, 3, 4, 5, 7}, salt), ErrSealMismatch)
+
+	require.NoError(t, l.Reveal(acc, numbers, salt))
+	require.Equal(t, numbers.String(), l.LocalState(acc)["commitment"])
+	require.ErrorIs(t, l.Reveal(acc, numbers, salt), ErrNotSealed)
+	require.ErrorIs(t, l.Reveal(invalid, bad, salt), algokeno.ErrCommitmentOrder)
+	require.Equal(t, uint64(1), l.NumSealed)
+
+	// The tiers wait for the reveal window while a ticket is still sealed,
+	// and are set against the draw published
+	_, err = l.SetDraw(creator, numbers, tiers, next)
+	require.ErrorIs(t, err, ErrRevealWindow)
+	l.Round = l.RevealBy + 1
+	_, err = l.SetDraw(creator, algokeno.Commitment{1, 2, 3, 4, 5, 7}, tiers, next)
+	require.ErrorIs(t, err, ErrDrawMismatch)
+	require.ErrorIs(t, l.Reveal(invalid, bad, salt), ErrRevealClosed)
+
+	_, err = l.SetDraw(creator, numbers, tiers, next)
+	require.NoError(t, err)
+	require.Zero(t, l.RevealBy)
+	_, err = l.SetDraw(creator, numbers, tiers, next)
+	require.ErrorIs(t, err, ErrDrawSet)
+
+	payments, err := l.Claim(acc)
+	require.NoError(t, err)
+	require.Equal(t, []Payment{{To: acc, Amount: 100_000}}, payments)
+
+	_, err = l.Claim(invalid)
+	require.ErrorIs(t, err, ErrSealed)
+}
+
+// TestSealedTicketsAllRevealed requires the draw to be set as soon as every
+// sealed ticket is revealed, without waiting for the reveal window
+func TestSealedTicketsAllRevealed(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+	acc := crypto.GenerateAccount().Address
+
+	numbers := algokeno.Commitment{1, 2, 3, 4, 5, 7}
+	salt := []byte("salt")
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+
+	l := New(creator)
+	require.NoError(t, l.OptIn(acc))
+	require.NoError(t, l.Commit(acc, algokeno.Seal(algokeno.Commitment{9, 10, 11, 12, 13, 14}, salt), 1_000_000))
+	// A later ticket replaces the sealed one
+	require.NoError(t, l.Commit(acc, algokeno.Seal(numbers, salt), 1_000_000))
+	require.Equal(t, uint64(1), l.NumSealed)
+
+	l.Round = 1
+	require.NoError(t, l.PublishDraw(creator, draw))
+	require.NoError(t, l.Reveal(acc, numbers, salt))
+	require.Zero(t, l.NumSealed)
+
+	_, err := l.SetDraw(creator, draw, []Tier{4: {1, 300_000}, 5: {}}, next)
+	require.NoError(t, err)
+
+	payments, err := l.Claim(acc)
+	require.NoError(t, err)
+	require.Equal(t, []Payment{{To: acc, Amount: 300_000}}, payments)
+}
+
+func TestAssetLotto(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+	acc := crypto.GenerateAccount().Address
+
+	require.ErrorIs(t, New(creator).OptInAsset(creator), ErrNoAsset)
+
+	config := algokeno.DefaultGameConfig
+	config.AssetID = 42
+	l := NewWithConfig(creator, config)
+	require.NoError(t, l.OptIn(acc))
+
+	numbers := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+
+	// The app can't take wagers until it's opted in to its asset
+	require.ErrorIs(t, l.Commit(acc, numbers, 1_000_000), ErrAssetNotOptedIn)
+	require.ErrorIs(t, l.OptInAsset(acc), ErrNotCreator)
+	require.NoError(t, l.OptInAsset(creator))
+
+	// Asset holdings have no min balance, so the escrow can be paid out in full
+	require.NoError(t, l.Commit(acc, numbers, 1_000_000))
+	payments, err := l.SetDraw(creator, numbers, []Tier{5: {1, 900_000}}, next)
+	require.NoError(t, err)
+	require.Equal(t, []Payment{{To: creator, Amount: 100_000, AssetID: 42}}, payments)
+	require.Equal(t, "42", l.GlobalState()["asset"])
+
+	payments, err = l.Claim(acc)
+	require.NoError(t, err)
+	require.Equal(t, []Payment{{To: acc, Amount: 900_000, AssetID: 42}}, payments)
+	require.Equal(t, uint64(0), l.Escrow)
+}
+
+func TestTreasury(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+	acc := crypto.GenerateAccount().Address
+	ops := crypto.GenerateAccount().Address
+	charity := crypto.GenerateAccount().Address
+
+	config := algokeno.DefaultGameConfig
+	config.Treasury = []algokeno.Beneficiary{
+		{Address: ops, ShareBps: 3_333},
+		{Address: charity, ShareBps: 6_667},
+	}
+	l := NewWithConfig(creator, config)
+	require.NoError(t, l.OptIn(acc))
+	require.NoError(t, l.Commit(acc, algokeno.Commitment{1, 2, 3, 4, 5, 6}, 1_000_001))
+
+	// The last beneficiary is paid what's left of the fee, the creator none of it
+	payments, err := l.SetDraw(creator, algokeno.Commitment{1, 2, 3, 4, 5, 6}, make([]Tier, 6), next)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]Payment{
+			{To: ops, Amount: 33_330},
+			{To: charity, Amount: 66_670},
+		},
+		payments,
+	)
+
+	decoded, err := ConfigFromState(l.GlobalState())
+	require.NoError(t, err)
+	require.Equal(t, config, decoded)
+}
+
+func TestDecodeState(t *testing.T) {
+
+	state, err := DecodeState([]models.TealKeyValue{
+		{Key: "ZHJhdw==", Value: models.TealValue{Type: 1, Bytes: "AQIDBAUG"}},
+		{Key: "Nmc=", Value: models.TealValue{Type: 2, Uint: 750_000}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"draw": "AQIDBAUG", "6g": "750000"}, state)
+
+	_, err = DecodeState([]models.TealKeyValue{{Key: "ZHJhdw==", Value: models.TealValue{Type: 3}}})
+	require.Error(t, err)
+}
+
+func TestHouseFeeSplit(t *testing.T) {
+
+	shares := HouseFeeSplit(100, []algokeno.Beneficiary{{ShareBps: 5_000}, {ShareBps: 2_501}, {ShareBps: 2_499}})
+	require.Equal(t, []uint64{50, 25, 25}, shares)
+
+	require.Empty(t, HouseFeeSplit(100, nil))
+
+	// Shares of fees too large to multiply by their bps in a uint64 are
+	// still exact
+	shares = HouseFeeSplit(math.MaxUint64, []algokeno.Beneficiary{{ShareBps: 9_999}, {ShareBps: 1}})
+	require.Equal(t, []uint64{18_444_899_399_302_180_659, 1_844_674_407_370_956}, shares)
+}
+
+func TestSponsor(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+	sponsor := crypto.GenerateAccount().Address
+	player := crypto.GenerateAccount().Address
+
+	l := New(creator)
+	require.ErrorIs(t, l.Sponsor(sponsor, 0, 500_000), ErrTierRange)
+	require.ErrorIs(t, l.Sponsor(sponsor, 7, 500_000), ErrTierRange)
+	require.ErrorIs(t, l.Sponsor(sponsor, 6, 50_000), ErrBelowMinBalance)
+
+	// Anyone can sponsor a tier, as many times as they like
+	require.NoError(t, l.Sponsor(sponsor, 6, 500_000))
+	require.NoError(t, l.Sponsor(creator, 6, 250_000))
+	require.NoError(t, l.Sponsor(sponsor, 1, 10))
+	require.Equal(t, uint64(750_010), l.Escrow)
+	require.Equal(t, "750000", l.GlobalState()["6g"])
+	require.Equal(t, "10", l.GlobalState()["1g"])
+
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	require.NoError(t, l.OptIn(player))
+	require.NoError(t, l.Commit(player, algokeno.Commitment{1, 2, 3, 4, 5, 7}, 1_000_000))
+
+	tiers := []Tier{{0, 10}, {}, {}, {}, {}, {0, 749_999}}
+	_, err := l.SetDraw(creator, draw, tiers, next)
+	require.ErrorIs(t, err, ErrBelowGuarantee)
+
+	// With no winners, the guaranteed jackpot rolls over. The house fee is
+	// only taken from the ticket, not from what sponsors deposited.
+	tiers[5].Prize = 750_000
+	payments, err := l.SetDraw(creator, draw, tiers, next)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]Payment{
+			{To: creator, Amount: 100_000},
+			{To: next, Amount: 750_010},
+		},
+		payments,
+	)
+
+	require.ErrorIs(t, l.Sponsor(sponsor, 6, 500_000), ErrDrawSet)
+}
+
+func TestSponsorNoTickets(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+
+	l := New(creator)
+	require.NoError(t, l.Sponsor(creator, 6, 2_000_000))
+	require.Equal(t, "2000000", l.GlobalState()["sponsored"])
+
+	// The escrow only holds the seeded jackpot, so there's no house fee and
+	// the whole of it rolls over
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	tiers := []Tier{{}, {}, {}, {}, {}, {0, 2_000_000}}
+	payments, err := l.SetDraw(creator, draw, tiers, next)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]Payment{
+			{To: creator, Amount: 0},
+			{To: next, Amount: 2_000_000},
+		},
+		payments,
+	)
+	require.Zero(t, l.Escrow)
+}
+
+func TestBonusGame(t *testing.T) {
+
+	creator := crypto.GenerateAccount().Address
+	next := crypto.GenerateAccount().Address
+
+	config := algokeno.DefaultGameConfig
+	config.Picks, config.MaxNumber, config.BonusMax = 5, 70, 26
+	l := NewWithConfig(creator, config)
+	require.Len(t, l.BonusTiers, 6)
+	require.Equal(t, "0", l.GlobalState()["0bs"])
+	require.Equal(t, "0", l.GlobalState()["5bp"])
+	require.Equal(t, "26", l.GlobalState()["bonusMax"])
+
+	picks := algokeno.Commitment{1, 2, 3, 4, 5}
+	draw := picks.WithBonus(7)
+	tickets := map[types.Address]algokeno.Commitment{
+		crypto.GenerateAccount().Address: picks.WithBonus(7),
+		crypto.GenerateAccount().Address: algokeno.Commitment{1, 2, 3, 4, 6}.WithBonus(8),
+		crypto.GenerateAccount().Address: algokeno.Commitment{10, 20, 30, 40, 50}.WithBonus(7),
+		crypto.GenerateAccount().Address: algokeno.Commitment{10, 20, 30, 40, 50}.WithBonus(8),
+	}
+	for player, ticket := range tickets {
+		require.NoError(t, l.OptIn(player))
+		require.Error(t, l.Commit(player, ticket.Picks(config), 1_000_000))
+		require.NoError(t, l.Commit(player, ticket, 1_000_000))
+	}
+
+	tiers := []Tier{{}, {}, {}, {1, 300_000}, {0, 200_000}}
+	bonusTiers := []Tier{{1, 100_000}, {}, {}, {}, {}, {1, 2_000_000}}
+
+	_, err := l.SetDraw(creator, draw, tiers, next)
+	require.ErrorIs(t, err, ErrNumArgs)
+
+	// Bonus tiers without winners roll over with the rest
+	bonusTiers[2].Prize = 50_000
+	payments, err := l.SetBonusDraw(creator, draw, tiers, bonusTiers, next)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]Payment{
+			{To: creator, Amount: 400_000},
+			{To: next, Amount: 250_000},
+		},
+		payments,
+	)
+	require.Equal(t, "2000000", l.GlobalState()["5bp"])
+	require.Equal(t, "1", l.GlobalState()["0bs"])
+
+	expectedPayouts := []uint64{2_000_000, 300_000, 100_000, 0}
+	for player, ticket := range tickets {
+		payments, err := l.Claim(player)
+
+		var expected uint64
+		switch m := ticket.MatchFor(config, draw); {
+		case m.Bonus && m.Numbers == 5:
+			expected = expectedPayouts[0]
+		case m.Numbers == 4:
+			expected = expectedPayouts[1]
+		case m.Bonus:
+			expected = expectedPayouts[2]
+		default:
+			require.ErrorIs(t, err, ErrNotWinner)
+			continue
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, []Payment{{To: player, Amount: expected}}, payments)
+	}
+	require.Equal(t, "0", l.GlobalState()["5bp"])
+	require.Equal(t, "0", l.GlobalState()["0bs"])
+}
+
+func TestHouseFee(t *testing.T) {
+
+	require.Equal(t, uint64(400_000), HouseFee(4_000_000, 1_000))
+	require.Equal(t, uint64(0), HouseFee(4_000_000, 0))
+	require.Equal(t, uint64(math.MaxUint64/10), HouseFee(math.MaxUint64, 1_000))
+	require.Equal(t, uint64(math.MaxUint64), HouseFee(math.MaxUint64, algokeno.MaxHouseFeeBps))
+}
+
+// TestRandomOpsConserveFunds drives random operation sequences through the
+// model and checks that every microalgo paid in is either still in escrow or
+// has been paid out, and that the escrow never drops below the min balance
+func TestRandomOpsConserveFunds(t *testing.T) {
+
+	for seed := int64(0); seed < 200; seed++ {
+		r := rand.New(rand.NewSource(seed))
+
+		creator := crypto.GenerateAccount().Address
+		next := crypto.GenerateAccount().Address
+		g := OpGenerator{
+			Rand: r,
+			Creator: creator,
+			Players: []types.Address{
+				crypto.GenerateAccount().Address,
+				crypto.GenerateAccount().Address,
+				crypto.GenerateAccount().Address,
+			},
+			Config: algokeno.DefaultGameConfig,
+			MaxWager: 5_000_000,
+		}
+
+		l := New(creator)
+		var paidIn, paidOut uint64
+		for _, op := range g.Ops(100) {
+			before := *l
+			payments, err := l.Apply(op, next)
+			if err != nil {
+				require.Nil(t, payments)
+				require.Equal(t, before.Escrow, l.Escrow, "seed %d: failed %v changed escrow", seed, op)
+				require.Equal(t, before.NumTickets, l.NumTickets, "seed %d: failed %v changed numTickets", seed, op)
+				require.False(t, errors.Is(err, ErrOverflow), "seed %d: %v overflowed", seed, op)
+				continue
+			}
+
+			if op.Type == OpCommit || op.Type == OpSponsor {
+				paidIn += op.Amount
+			}
+			for _, p := range payments {
+				paidOut += p.Amount
+			}
+
+			require.Equal(t, paidIn, l.Escrow+paidOut, "seed %d: funds not conserved after %v", seed, op)
+			require.NoError(t, checkMinBalance(l.Escrow), "seed %d: after %v", seed, op)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package model
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno"
+)
+
+// OpType is one of the operations supported by the contract
+type OpType int
+
+const (
+	OpOptIn OpType = iota
+	OpCommit
+	OpSetDraw
+	OpClaim
+	OpReveal
+	OpOptInAsset
+	OpSponsor
+	OpPublishDraw
+)
+
+func (t OpType) String() string {
+
+	switch t {
+	case OpOptIn:
+		return "OptIn"
+	case OpCommit:
+		return "Commit"
+	case OpSetDraw:
+		return "SetDraw"
+	case OpClaim:
+		return "Claim"
+	case OpReveal:
+		return "Reveal"
+	case OpOptInAsset:
+		return "OptInAsset"
+	case OpSponsor:
+		return "Sponsor"
+	case OpPublishDraw:
+		return "PublishDraw"
+	}
+	return fmt.Sprintf("OpType(%d)", int(t))
+}
+
+// Op is a single operation against the app. Which fields are used depends on Type:
+//   - OpCommit uses Commitment and Amount (the wager payment, or asset
+//     transfer if the app has an asset)
+//   - OpSetDraw uses Commitment (the draw) and Tiers
+//   - OpReveal uses Commitment (the numbers) and Salt
+//   - OpSponsor uses Tier and Amount (the deposit)
+//   - OpPublishDraw uses Commitment (the draw)
+type Op struct {
+	Type OpType
+	Sender types.Address
+	Commitment algokeno.Commitment
+	Amount uint64
+	Tiers []Tier
+	Salt []byte
+	Tier int
+}
+
+func (op Op) String() string {
+
+	switch op.Type {
+	case OpCommit:
+		return fmt.Sprintf("%v(%v, %v, %d)", op.Type, op.Sender, []byte(op.Commitment), op.Amount)
+	case OpSetDraw:
+		return fmt.Sprintf("%v(%v, %v, %v)", op.Type, op.Sender, []byte(op.Commitment), op.Tiers)
+	case OpReveal:
+		return fmt.Sprintf("%v(%v, %v, %x)", op.Type, op.Sender, []byte(op.Commitment), op.Salt)
+	case OpSponsor:
+		return fmt.Sprintf("%v(%v, %d, %d)", op.Type, op.Sender, op.Tier, op.Amount)
+	case OpPublishDraw:
+		return fmt.Sprintf("%v(%v, %v)", op.Type, op.Sender, []byte(op.Commitment))
+	}
+	return fmt.Sprintf("%v(%v)", op.Type, op.Sender)
+}
+
+// Apply applies op to the model, returning the payments made by the app.
+// next is the address any SetDraw rollover is sent to.
+func (l *Lotto) Apply(op Op, next types.Address) ([]Payment, error) {
+
+	switch op.Type {
+	case OpOptIn:
+		return nil, l.OptIn(op.Sender)
+	case OpCommit:
+		return nil, l.Commit(op.Sender, op.Commitment, op.Amount)
+	case OpSetDraw:
+		return l.SetDraw(op.Sender, op.Commitment, op.Tiers, next)
+	case OpClaim:
+		return l.Claim(op.Sender)
+	case OpReveal:
+		return nil, l.Reveal(op.Sender, op.Commitment, op.Salt)
+	case OpOptInAsset:
+		return nil, l.OptInAsset(op.Sender)
+	case OpSponsor:
+		return nil, l.Sponsor(op.Sender, op.Tier, op.Amount)
+	case OpPublishDraw:
+		return nil, l.PublishDraw(op.Sender, op.Commitment)
+	}
+	return nil, fmt.Errorf("unknown op type: %v", op.Type)
+}
+
+// OpGenerator generates random operation sequences which are biased towards
+// operations that succeed (e.g. valid commitments from opted in accounts),
+// while still producing a fair share of invalid ones
+type OpGenerator struct {
+	Rand *rand.Rand
+	Creator types.Address
+	Players []types.Address
+
+	// Config is the game config of the app the ops are generated for
+	Config algokeno.GameConfig
+
+	// MaxWager bounds the amount paid with each Commit
+	MaxWager uint64
+
+	committed []algokeno.Commitment
+	sealed map[types.Address]Op
+	published algokeno.Commitment
+}
+
+// Ops returns n random operations
+func (g *OpGenerator) Ops(n int) []Op {
+
+	ops := make([]Op, n)
+	for i := range ops {
+		ops[i] = g.Op()
+	}
+	return ops
+}
+
+// Op returns a single random operation
+func (g *OpGenerator) Op() Op {
+
+	r := g.Rand
+	sender := g.Players[r.Intn(len(g.Players))]
+
+	switch p := r.Intn(100); {
+	case p < 15:
+		return Op{
+			Type: OpOptIn,
+			Sender: sender,
+		}
+	case p < 60:
+		c := g.commitment()
+		g.committed = append(g.committed, c)
+		op := Op{
+			Type: OpCommit,
+			Sender: sender,
+			Commitment: c,
+			Amount: g.wager(),
+		}
+		// Seal some tickets, remembering how to reveal them
+		if r.Intn(4) == 0 {
+			salt := make([]byte, r.Intn(33))
+			r.Read(salt)
+			if g.sealed == nil {
+				g.sealed = make(map[types.Address]Op)
+			}
+			g.sealed[sender] = Op{
+				Type: OpReveal,
+				Sender: sender,
+				Commitment: c,
+				Salt: salt,
+			}
+			op.Commitment = algokeno.Seal(c, salt)
+		}
+		return op
+	case p < 75:
+		if r.Intn(5) != 0 {
+			sender = g.Creator
+		}
+		if r.Intn(4) == 0 {
+			g.published = g.draw()
+			return Op{
+				Type: OpPublishDraw,
+				Sender: sender,
+				Commitment: g.published,
+			}
+		}
+		op := Op{
+			Type: OpSetDraw,
+			Sender: sender,
+			Commitment: g.draw(),
+		}
+		// Set the published draw most of the time, as no other can be
+		if g.published != nil && r.Intn(5) != 0 {
+			op.Commitment = g.published
+		}
+		op.Tiers = make([]Tier, g.Config.Picks)
+		for i := range op.Tiers {
+			op.Tiers[i] = Tier{
+				Winners: uint64(r.Intn(3)),
+				Prize: uint64(r.Int63n(int64(g.MaxWager)/4 + 1)),
+			}
+		}
+		return op
+	case p < 87:
+		return Op{
+			Type: OpClaim,
+			Sender: sender,
+		}
+	case p < 90:
+		// Mostly small deposits, so SetDraw prizes still clear them
+		return Op{
+			Type: OpSponsor,
+			Sender: sender,
+			Tier: r.Intn(g.Config.Picks+2),
+			Amount: uint64(r.Int63n(int64(g.MaxWager)/20 + 1)),
+		}
+	default:
+		op, ok := g.sealed[sender]
+		if !ok || r.Intn(5) == 0 {
+			op = Op{
+				Type: OpReveal,
+				Sender: sender,
+				Commitment: g.commitment(),
+				Salt: []byte{byte(r.Intn(256))},
+			}
+		}
+		return op
+	}
+}
+
+// draw returns a random draw, which half of the time is a previously
+// committed ticket so that claims get a chance to succeed
+func (g *OpGenerator) draw() algokeno.Commitment {
+
+	r := g.Rand
+	if len(g.committed) > 0 && r.Intn(2) == 0 {
+		return g.committed[r.Intn(len(g.committed))]
+	}
+	return g.commitment()
+}
+
+// commitment returns a random commitment, which is valid most of the time
+func (g *OpGenerator) commitment() algokeno.Commitment {
+
+	r := g.Rand
+	if r.Intn(5) == 0 {
+		c := make(algokeno.Commitment, r.Intn(g.Config.Picks+2))
+		r.Read(c)
+		return c
+	}
+	return RandomCommitment(r, g.Config)
+}
+
+func (g *OpGenerator) wager() uint64 {
+
+	r := g.Rand
+	if r.Intn(2) == 0 {
+		return g.Config.TicketPrice
+	}
+	return uint64(r.Int63n(int64(g.MaxWager) + 1))
+}
+
+// RandomCommitment returns a valid commitment of config.Picks distinct numbers
+func RandomCommitment(r *rand.Rand, config algokeno.GameConfig) algokeno.Commitment {
+
+	perm := r.Perm(config.MaxNumber)[:config.Picks]
+	sort.Ints(perm)
+	c := make(algokeno.Commitment, config.Picks)
+	for i, n := range perm {
+		c[i] = byte(n)
+	}
+	return c
+}
@@ -0,0 +1,267 @@
+// Package odds computes the probability of each tier of a lotto app from
+// its config, and the return of a ticket given the tiers' prize pools: its
+// expected value, variance and the house edge, and the jackpot at which a
+// ticket breaks even.
+//
+// Probabilities are exact (hypergeometric, as a draw and a ticket pick the
+// same number of numbers without replacement). Returns assume the other
+// tickets are picked at random, e.g. quick picks, and follow the contract
+// in sharing each tier's prize pool equally between its winners, with
+// the pools of tiers without winners rolling over rather than paid out.
+package odds
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/model"
+)
+
+var (
+	ErrNoTickets = errors.New("at least one ticket must be sold")
+	ErrPoolsLength = errors.New("wrong number of prize pools")
+	ErrShares = errors.New("shares must add up to at most 100%")
+)
+
+// Hypergeometric returns the exact probability of drawing k of successes
+// numbers when draws numbers are drawn without replacement from
+// population, i.e. C(successes, k) * C(population-successes, draws-k) /
+// C(population, draws)
+func Hypergeometric(population, successes, draws, k int) *big.Rat {
+
+	if k < 0 || k > successes || k > draws || draws-k > population-successes {
+		return new(big.Rat)
+	}
+
+	num := new(big.Int).Binomial(int64(successes), int64(k))
+	num.Mul(num, new(big.Int).Binomial(int64(population-successes), int64(draws-k)))
+	return new(big.Rat).SetFrac(num, new(big.Int).Binomial(int64(population), int64(draws)))
+}
+
+// Tiers returns the probability of a ticket of an app created with config
+// winning each of its config.Picks tiers, i.e. the probability at index i
+// is of matching i+1 numbers, and in a bonus game not the bonus number
+func Tiers(config algokeno.GameConfig) []*big.Rat {
+
+	tiers := make([]*big.Rat, config.Picks)
+	for i := range tiers {
+		tiers[i] = Hypergeometric(config.MaxNumber, config.Picks, config.Picks, i+1)
+		if config.HasBonus() {
+			tiers[i].Mul(tiers[i], big.NewRat(int64(config.BonusMax-1), int64(config.BonusMax)))
+		}
+	}
+	return tiers
+}
+
+// BonusTiers returns the probability of a ticket of a bonus game created
+// with config winning each of its config.Picks+1 bonus tiers, i.e. the
+// probability at index i is of matching i numbers and the bonus number.
+// It's nil if config isn't a bonus game.
+func BonusTiers(config algokeno.GameConfig) []*big.Rat {
+
+	if !config.HasBonus() {
+		return nil
+	}
+
+	tiers := make([]*big.Rat, config.Picks+1)
+	for i := range tiers {
+		tiers[i] = Hypergeometric(config.MaxNumber, config.Picks, config.Picks, i)
+		tiers[i].Mul(tiers[i], big.NewRat(1, int64(config.BonusMax)))
+	}
+	return tiers
+}
+
+// Return is what a ticket is expected to be paid, in microalgos or the
+// app's asset
+type Return struct {
+	// EV is the expected payout of a ticket
+	EV float64
+
+	// Variance is the variance of the payout of a ticket
+	Variance float64
+
+	// HouseEdge is the share of the ticket price a ticket is expected to
+	// lose, i.e. 1 - EV/TicketPrice. It's the house fee if every pool is
+	// paid out in full, and more if some are expected to roll over.
+	HouseEdge float64
+}
+
+// TicketReturn returns the return of a ticket of an app created with
+// config when tickets are sold, given the prize pool of each tier, indexed
+// like Tiers, and of each bonus tier, indexed like BonusTiers. With a
+// single ticket sold each pool is a fixed prize.
+func TicketReturn(config algokeno.GameConfig, tickets uint64, pools, bonusPools []uint64) (Return, error) {
+
+	probs, err := tierPools(config, tickets, pools, bonusPools)
+	if err != nil {
+		return Return{}, err
+	}
+
+	var ev, ev2 float64
+	for _, t := range probs {
+		share, share2 := shareMoments(t.p, tickets)
+		ev += t.p * t.pool * share
+		ev2 += t.p * t.pool * t.pool * share2
+	}
+
+	return Return{
+		EV: ev,
+		Variance: math.Max(ev2-ev*ev, 0),
+		HouseEdge: 1 - ev/float64(config.TicketPrice),
+	}, nil
+}
+
+// BreakEvenJackpot returns the smallest prize pool of the jackpot (the
+// tier of tickets matching every number, and in a bonus game the bonus
+// number too) for which a ticket's EV is at least the ticket price, given
+// the pools of the other tiers. The jackpot's own entry in pools or
+// bonusPools is ignored.
+func BreakEvenJackpot(config algokeno.GameConfig, tickets uint64, pools, bonusPools []uint64) (uint64, error) {
+
+	probs, err := tierPools(config, tickets, pools, bonusPools)
+	if err != nil {
+		return 0, err
+	}
+
+	// The jackpot is the last of the tiers, or of the bonus tiers
+	jackpot := probs[len(probs)-1]
+
+	var ev float64
+	for _, t := range probs[:len(probs)-1] {
+		share, _ := shareMoments(t.p, tickets)
+		ev += t.p * t.pool * share
+	}
+
+	short := float64(config.TicketPrice) - ev
+	if short <= 0 {
+		return 0, nil
+	}
+
+	share, _ := shareMoments(jackpot.p, tickets)
+	return uint64(math.Ceil(short / (jackpot.p * share))), nil
+}
+
+// Pools returns the prize pools of the tiers and bonus tiers of an app
+// created with config once tickets are sold at its ticket price, splitting
+// what's left once the house fee is paid by shareBps and bonusShareBps, in
+// basis points and indexed like Tiers and BonusTiers
+func Pools(config algokeno.GameConfig, tickets uint64, shareBps, bonusShareBps []uint64) ([]uint64, []uint64, error) {
+
+	if err := checkLengths(config, shareBps, bonusShareBps); err != nil {
+		return nil, nil, err
+	}
+
+	var total uint64
+	for _, share := range append(append([]uint64(nil), shareBps...), bonusShareBps...) {
+		total += share
+	}
+	if total > algokeno.MaxHouseFeeBps {
+		return nil, nil, fmt.Errorf("%w: %d bps", ErrShares, total)
+	}
+
+	escrow := tickets * config.TicketPrice
+	if config.TicketPrice != 0 && escrow/config.TicketPrice != tickets {
+		return nil, nil, model.ErrOverflow
+	}
+
+	pot := new(big.Int).SetUint64(escrow - model.HouseFee(escrow, config.HouseFeeBps))
+	split := func(shares []uint64) []uint64 {
+		if shares == nil {
+			return nil
+		}
+
+		pools := make([]uint64, len(shares))
+		for i, share := range shares {
+			pool := new(big.Int).Mul(pot, new(big.Int).SetUint64(share))
+			pools[i] = pool.Div(pool, big.NewInt(algokeno.MaxHouseFeeBps)).Uint64()
+		}
+		return pools
+	}
+	return split(shareBps), split(bonusShareBps), nil
+}
+
+// tierPool is the probability of a ticket winning a tier, and its pool
+type tierPool struct {
+	p float64
+	pool float64
+}
+
+// tierPools returns each of the tiers followed by each of the bonus tiers
+// of an app created with config
+func tierPools(config algokeno.GameConfig, tickets uint64, pools, bonusPools []uint64) ([]tierPool, error) {
+
+	if tickets == 0 {
+		return nil, ErrNoTickets
+	}
+
+	if err := checkLengths(config, pools, bonusPools); err != nil {
+		return nil, err
+	}
+
+	var res []tierPool
+	add := func(probs []*big.Rat, pools []uint64) {
+		for i, p := range probs {
+			f, _ := p.Float64()
+			res = append(res, tierPool{p: f, pool: float64(pools[i])})
+		}
+	}
+	add(Tiers(config), pools)
+	add(BonusTiers(config), bonusPools)
+	return res, nil
+}
+
+func checkLengths(config algokeno.GameConfig, tiers, bonusTiers []uint64) error {
+
+	if len(tiers) != config.Picks {
+		return fmt.Errorf("%w: %d tiers of %d", ErrPoolsLength, len(tiers), config.Picks)
+	}
+
+	var numBonus int
+	if config.HasBonus() {
+		numBonus = config.Picks + 1
+	}
+	if len(bonusTiers) != numBonus {
+		return fmt.Errorf("%w: %d bonus tiers of %d", ErrPoolsLength, len(bonusTiers), numBonus)
+	}
+	return nil
+}
+
+// shareMoments returns E[1/(1+Y)] and E[1/(1+Y)^2] for Y ~ Binomial(n-1,
+// p), i.e. the expected first and second moments of the share of a pool
+// paid to a winning ticket, which splits it with the Y other tickets of the
+// n sold that win it too
+func shareMoments(p float64, n uint64) (float64, float64) {
+
+	others := float64(n - 1)
+	if p == 0 || others == 0 {
+		return 1, 1
+	}
+	if p == 1 {
+		return 1 / (1 + others), 1 / ((1 + others) * (1 + others))
+	}
+
+	// E[1/(1+Y)] = (1 - (1-p)^n) / (n p)
+	first := -math.Expm1(float64(n)*math.Log1p(-p)) / (float64(n) * p)
+
+	// E[1/(1+Y)^2] has no closed form, so it's summed over the pmf of Y,
+	// computed in log space from pmf(0) = (1-p)^(n-1), until what's left
+	// of its tail past the mean can't matter
+	var second, mass float64
+	logPmf := others * math.Log1p(-p)
+	logOdds := math.Log(p) - math.Log1p(-p)
+	mean := others * p
+	for y := 0.0; y <= others; y++ {
+		pmf := math.Exp(logPmf)
+		second += pmf / ((1 + y) * (1 + y))
+		mass += pmf
+
+		if y > mean && (pmf < 1e-18 || 1-mass < 1e-15) {
+			break
+		}
+		logPmf += math.Log((others-y)/(y+1)) + logOdds
+	}
+	return first, second
+}
@@ -0,0 +1,189 @@
+package odds
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/model"
+)
+
+func TestTiers(t *testing.T) {
+
+	config := algokeno.DefaultGameConfig
+	config.MaxNumber = 49
+
+	tiers := Tiers(config)
+	require.Len(t, tiers, 6)
+	require.Nil(t, BonusTiers(config))
+	require.Equal(t, big.NewRat(1, 13_983_816), tiers[5])
+	require.Equal(t, big.NewRat(258, 13_983_816), tiers[4])
+	require.Equal(t, big.NewRat(13_545, 13_983_816), tiers[3])
+
+	// With the odds of matching none, the tiers add up to 1
+	total := Hypergeometric(49, 6, 6, 0)
+	for _, p := range tiers {
+		total.Add(total, p)
+	}
+	require.Equal(t, big.NewRat(1, 1), total)
+}
+
+func TestBonusTiers(t *testing.T) {
+
+	config := algokeno.DefaultGameConfig
+	config.Picks, config.MaxNumber, config.BonusMax = 5, 69, 26
+
+	tiers := Tiers(config)
+	bonusTiers := BonusTiers(config)
+	require.Len(t, tiers, 5)
+	require.Len(t, bonusTiers, 6)
+	require.Equal(t, big.NewRat(1, 292_201_338), bonusTiers[5])
+	require.Equal(t, big.NewRat(25, 292_201_338), tiers[4])
+
+	total := new(big.Rat).Mul(Hypergeometric(69, 5, 5, 0), big.NewRat(25, 26))
+	for _, p := range append(tiers, bonusTiers...) {
+		total.Add(total, p)
+	}
+	require.Equal(t, big.NewRat(1, 1), total)
+}
+
+func TestHypergeometric(t *testing.T) {
+
+	// 20 numbers drawn of 80, as in keno, hitting all 10 spots of 10
+	require.Equal(t, big.NewRat(17, 151_499_090), Hypergeometric(80, 20, 10, 10))
+	require.Equal(t, new(big.Rat), Hypergeometric(10, 3, 3, 4))
+	require.Equal(t, new(big.Rat), Hypergeometric(10, 3, 3, -1))
+}
+
+func TestTicketReturn(t *testing.T) {
+
+	config := algokeno.DefaultGameConfig
+	config.MaxNumber = 49
+	pools := []uint64{0, 0, 10_000_000, 100_000_000, 1_000_000_000, 1_000_000_000_000}
+
+	// A single ticket wins each pool whole
+	ret, err := TicketReturn(config, 1, pools, nil)
+	require.NoError(t, err)
+
+	var ev, ev2 float64
+	for i, p := range Tiers(config) {
+		f, _ := p.Float64()
+		ev += f * float64(pools[i])
+		ev2 += f * float64(pools[i]) * float64(pools[i])
+	}
+	require.InDelta(t, ev, ret.EV, 1e-6)
+	require.InDelta(t, ev2-ev*ev, ret.Variance, ret.Variance*1e-9)
+	require.InDelta(t, 1-ev/1_000_000, ret.HouseEdge, 1e-9)
+
+	// With nothing in the pools a ticket loses its whole price
+	ret, err = TicketReturn(config, 1_000, []uint64{0, 0, 0, 0, 0, 0}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0.0, ret.EV)
+	require.InDelta(t, 1, ret.HouseEdge, 1e-9)
+
+	_, err = TicketReturn(config, 0, pools, nil)
+	require.ErrorIs(t, err, ErrNoTickets)
+
+	_, err = TicketReturn(config, 1, pools[1:], nil)
+	require.ErrorIs(t, err, ErrPoolsLength)
+
+	_, err = TicketReturn(config, 1, pools, pools)
+	require.ErrorIs(t, err, ErrPoolsLength)
+}
+
+func TestBreakEvenJackpot(t *testing.T) {
+
+	config := algokeno.DefaultGameConfig
+	config.MaxNumber = 49
+	pools := []uint64{0, 0, 0, 0, 0, 0}
+
+	jackpot, err := BreakEvenJackpot(config, 1, pools, nil)
+	require.NoError(t, err)
+	require.InDelta(t, 13_983_816*1_000_000, float64(jackpot), 1)
+
+	// It takes a bigger jackpot to break even when it may be shared
+	shared, err := BreakEvenJackpot(config, 10_000_000, pools, nil)
+	require.NoError(t, err)
+	require.Greater(t, shared, jackpot)
+
+	pools[5] = shared
+	ret, err := TicketReturn(config, 10_000_000, pools, nil)
+	require.NoError(t, err)
+	require.InDelta(t, 1_000_000, ret.EV, 1)
+
+	// Nothing more is needed if the other tiers already pay the price
+	pools = []uint64{0, 0, 1_000_000_000, 0, 0, 0}
+	jackpot, err = BreakEvenJackpot(config, 1, pools, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), jackpot)
+}
+
+func TestPools(t *testing.T) {
+
+	config := algokeno.DefaultGameConfig
+
+	pools, bonusPools, err := Pools(config, 1_000, []uint64{0, 0, 1_000, 2_000, 3_000, 4_000}, nil)
+	require.NoError(t, err)
+	require.Nil(t, bonusPools)
+	require.Equal(t, []uint64{0, 0, 90_000_000, 180_000_000, 270_000_000, 360_000_000}, pools)
+
+	_, _, err = Pools(config, 1_000, []uint64{0, 0, 1_000, 2_000, 3_000, 4_001}, nil)
+	require.ErrorIs(t, err, ErrShares)
+
+	_, _, err = Pools(config, math.MaxUint64, []uint64{0, 0, 0, 0, 0, 0}, nil)
+	require.ErrorIs(t, err, model.ErrOverflow)
+
+	bonus := config
+	bonus.Picks, bonus.MaxNumber, bonus.BonusMax = 5, 69, 26
+	pools, bonusPools, err = Pools(bonus, 10, []uint64{0, 0, 0, 0, 5_000}, []uint64{0, 0, 0, 0, 0, 5_000})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 0, 0, 0, 4_500_000}, pools)
+	require.Equal(t, []uint64{0, 0, 0, 0, 0, 4_500_000}, bonusPools)
+}
+
+func TestShareMoments(t *testing.T) {
+
+	testCases := []struct{
+		Name string
+		P float64
+		N uint64
+	}{
+		{
+			Name: "likely",
+			P: 0.3,
+			N: 50,
+		},
+		{
+			Name: "unlikely",
+			P: 1e-7,
+			N: 1_000,
+		},
+		{
+			Name: "single ticket",
+			P: 0.5,
+			N: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+
+			// The binomial pmf, term by term
+			others := int64(tc.N - 1)
+			var first, second float64
+			for y := int64(0); y <= others; y++ {
+				c, _ := new(big.Float).SetInt(new(big.Int).Binomial(others, y)).Float64()
+				pmf := c * math.Pow(tc.P, float64(y)) * math.Pow(1-tc.P, float64(others-y))
+				first += pmf / float64(1+y)
+				second += pmf / float64((1+y)*(1+y))
+			}
+
+			gotFirst, gotSecond := shareMoments(tc.P, tc.N)
+			require.InDelta(t, first, gotFirst, 1e-12)
+			require.InDelta(t, second, gotSecond, 1e-12)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Package player buys tickets in lotto apps with the contract's `commit`
+// method, opting the player's account in to the app first if it isn't yet.
+//
+// An account holds a single ticket per app (in its local state), so buying
+// several tickets in one app takes as many accounts.
+package player
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/client"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+const waitRounds = 4
+
+// Player buys tickets from a single account
+type Player struct {
+	cl *client.Context
+	acc crypto.Account
+}
+
+// New returns a player buying tickets from acc
+func New(cl *client.Context, acc crypto.Account) *Player {
+
+	return &Player{
+		cl: cl,
+		acc: acc,
+	}
+}
+
+// Config returns the config the app appID was created with
+func (p *Player) Config(ctx context.Context, appID uint64) (algokeno.GameConfig, error) {
+
+	app, err := p.cl.Algod.GetApplicationByID(appID).Do(ctx)
+	if err != nil {
+		return algokeno.GameConfig{}, err
+	}
+
+	state, err := model.DecodeState(app.Params.GlobalState)
+	if err != nil {
+		return algokeno.GameConfig{}, err
+	}
+	return model.ConfigFromState(state)
+}
+
+// Commit buys the ticket c in the app appID for wager, or the app's ticket
+// price if wager is 0, opting in to the app first if need be, and waits for
+// it to be confirmed. It returns the ID of the commit app call.
+func (p *Player) Commit(ctx context.Context, appID uint64, c algokeno.Commitment, wager uint64) (string, error) {
+
+	config, err := p.Config(ctx, appID)
+	if err != nil {
+		return "", err
+	}
+
+	if !c.IsSealed() {
+		if err := c.ValidateFor(config); err != nil {
+			return "", err
+		}
+	}
+
+	if wager == 0 {
+		wager = config.TicketPrice
+	}
+
+	optedIn, err := p.optedIn(ctx, appID)
+	if err != nil {
+		return "", err
+	}
+
+	if !optedIn {
+		params, err := p.cl.SuggestedParams(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		optIn, err := future.MakeApplicationOptInTx(
+			appID,
+			nil,
+			nil,
+			nil,
+			nil,
+			params,
+			p.acc.Address,
+			nil,
+			types.Digest{},
+			[32]byte{},
+			types.Address{},
+		)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := p.execute(ctx, optIn); err != nil {
+			return "", fmt.Errorf("opting in: %w", err)
+		}
+	}
+
+	params, err := p.cl.SuggestedParams(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	txns, err := CommitTxns(config, appID, p.acc.Address, c, wager, params)
+	if err != nil {
+		return "", err
+	}
+
+	return p.execute(ctx, txns...)
+}
+
+// CommitTxns returns the group buying the ticket c from sender in the app
+// appID, created with config: a payment of wager to the app, or a transfer
+// of its asset if it has one, followed by the commit app call taking it as
+// its wager arg
+func CommitTxns(
+	config algokeno.GameConfig,
+	appID uint64,
+	sender types.Address,
+	c algokeno.Commitment,
+	wager uint64,
+	params types.SuggestedParams,
+) ([]types.Transaction, error) {
+
+	if wager < config.TicketPrice {
+		return nil, fmt.Errorf("%w: %d < %d", model.ErrNoWager, wager, config.TicketPrice)
+	}
+
+	appAddr := crypto.GetApplicationAddress(appID)
+	var deposit types.Transaction
+	var err error
+	if config.AssetID == 0 {
+		deposit, err = future.MakePaymentTxn(sender.String(), appAddr.String(), wager, nil, "", params)
+	} else {
+		deposit, err = future.MakeAssetTransferTxn(sender.String(), appAddr.String(), wager, nil, params, "", config.AssetID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	call, err := future.MakeApplicationNoOpTx(
+		appID,
+		[][]byte{lotto.MethodCommit.GetSelector(), model.BytesArg(c)},
+		nil,
+		nil,
+		nil,
+		params,
+		sender,
+		nil,
+		types.Digest{},
+		[32]byte{},
+		types.Address{},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return []types.Transaction{deposit, call}, nil
+}
+
+func (p *Player) optedIn(ctx context.Context, appID uint64) (bool, error) {
+
+	info, err := p.cl.Algod.AccountInformation(p.acc.Address.String()).Do(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, state := range info.AppsLocalState {
+		if state.Id == appID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *Player) execute(ctx context.Context, txns ...types.Transaction) (string, error) {
+
+	var atc future.AtomicTransactionComposer
+	for _, txn := range txns {
+		err := atc.AddTransaction(future.TransactionWithSigner{
+			Txn: txn,
+			Signer: future.BasicAccountTransactionSigner{Account: p.acc},
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	res, err := atc.Execute(p.cl.Algod, ctx, waitRounds)
+	if err != nil {
+		return "", err
+	}
+	// The app call is last, after any deposit it takes
+	return res.TxIDs[len(res.TxIDs)-1], nil
+}
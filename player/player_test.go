@@ -0,0 +1,54 @@
+package player
+
+import (
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+func TestCommitTxns(t *testing.T) {
+
+	sender := crypto.GenerateAccount().Address
+	params := types.SuggestedParams{
+		Fee: 1000,
+		FlatFee: true,
+		FirstRoundValid: 1,
+		LastRoundValid: 1000,
+		GenesisHash: make([]byte, 32),
+	}
+	c := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+
+	txns, err := CommitTxns(algokeno.DefaultGameConfig, 86, sender, c, 2_000_000, params)
+	require.NoError(t, err)
+	require.Len(t, txns, 2)
+
+	payment := txns[0]
+	require.Equal(t, types.PaymentTx, payment.Type)
+	require.Equal(t, crypto.GetApplicationAddress(86), payment.Receiver)
+	require.Equal(t, types.MicroAlgos(2_000_000), payment.Amount)
+
+	// The call follows the wager it takes as its txn arg, which isn't one
+	// of its app args
+	call := txns[1]
+	require.Equal(t, types.ApplicationCallTx, call.Type)
+	require.Equal(t, types.AppIndex(86), call.ApplicationID)
+	require.Equal(t, [][]byte{lotto.MethodCommit.GetSelector(), {0, 6, 1, 2, 3, 4, 5, 6}}, call.ApplicationArgs)
+
+	// Apps with an asset take wagers in it
+	asset := algokeno.DefaultGameConfig
+	asset.AssetID = 42
+	txns, err = CommitTxns(asset, 86, sender, c, 1_000_000, params)
+	require.NoError(t, err)
+	require.Equal(t, types.AssetTransferTx, txns[0].Type)
+	require.Equal(t, types.AssetIndex(42), txns[0].XferAsset)
+	require.Equal(t, uint64(1_000_000), txns[0].AssetAmount)
+
+	_, err = CommitTxns(algokeno.DefaultGameConfig, 86, sender, c, 999_999, params)
+	require.ErrorIs(t, err, model.ErrNoWager)
+}
@@ -0,0 +1,162 @@
+// Package profile records the opcode cost, inner transaction count and
+// minimum fee of each contract operation, and compares them against a saved
+// baseline so that contract changes which make operations more expensive
+// are caught.
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// MaxAppProgramCost is the opcode budget of a single app call, which each
+// of its inner app calls adds to
+const MaxAppProgramCost = 700
+
+// Op is the profile of a single contract operation
+type Op struct {
+	Name string `json:"name"`
+
+	// Cost is the opcode cost of the app call as reported by dryrun
+	Cost uint64 `json:"cost"`
+
+	// InnerTxns is the number of inner transactions the app call submits
+	InnerTxns uint64 `json:"inner_txns"`
+
+	// InnerAppCalls is how many of the inner transactions are app calls,
+	// such as the ones set_draw makes to raise its opcode budget
+	InnerAppCalls uint64 `json:"inner_app_calls"`
+
+	// MinFee is the lowest fee (in microalgos) of the app call for which
+	// the operation succeeds, covering its inner transactions including
+	// its inner app calls
+	MinFee uint64 `json:"min_fee"`
+}
+
+// Budget is the opcode budget of the app call, pooled with its inner app
+// calls
+func (op Op) Budget() uint64 {
+
+	return (1 + op.InnerAppCalls) * MaxAppProgramCost
+}
+
+// Report is the profile of all the contract operations
+type Report struct {
+	Ops []Op `json:"ops"`
+}
+
+// Add adds op to the report, replacing any previous op with the same name
+func (r *Report) Add(op Op) {
+
+	for i := range r.Ops {
+		if r.Ops[i].Name == op.Name {
+			r.Ops[i] = op
+			return
+		}
+	}
+	r.Ops = append(r.Ops, op)
+	sort.Slice(r.Ops, func(i, j int) bool {
+		return r.Ops[i].Name < r.Ops[j].Name
+	})
+}
+
+// Get returns the op called name
+func (r Report) Get(name string) (Op, bool) {
+
+	for _, op := range r.Ops {
+		if op.Name == name {
+			return op, true
+		}
+	}
+	return Op{}, false
+}
+
+// Load reads a report saved with Save
+func Load(path string) (Report, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var r Report
+	if err := json.Unmarshal(b, &r); err != nil {
+		return Report{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Save writes the report to path as JSON
+func (r Report) Save(path string) error {
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// WriteTable writes the report as a human readable table
+func (r Report) WriteTable(w io.Writer) error {
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "op\tcost\tbudget used\tinner txns\tmin fee")
+	for _, op := range r.Ops {
+		fmt.Fprintf(
+			tw,
+			"%s\t%d\t%.0f%%\t%d\t%d\n",
+			op.Name,
+			op.Cost,
+			100*float64(op.Cost)/float64(op.Budget()),
+			op.InnerTxns,
+			op.MinFee,
+		)
+	}
+	return tw.Flush()
+}
+
+// Compare returns a description of each regression in current compared to
+// baseline. An op's cost regresses if it grows by more than threshold (a
+// fraction, e.g. 0.1 for 10%) or exceeds the opcode budget. Any increase in
+// inner transactions or min fee is a regression, as it changes what callers
+// have to pay. Ops missing from the baseline are not compared.
+func Compare(baseline, current Report, threshold float64) []string {
+
+	var regressions []string
+	for _, op := range current.Ops {
+
+		if op.Cost > op.Budget() {
+			regressions = append(regressions, fmt.Sprintf(
+				"%s: cost %d exceeds budget of %d", op.Name, op.Cost, op.Budget(),
+			))
+		}
+
+		base, ok := baseline.Get(op.Name)
+		if !ok {
+			continue
+		}
+
+		if float64(op.Cost) > float64(base.Cost)*(1+threshold) {
+			regressions = append(regressions, fmt.Sprintf(
+				"%s: cost increased from %d to %d (more than %.0f%%)", op.Name, base.Cost, op.Cost, 100*threshold,
+			))
+		}
+
+		if op.InnerTxns > base.InnerTxns {
+			regressions = append(regressions, fmt.Sprintf(
+				"%s: inner txns increased from %d to %d", op.Name, base.InnerTxns, op.InnerTxns,
+			))
+		}
+
+		if op.MinFee > base.MinFee {
+			regressions = append(regressions, fmt.Sprintf(
+				"%s: min fee increased from %d to %d", op.Name, base.MinFee, op.MinFee,
+			))
+		}
+	}
+	return regressions
+}
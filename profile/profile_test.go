@@ -0,0 +1,145 @@
+package profile
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+
+	var r Report
+	r.Add(Op{Name: "SetDraw", Cost: 120, InnerTxns: 2, MinFee: 3000})
+	r.Add(Op{Name: "Commit", Cost: 60, MinFee: 1000})
+	r.Add(Op{Name: "SetDraw", Cost: 121, InnerTxns: 2, MinFee: 3000})
+
+	require.Equal(t, []Op{
+		{Name: "Commit", Cost: 60, MinFee: 1000},
+		{Name: "SetDraw", Cost: 121, InnerTxns: 2, MinFee: 3000},
+	}, r.Ops)
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, r.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, r, loaded)
+}
+
+func TestCompare(t *testing.T) {
+
+	baseline := Report{
+		Ops: []Op{
+			{Name: "Claim", Cost: 100, InnerTxns: 1, MinFee: 2000},
+			{Name: "Commit", Cost: 60, MinFee: 1000},
+			{Name: "SetDraw", Cost: 200, InnerTxns: 2, MinFee: 3000},
+		},
+	}
+
+	testCases := []struct{
+		Name string
+		Current Report
+		ExpectedRegressions []string
+	}{
+		{
+			Name: "unchanged",
+			Current: baseline,
+		},
+		{
+			Name: "cost increase within threshold",
+			Current: Report{
+				Ops: []Op{
+					{Name: "Claim", Cost: 110, InnerTxns: 1, MinFee: 2000},
+				},
+			},
+		},
+		{
+			Name: "cost increase beyond threshold",
+			Current: Report{
+				Ops: []Op{
+					{Name: "Claim", Cost: 111, InnerTxns: 1, MinFee: 2000},
+				},
+			},
+			ExpectedRegressions: []string{
+				"Claim: cost increased from 100 to 111 (more than 10%)",
+			},
+		},
+		{
+			Name: "more inner txns and higher min fee",
+			Current: Report{
+				Ops: []Op{
+					{Name: "Claim", Cost: 100, InnerTxns: 2, MinFee: 3000},
+				},
+			},
+			ExpectedRegressions: []string{
+				"Claim: inner txns increased from 1 to 2",
+				"Claim: min fee increased from 2000 to 3000",
+			},
+		},
+		{
+			Name: "cost over budget",
+			Current: Report{
+				Ops: []Op{
+					{Name: "Keno", Cost: 701},
+				},
+			},
+			ExpectedRegressions: []string{
+				"Keno: cost 701 exceeds budget of 700",
+			},
+		},
+		{
+			Name: "inner app calls raise the budget",
+			Current: Report{
+				Ops: []Op{
+					{Name: "Keno", Cost: 1036, InnerTxns: 1, InnerAppCalls: 1, MinFee: 2000},
+				},
+			},
+		},
+		{
+			Name: "cost over budget raised by inner app calls",
+			Current: Report{
+				Ops: []Op{
+					{Name: "Keno", Cost: 1401, InnerTxns: 1, InnerAppCalls: 1, MinFee: 2000},
+				},
+			},
+			ExpectedRegressions: []string{
+				"Keno: cost 1401 exceeds budget of 1400",
+			},
+		},
+		{
+			Name: "cheaper is not a regression",
+			Current: Report{
+				Ops: []Op{
+					{Name: "SetDraw", Cost: 150, InnerTxns: 1, MinFee: 2000},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			require.Equal(t, test.ExpectedRegressions, Compare(baseline, test.Current, 0.1))
+		})
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+
+	r := Report{
+		Ops: []Op{
+			{Name: "Claim", Cost: 70, InnerTxns: 1, MinFee: 2000},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteTable(&buf))
+	require.Equal(
+		t,
+		"op     cost  budget used  inner txns  min fee\n"+
+			"Claim  70    10%          1           2000\n",
+		buf.String(),
+	)
+}
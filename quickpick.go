@@ -0,0 +1,99 @@
+package algokeno
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+)
+
+var ErrQuickPicks = errors.New("more quick picks than distinct tickets")
+
+// QuickPick returns a random ticket for an app created with config: its
+// picks drawn without replacement from the numbers below config.MaxNumber,
+// sorted, and followed by a bonus number below config.BonusMax in a bonus
+// game. Numbers are drawn uniformly with crypto/rand.
+func QuickPick(config GameConfig) (Commitment, error) {
+
+	return quickPick(rand.Reader, config)
+}
+
+// QuickPicks returns n distinct quick picks for an app created with config,
+// so that buying several tickets at once never doubles up on one. It fails
+// if the game has fewer than n distinct tickets.
+func QuickPicks(config GameConfig, n int) ([]Commitment, error) {
+
+	if max := NumTickets(config); max.Cmp(big.NewInt(int64(n))) < 0 {
+		return nil, fmt.Errorf("%w: %d of %s", ErrQuickPicks, n, max)
+	}
+
+	res := make([]Commitment, 0, n)
+	seen := make(map[string]bool, n)
+	for len(res) < n {
+		c, err := quickPick(rand.Reader, config)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[string(c)] {
+			continue
+		}
+		seen[string(c)] = true
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// NumTickets returns the number of distinct tickets of an app created with
+// config, i.e. config.MaxNumber choose config.Picks, times config.BonusMax
+// in a bonus game
+func NumTickets(config GameConfig) *big.Int {
+
+	n := new(big.Int).Binomial(int64(config.MaxNumber), int64(config.Picks))
+	if config.HasBonus() {
+		n.Mul(n, big.NewInt(int64(config.BonusMax)))
+	}
+	return n
+}
+
+func quickPick(r io.Reader, config GameConfig) (Commitment, error) {
+
+	// A partial Fisher-Yates shuffle of the numbers below MaxNumber, of
+	// which the first Picks are drawn
+	numbers := make([]uint8, config.MaxNumber)
+	for i := range numbers {
+		numbers[i] = uint8(i)
+	}
+
+	for i := 0; i < config.Picks; i++ {
+		j, err := randIntn(r, len(numbers)-i)
+		if err != nil {
+			return nil, err
+		}
+		numbers[i], numbers[i+j] = numbers[i+j], numbers[i]
+	}
+
+	c := Commitment(numbers[:config.Picks])
+	sort.Slice(c, func(i, j int) bool { return c[i] < c[j] })
+
+	if config.HasBonus() {
+		bonus, err := randIntn(r, config.BonusMax)
+		if err != nil {
+			return nil, err
+		}
+		c = c.WithBonus(uint8(bonus))
+	}
+	return c, nil
+}
+
+// randIntn returns a uniform random int in [0, n) read from r
+func randIntn(r io.Reader, n int) (int, error) {
+
+	i, err := rand.Int(r, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
@@ -0,0 +1,72 @@
+package algokeno
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuickPick(t *testing.T) {
+
+	bonus := DefaultGameConfig
+	bonus.Picks, bonus.MaxNumber, bonus.BonusMax = 5, 70, 26
+
+	testCases := []struct{
+		Name string
+		Config GameConfig
+	}{
+		{
+			Name: "default",
+			Config: DefaultGameConfig,
+		},
+		{
+			Name: "bonus ball",
+			Config: bonus,
+		},
+		{
+			Name: "every number",
+			Config: GameConfig{Picks: 3, MaxNumber: 3, TicketPrice: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+
+			for i := 0; i < 100; i++ {
+				c, err := QuickPick(tc.Config)
+				require.NoError(t, err)
+				require.NoError(t, c.ValidateFor(tc.Config))
+			}
+		})
+	}
+}
+
+func TestQuickPicks(t *testing.T) {
+
+	config := GameConfig{Picks: 2, MaxNumber: 5, TicketPrice: 1}
+
+	// Every one of the 10 tickets, each once
+	cs, err := QuickPicks(config, 10)
+	require.NoError(t, err)
+	require.Len(t, cs, 10)
+	for i, c := range cs {
+		require.NoError(t, c.ValidateFor(config))
+		for _, other := range cs[:i] {
+			require.False(t, bytes.Equal(c, other))
+		}
+	}
+
+	_, err = QuickPicks(config, 11)
+	require.ErrorIs(t, err, ErrQuickPicks)
+}
+
+func TestNumTickets(t *testing.T) {
+
+	require.Equal(t, big.NewInt(74_974_368), NumTickets(DefaultGameConfig))
+
+	bonus := DefaultGameConfig
+	bonus.Picks, bonus.MaxNumber, bonus.BonusMax = 5, 70, 25
+	require.Equal(t, big.NewInt(302_575_350), NumTickets(bonus))
+}
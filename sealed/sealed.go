@@ -0,0 +1,184 @@
+// Package sealed creates sealed lotto tickets and keeps their salts safe
+// until they're revealed.
+//
+// A sealed ticket commits sha512_256(numbers || salt), so a player's numbers
+// aren't on chain (to be watched and copied) until they reveal them after
+// the draw. Losing the salt means the ticket can never be revealed or
+// claimed, so Store writes it to disk, durably, before the commit is sent.
+package sealed
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/model"
+)
+
+// SaltLength is the length of the salts generated by New, which is enough
+// that the numbers can't be brute forced from the sealed commitment
+const SaltLength = 32
+
+var (
+	ErrNotFound = errors.New("sealed ticket not found")
+	ErrMismatch = errors.New("sealed ticket does not match its commitment")
+)
+
+// Ticket is a sealed ticket along with what's needed to reveal it
+type Ticket struct {
+	AppID uint64 `json:"app_id"`
+	Player string `json:"player"`
+	Numbers algokeno.Commitment `json:"numbers"`
+	Salt []byte `json:"salt"`
+}
+
+// New seals numbers for player in the app appID with a random salt
+func New(appID uint64, player types.Address, numbers algokeno.Commitment) (Ticket, error) {
+
+	salt := make([]byte, SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return Ticket{}, err
+	}
+
+	return Ticket{
+		AppID: appID,
+		Player: player.String(),
+		Numbers: numbers,
+		Salt: salt,
+	}, nil
+}
+
+// Commitment returns the sealed commitment to pass to `commit`
+func (t Ticket) Commitment() algokeno.Commitment {
+
+	return algokeno.Seal(t.Numbers, t.Salt)
+}
+
+// RevealArgs returns the `reveal` application args (excluding the method
+// selector), the numbers and salt ABI encoded
+func (t Ticket) RevealArgs() [][]byte {
+
+	return [][]byte{
+		model.BytesArg(t.Numbers),
+		model.BytesArg(t.Salt),
+	}
+}
+
+// Store keeps sealed tickets on disk, one file per ticket under
+// <dir>/<app id>/<player>/<commitment>.json. Files are only readable by the
+// owner, as the salt is what stops anyone else revealing a ticket's numbers.
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store of tickets under dir, creating it if needed
+func NewStore(dir string) (*Store, error) {
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Save durably writes t, which must be done before its Commit is sent.
+// Tickets are keyed by their commitment, so saving a new ticket never
+// replaces an older one which may still be the one committed on chain.
+func (s *Store) Save(t Ticket) error {
+
+	path := s.path(t.AppID, t.Player, t.Commitment())
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename it into place, so that a crash never
+	// leaves a partially written ticket behind
+	f, err := os.CreateTemp(filepath.Dir(path), ".ticket-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// Load returns the ticket of player in the app appID whose sealed
+// commitment is sealed, i.e. the one in their local state
+func (s *Store) Load(appID uint64, player types.Address, sealed algokeno.Commitment) (Ticket, error) {
+
+	path := s.path(appID, player.String(), sealed)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Ticket{}, fmt.Errorf("%w: %s", ErrNotFound, path)
+	} else if err != nil {
+		return Ticket{}, err
+	}
+
+	var t Ticket
+	if err := json.Unmarshal(b, &t); err != nil {
+		return Ticket{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if string(t.Commitment()) != string(sealed) {
+		return Ticket{}, fmt.Errorf("%w: %s", ErrMismatch, path)
+	}
+	return t, nil
+}
+
+// Remove deletes t once it's no longer needed, i.e. after it's been claimed
+// or the app has been settled
+func (s *Store) Remove(t Ticket) error {
+
+	err := os.Remove(s.path(t.AppID, t.Player, t.Commitment()))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) path(appID uint64, player string, sealed algokeno.Commitment) string {
+
+	return filepath.Join(
+		s.dir,
+		strconv.FormatUint(appID, 10),
+		player,
+		hex.EncodeToString(sealed)+".json",
+	)
+}
+
+func syncDir(dir string) error {
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,97 @@
+package sealed
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/model"
+)
+
+func TestNew(t *testing.T) {
+
+	player := crypto.GenerateAccount().Address
+	numbers := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+
+	t1, err := New(1, player, numbers)
+	require.NoError(t, err)
+	t2, err := New(1, player, numbers)
+	require.NoError(t, err)
+
+	require.Len(t, t1.Salt, SaltLength)
+	require.True(t, t1.Commitment().IsSealed())
+	require.NotEqual(t, t1.Commitment(), t2.Commitment(), "salts should differ")
+	require.Equal(t, algokeno.Seal(numbers, t1.Salt), t1.Commitment())
+	require.Equal(t, [][]byte{model.BytesArg(numbers), model.BytesArg(t1.Salt)}, t1.RevealArgs())
+}
+
+func TestStore(t *testing.T) {
+
+	dir := t.TempDir()
+	s, err := NewStore(filepath.Join(dir, "tickets"))
+	require.NoError(t, err)
+
+	player := crypto.GenerateAccount().Address
+	older, err := New(7, player, algokeno.Commitment{1, 2, 3, 4, 5, 6})
+	require.NoError(t, err)
+	newer, err := New(7, player, algokeno.Commitment{10, 11, 12, 13, 14, 15})
+	require.NoError(t, err)
+
+	_, err = s.Load(7, player, older.Commitment())
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Saving a newer ticket keeps the older one, in case its Commit is the
+	// one which made it on chain
+	require.NoError(t, s.Save(older))
+	require.NoError(t, s.Save(newer))
+
+	for _, ticket := range []Ticket{older, newer} {
+		loaded, err := s.Load(7, player, ticket.Commitment())
+		require.NoError(t, err)
+		require.Equal(t, ticket, loaded)
+	}
+
+	// Only the owner can read the salts
+	path := s.path(7, player.String(), older.Commitment())
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	info, err = os.Stat(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	// No temp files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	require.NoError(t, s.Remove(older))
+	require.NoError(t, s.Remove(older))
+	_, err = s.Load(7, player, older.Commitment())
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStoreDetectsTampering(t *testing.T) {
+
+	s, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	player := crypto.GenerateAccount().Address
+	ticket, err := New(7, player, algokeno.Commitment{1, 2, 3, 4, 5, 6})
+	require.NoError(t, err)
+	require.NoError(t, s.Save(ticket))
+
+	tampered := ticket
+	tampered.Numbers = algokeno.Commitment{1, 2, 3, 4, 5, 7}
+	b, err := json.Marshal(tampered)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(s.path(7, player.String(), ticket.Commitment()), b, 0600))
+
+	_, err = s.Load(7, player, ticket.Commitment())
+	require.ErrorIs(t, err, ErrMismatch)
+}
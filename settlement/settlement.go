@@ -0,0 +1,548 @@
+// Package settlement reads the tickets of a lotto app from the indexer and
+// works out the winners of each tier, for the creator to set the draw with.
+//
+// The indexer lags behind algod, so every read first waits for the indexer
+// to have processed the round it's reading up to.
+package settlement
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/client/v2/indexer"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+// PollInterval is how often WaitForIndexerRound polls the indexer
+var PollInterval = 200 * time.Millisecond
+
+var ErrInsolvent = errors.New("escrow can't cover house fee and prizes")
+
+// Ticket is a player's commitment, as of the latest commit they made
+type Ticket struct {
+	Player types.Address
+
+	// Commitment is the ticket's numbers or, for a sealed ticket which
+	// hasn't been revealed yet, its sealed commitment
+	Commitment algokeno.Commitment
+
+	Wager uint64
+
+	// Round is the round the commit was confirmed in
+	Round uint64
+}
+
+// Unrevealed returns whether t is a sealed ticket whose numbers aren't
+// known yet
+func (t Ticket) Unrevealed() bool {
+
+	return t.Commitment.IsSealed()
+}
+
+// Sponsorship is a deposit made to a tier's prize with a sponsor app call
+type Sponsorship struct {
+	Sponsor types.Address
+
+	// Tier is the tier of tickets matching that many numbers
+	Tier int
+
+	Amount uint64
+
+	// Note is the app call's note, which sponsors attribute deposits in
+	Note []byte
+
+	// Round is the round the sponsor call was confirmed in
+	Round uint64
+}
+
+// Settler settles a single lotto app
+type Settler struct {
+	idx *indexer.Client
+	appID uint64
+}
+
+// New returns a settler for the app appID
+func New(idx *indexer.Client, appID uint64) *Settler {
+
+	return &Settler{
+		idx: idx,
+		appID: appID,
+	}
+}
+
+// WaitForIndexerRound polls the indexer's health endpoint until it has
+// processed round, or ctx is done
+func (s *Settler) WaitForIndexerRound(ctx context.Context, round uint64) error {
+
+	return WaitForIndexerRound(ctx, s.idx, round)
+}
+
+// WaitForIndexerRound polls the health endpoint of idx until it has
+// processed round, or ctx is done. Errors from the indexer are retried, as
+// it may still be starting up.
+func WaitForIndexerRound(ctx context.Context, idx *indexer.Client, round uint64) error {
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	var lastErr error
+	var lastRound uint64
+	for {
+		health, err := idx.HealthCheck().Do(ctx)
+		switch {
+		case err == nil && health.Round >= round:
+			return nil
+		case err == nil:
+			lastErr, lastRound = nil, health.Round
+		case ctx.Err() == nil:
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("waiting for indexer round %d: %v: %w", round, lastErr, ctx.Err())
+			}
+			return fmt.Errorf("waiting for indexer round %d (at %d): %w", round, lastRound, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tickets returns each player's ticket as of round, i.e. their latest
+// commit confirmed at or before round, in the order they were confirmed.
+// round should be the round the last relevant commit was confirmed in.
+// Sealed tickets are returned with their numbers if they were revealed at
+// or before round.
+func (s *Settler) Tickets(ctx context.Context, round uint64) ([]Ticket, error) {
+
+	calls, payments, err := s.appTxns(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+
+	return tickets(calls, payments, crypto.GetApplicationAddress(s.appID))
+}
+
+// Sponsorships returns the deposits made with sponsor calls confirmed at or
+// before round, in the order they were confirmed
+func (s *Settler) Sponsorships(ctx context.Context, round uint64) ([]Sponsorship, error) {
+
+	calls, payments, err := s.appTxns(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+
+	return sponsorships(calls, payments, crypto.GetApplicationAddress(s.appID))
+}
+
+// appTxns returns the app calls to the app, and the deposits to its
+// account in the asset it was created with, confirmed at or before round
+func (s *Settler) appTxns(ctx context.Context, round uint64) ([]models.Transaction, []models.Transaction, error) {
+
+	if err := s.WaitForIndexerRound(ctx, round); err != nil {
+		return nil, nil, err
+	}
+
+	config, err := s.config(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	calls, err := s.search(ctx, round, func(q *indexer.SearchForTransactions) {
+		q.ApplicationId(s.appID).TxType("appl")
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	appAddr := crypto.GetApplicationAddress(s.appID)
+	payments, err := s.search(ctx, round, func(q *indexer.SearchForTransactions) {
+		q.AddressString(appAddr.String()).AddressRole("receiver")
+		if config.AssetID == 0 {
+			q.TxType("pay")
+		} else {
+			q.TxType("axfer").AssetID(config.AssetID)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return calls, payments, nil
+}
+
+// config returns the config the app was created with
+func (s *Settler) config(ctx context.Context) (algokeno.GameConfig, error) {
+
+	app, err := s.idx.LookupApplicationByID(s.appID).Do(ctx)
+	if err != nil {
+		return algokeno.GameConfig{}, err
+	}
+
+	state, err := model.DecodeState(app.Application.Params.GlobalState)
+	if err != nil {
+		return algokeno.GameConfig{}, err
+	}
+	return model.ConfigFromState(state)
+}
+
+func (s *Settler) search(
+	ctx context.Context,
+	round uint64,
+	filter func(*indexer.SearchForTransactions),
+) ([]models.Transaction, error) {
+
+	var txns []models.Transaction
+	var next string
+	for {
+		q := s.idx.SearchForTransactions().MaxRound(round)
+		filter(q)
+		if next != "" {
+			q.NextToken(next)
+		}
+
+		res, err := q.Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, res.Transactions...)
+
+		if res.NextToken == "" || len(res.Transactions) == 0 {
+			return txns, nil
+		}
+		next = res.NextToken
+	}
+}
+
+// tickets matches each commit app call with the wager paid to the app in
+// the same group. Later commits from a player replace earlier ones, as they
+// do in the app's local state, and reveals replace sealed commitments with
+// their numbers.
+func tickets(calls, payments []models.Transaction, appAddr types.Address) ([]Ticket, error) {
+
+	wagers := deposits(payments, appAddr)
+
+	var all []Ticket
+	revealed := make(map[string]algokeno.Commitment)
+	for _, c := range calls {
+		args := c.ApplicationTransaction.ApplicationArgs
+		isCommit := len(args) == 2 && bytes.Equal(args[0], lotto.MethodCommit.GetSelector())
+		isReveal := len(args) == 3 && bytes.Equal(args[0], lotto.MethodReveal.GetSelector())
+		if !isCommit && !isReveal {
+			continue
+		}
+
+		// The app rejects calls whose byte[] args aren't ABI encoded, so
+		// they can't be confirmed
+		var decoded [][]byte
+		for _, arg := range args[1:] {
+			b, err := model.ParseBytesArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			decoded = append(decoded, b)
+		}
+
+		player, err := types.DecodeAddress(c.Sender)
+		if err != nil {
+			return nil, err
+		}
+
+		if isReveal {
+			// Keyed by the sealed commitment the reveal opens, as the app
+			// only accepts it if that's the player's ticket
+			sealed := algokeno.Seal(decoded[0], decoded[1])
+			revealed[c.Sender+string(sealed)] = algokeno.Commitment(decoded[0])
+			continue
+		}
+
+		all = append(all, Ticket{
+			Player: player,
+			Commitment: algokeno.Commitment(decoded[0]),
+			Wager: wagers[string(c.Group)],
+			Round: c.ConfirmedRound,
+		})
+	}
+
+	// Keep only the latest ticket of each player
+	var res []Ticket
+	seen := make(map[types.Address]bool)
+	for i := len(all)-1; i >= 0; i-- {
+		if seen[all[i].Player] {
+			continue
+		}
+		seen[all[i].Player] = true
+
+		t := all[i]
+		if numbers, ok := revealed[t.Player.String()+string(t.Commitment)]; ok && t.Unrevealed() {
+			t.Commitment = numbers
+		}
+		res = append([]Ticket{t}, res...)
+	}
+	return res, nil
+}
+
+// sponsorships matches each sponsor app call with the deposit paid to the
+// app in the same group
+func sponsorships(calls, payments []models.Transaction, appAddr types.Address) ([]Sponsorship, error) {
+
+	amounts := deposits(payments, appAddr)
+
+	var res []Sponsorship
+	for _, c := range calls {
+		args := c.ApplicationTransaction.ApplicationArgs
+		if len(args) != 2 || !bytes.Equal(args[0], lotto.MethodSponsor.GetSelector()) || len(args[1]) != 8 {
+			continue
+		}
+
+		sponsor, err := types.DecodeAddress(c.Sender)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, Sponsorship{
+			Sponsor: sponsor,
+			Tier: int(binary.BigEndian.Uint64(args[1])),
+			Amount: amounts[string(c.Group)],
+			Note: c.Note,
+			Round: c.ConfirmedRound,
+		})
+	}
+	return res, nil
+}
+
+// deposits returns the amount of each payment or asset transfer to the app
+// made in a group, keyed by the group. The app only checks the deposit is
+// the first txn of the group, so anyone can pay it for the caller.
+func deposits(payments []models.Transaction, appAddr types.Address) map[string]uint64 {
+
+	res := make(map[string]uint64)
+	for _, p := range payments {
+		if len(p.Group) == 0 {
+			continue
+		}
+		switch appAddr.String() {
+		case p.PaymentTransaction.Receiver:
+			res[string(p.Group)] = p.PaymentTransaction.Amount
+		case p.AssetTransferTransaction.Receiver:
+			res[string(p.Group)] = p.AssetTransferTransaction.Amount
+		}
+	}
+	return res
+}
+
+// Guaranteed returns the prize sponsors have guaranteed each of the
+// config.Picks tiers of an app created with config, i.e. the sum of the
+// sponsorships of each tier
+func Guaranteed(config algokeno.GameConfig, sponsorships []Sponsorship) []uint64 {
+
+	guaranteed := make([]uint64, config.Picks)
+	for _, sp := range sponsorships {
+		if sp.Tier >= 1 && sp.Tier <= len(guaranteed) {
+			guaranteed[sp.Tier-1] += sp.Amount
+		}
+	}
+	return guaranteed
+}
+
+// HonourGuarantees returns prizes with each raised to at least the prize
+// guaranteed for its tier, as SetDraw rejects prizes below their guarantee
+func HonourGuarantees(prizes, guaranteed []uint64) []uint64 {
+
+	res := append([]uint64(nil), prizes...)
+	for i := range res {
+		if i < len(guaranteed) && res[i] < guaranteed[i] {
+			res[i] = guaranteed[i]
+		}
+	}
+	return res
+}
+
+// Winners returns the number of tickets matching each number of picks of
+// draw in an app created with config, i.e. the count at index i is of
+// tickets matching i+1 numbers. Unrevealed tickets aren't counted, nor are
+// tickets whose bonus number was drawn, which win a bonus tier instead.
+func Winners(config algokeno.GameConfig, draw algokeno.Commitment, tickets []Ticket) []uint64 {
+
+	winners := make([]uint64, config.Picks)
+	for _, m := range matches(config, draw, tickets) {
+		if !m.Bonus && m.Numbers > 0 && m.Numbers <= len(winners) {
+			winners[m.Numbers-1]++
+		}
+	}
+	return winners
+}
+
+// BonusWinners returns the number of tickets of a bonus game created with
+// config whose bonus number was drawn, by the number of picks of draw they
+// match, i.e. the count at index i is of tickets matching i numbers and
+// the bonus. It's nil if config isn't a bonus game.
+func BonusWinners(config algokeno.GameConfig, draw algokeno.Commitment, tickets []Ticket) []uint64 {
+
+	if !config.HasBonus() {
+		return nil
+	}
+
+	winners := make([]uint64, config.Picks+1)
+	for _, m := range matches(config, draw, tickets) {
+		if m.Bonus && m.Numbers < len(winners) {
+			winners[m.Numbers]++
+		}
+	}
+	return winners
+}
+
+// Payouts returns what each of tickets is paid when it claims against the
+// draw set with tiers and bonusTiers, i.e. an equal share of the prize of
+// the tier it matched. The dust of each tier is rolled over rather than
+// paid out.
+func Payouts(
+	config algokeno.GameConfig,
+	draw algokeno.Commitment,
+	tickets []Ticket,
+	tiers []model.Tier,
+	bonusTiers []model.Tier,
+) []uint64 {
+
+	payouts := make([]uint64, len(tickets))
+	for i, m := range matches(config, draw, tickets) {
+		switch {
+		case m.Bonus && m.Numbers < len(bonusTiers):
+			payouts[i] = bonusTiers[m.Numbers].Share()
+		case !m.Bonus && m.Numbers > 0 && m.Numbers <= len(tiers):
+			payouts[i] = tiers[m.Numbers-1].Share()
+		}
+	}
+	return payouts
+}
+
+// matches returns how each of tickets matches draw, or the zero Match if it
+// isn't eligible to claim
+func matches(config algokeno.GameConfig, draw algokeno.Commitment, tickets []Ticket) []algokeno.Match {
+
+	// If every drawn number fits in a bitmap, each ticket's matches are a
+	// popcount against it. Ticket numbers left out of the ticket's bitmap
+	// can't have been drawn, so the count is exact.
+	bitmask, err := draw.Picks(config).ToBitmask()
+	bitmapped := err == nil
+	drawBits := bitmask.Bitmap()
+
+	res := make([]algokeno.Match, len(tickets))
+	for i, t := range tickets {
+		if t.Wager < config.TicketPrice || t.Unrevealed() {
+			continue
+		}
+
+		res[i] = t.Commitment.MatchFor(config, draw)
+		if bitmapped {
+			res[i].Numbers = bits.OnesCount64(t.Commitment.Picks(config).Bitmap() & drawBits)
+		}
+	}
+	return res
+}
+
+// Unrevealed returns the number of tickets which are still sealed. Like the
+// app's "numSealed", it counts every sealed ticket whatever its wager, even
+// though those under the ticket price can't claim. While there are any the
+// draw has to be published first, and set once they're revealed or the
+// reveal window is over.
+func Unrevealed(tickets []Ticket) uint64 {
+
+	var n uint64
+	for _, t := range tickets {
+		if t.Unrevealed() {
+			n++
+		}
+	}
+	return n
+}
+
+// Tiers returns the tiers to set the draw with, given the prize of each of
+// the config.Picks tiers. Tickets still sealed can never claim once the draw
+// is set, so they aren't counted.
+func Tiers(
+	config algokeno.GameConfig,
+	draw algokeno.Commitment,
+	tickets []Ticket,
+	prizes []uint64,
+) []model.Tier {
+
+	tiers := make([]model.Tier, config.Picks)
+	for i, w := range Winners(config, draw, tickets) {
+		tiers[i] = model.Tier{
+			Winners: w,
+			Prize: prizes[i],
+		}
+	}
+	return tiers
+}
+
+// BonusTiers returns the bonus tiers to set the draw of a bonus game with,
+// given the prize of each of its config.Picks+1 bonus tiers. Like Tiers,
+// sealed tickets aren't counted. It's nil if config isn't a bonus game.
+func BonusTiers(
+	config algokeno.GameConfig,
+	draw algokeno.Commitment,
+	tickets []Ticket,
+	prizes []uint64,
+) []model.Tier {
+
+	if !config.HasBonus() {
+		return nil
+	}
+
+	tiers := make([]model.Tier, config.Picks+1)
+	for i, w := range BonusWinners(config, draw, tickets) {
+		tiers[i] = model.Tier{
+			Winners: w,
+			Prize: prizes[i],
+		}
+	}
+	return tiers
+}
+
+// CheckSolvency returns an error if an app created with config, whose
+// escrow holds escrow of which sponsored was deposited by sponsors, can't
+// pay out the prizes of tiers (which in a bonus game should include its
+// bonus tiers) once its house fee is paid to the treasury. The fee isn't
+// taken from sponsors' deposits. Prizes which roll over are paid out on
+// SetDraw too, so every tier's prize counts. An Algo escrow has to be left
+// with model.MinBalance, whereas an asset holding can be emptied.
+func CheckSolvency(config algokeno.GameConfig, escrow, sponsored uint64, tiers []model.Tier) error {
+
+	if sponsored > escrow {
+		return fmt.Errorf("%w: %d sponsored, escrow holds %d", ErrInsolvent, sponsored, escrow)
+	}
+
+	fee := model.HouseFee(escrow-sponsored, config.HouseFeeBps)
+
+	var reserve uint64
+	if config.AssetID == 0 {
+		reserve = model.MinBalance
+	}
+
+	needed := fee + reserve
+	for _, tier := range tiers {
+		if needed+tier.Prize < needed {
+			return model.ErrOverflow
+		}
+		needed += tier.Prize
+	}
+
+	if needed > escrow {
+		return fmt.Errorf("%w: needs %d, escrow holds %d", ErrInsolvent, needed, escrow)
+	}
+	return nil
+}
@@ -0,0 +1,658 @@
+package settlement
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/client/v2/indexer"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+const testAppID = 86
+
+// fakeIndexer serves the health, app lookup and transaction search
+// endpoints. Its round advances by one on each health check, up to
+// maxRound. The app is created with config, and the deposits to it are
+// payments, or asset transfers of config.AssetID.
+type fakeIndexer struct {
+	round uint64
+	maxRound uint64
+	config algokeno.GameConfig
+	calls []models.Transaction
+	payments []models.Transaction
+}
+
+func (f *fakeIndexer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	var res interface{}
+	switch r.URL.Path {
+	case "/health":
+		round := atomic.AddUint64(&f.round, 1)
+		if round > f.maxRound {
+			round = f.maxRound
+		}
+		res = models.HealthCheck{Round: round, DbAvailable: true}
+	case fmt.Sprintf("/v2/applications/%d", testAppID):
+		res = models.ApplicationResponse{
+			Application: models.Application{
+				Id: testAppID,
+				Params: models.ApplicationParams{GlobalState: encodeState(model.ConfigState(f.config))},
+			},
+		}
+	case "/v2/transactions":
+		q := r.URL.Query()
+		switch {
+		case q.Get("tx-type") == "appl":
+			res = models.TransactionsResponse{Transactions: f.calls}
+		case q.Get("tx-type") == "pay" && f.config.AssetID == 0:
+			res = models.TransactionsResponse{Transactions: f.payments}
+		case q.Get("tx-type") == "axfer" && q.Get("asset-id") == strconv.FormatUint(f.config.AssetID, 10):
+			res = models.TransactionsResponse{Transactions: f.payments}
+		default:
+			res = models.TransactionsResponse{}
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+// encodeState encodes state, in the form of model.GlobalState, as it's read
+// from the indexer
+func encodeState(state map[string]string) []models.TealKeyValue {
+
+	var res []models.TealKeyValue
+	for key, value := range state {
+		kv := models.TealKeyValue{Key: base64.StdEncoding.EncodeToString([]byte(key))}
+		if u, err := strconv.ParseUint(value, 10, 64); err == nil {
+			kv.Value = models.TealValue{Type: 2, Uint: u}
+		} else {
+			kv.Value = models.TealValue{Type: 1, Bytes: value}
+		}
+		res = append(res, kv)
+	}
+	return res
+}
+
+func newFakeIndexer(t *testing.T, f *fakeIndexer) *indexer.Client {
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	c, err := indexer.MakeClient(srv.URL, "")
+	require.NoError(t, err)
+	return c
+}
+
+func commitTxns(player crypto.Account, group string, c algokeno.Commitment, wager, round uint64) (models.Transaction, models.Transaction) {
+
+	call := models.Transaction{
+		Sender: player.Address.String(),
+		Group: []byte(group),
+		ConfirmedRound: round,
+		ApplicationTransaction: models.TransactionApplication{
+			ApplicationId: testAppID,
+			ApplicationArgs: [][]byte{lotto.MethodCommit.GetSelector(), model.BytesArg(c)},
+		},
+	}
+	payment := models.Transaction{
+		Sender: player.Address.String(),
+		Group: []byte(group),
+		ConfirmedRound: round,
+		PaymentTransaction: models.TransactionPayment{
+			Receiver: crypto.GetApplicationAddress(testAppID).String(),
+			Amount: wager,
+		},
+	}
+	return call, payment
+}
+
+// assetCommitTxns is commitTxns for an app taking wagers in assetID
+func assetCommitTxns(player crypto.Account, group string, c algokeno.Commitment, assetID, wager, round uint64) (models.Transaction, models.Transaction) {
+
+	call, payment := commitTxns(player, group, c, 0, round)
+	payment.PaymentTransaction = models.TransactionPayment{}
+	payment.AssetTransferTransaction = models.TransactionAssetTransfer{
+		AssetId: assetID,
+		Receiver: crypto.GetApplicationAddress(testAppID).String(),
+		Amount: wager,
+	}
+	return call, payment
+}
+
+func TestWaitForIndexerRound(t *testing.T) {
+
+	PollInterval = time.Millisecond
+	f := &fakeIndexer{maxRound: 10}
+	idx := newFakeIndexer(t, f)
+
+	require.NoError(t, WaitForIndexerRound(context.Background(), idx, 5))
+	require.Equal(t, uint64(5), atomic.LoadUint64(&f.round))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := WaitForIndexerRound(ctx, idx, 11)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Contains(t, err.Error(), "(at 10)")
+}
+
+func TestTickets(t *testing.T) {
+
+	PollInterval = time.Millisecond
+
+	acc1 := crypto.GenerateAccount()
+	acc2 := crypto.GenerateAccount()
+	acc3 := crypto.GenerateAccount()
+
+	f := &fakeIndexer{maxRound: 20}
+	add := func(call, payment models.Transaction) {
+		f.calls = append(f.calls, call)
+		f.payments = append(f.payments, payment)
+	}
+	add(commitTxns(acc1, "g1", algokeno.Commitment{1, 2, 3, 4, 5, 6}, 1_000_000, 11))
+	add(commitTxns(acc2, "g2", algokeno.Commitment{1, 2, 3, 7, 8, 9}, 1_000_000, 12))
+	add(commitTxns(acc1, "g3", algokeno.Commitment{10, 11, 12, 13, 14, 15}, 2_000_000, 13))
+	add(commitTxns(acc3, "g4", algokeno.Commitment{1, 2, 3, 4, 5, 7}, 500_000, 14))
+
+	// Calls of other methods (e.g. set_draw) are ignored
+	f.calls = append(f.calls, models.Transaction{
+		Sender: acc1.Address.String(),
+		ApplicationTransaction: models.TransactionApplication{
+			ApplicationId: testAppID,
+			ApplicationArgs: [][]byte{lotto.MethodSetDraw.GetSelector(), model.BytesArg(algokeno.Commitment{1, 2, 3, 4, 5, 6})},
+		},
+	})
+
+	s := New(newFakeIndexer(t, f), testAppID)
+	tickets, err := s.Tickets(context.Background(), 14)
+	require.NoError(t, err)
+
+	require.Equal(t, []Ticket{
+		{Player: acc2.Address, Commitment: algokeno.Commitment{1, 2, 3, 7, 8, 9}, Wager: 1_000_000, Round: 12},
+		{Player: acc1.Address, Commitment: algokeno.Commitment{10, 11, 12, 13, 14, 15}, Wager: 2_000_000, Round: 13},
+		{Player: acc3.Address, Commitment: algokeno.Commitment{1, 2, 3, 4, 5, 7}, Wager: 500_000, Round: 14},
+	}, tickets)
+
+	// acc3 wagered less than the minimum so doesn't count
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	require.Equal(t, []uint64{0, 0, 1, 0, 0, 0}, Winners(algokeno.DefaultGameConfig, draw, tickets))
+
+	// ...unless the app was created with a lower ticket price
+	cheap := algokeno.DefaultGameConfig
+	cheap.TicketPrice = 500_000
+	require.Equal(t, []uint64{0, 0, 1, 0, 1, 0}, Winners(cheap, draw, tickets))
+
+	tiers := Tiers(algokeno.DefaultGameConfig, draw, tickets, []uint64{1, 2, 3, 4, 5, 6})
+	require.Equal(t, uint64(1), tiers[2].Winners)
+	require.Equal(t, uint64(3), tiers[2].Prize)
+	require.Equal(t, uint64(0), tiers[5].Winners)
+	require.Equal(t, uint64(6), tiers[5].Prize)
+}
+
+func TestTicketsPaidByAnother(t *testing.T) {
+
+	PollInterval = time.Millisecond
+
+	player := crypto.GenerateAccount()
+	payer := crypto.GenerateAccount()
+
+	// The app only requires the wager to be paid to it in the commit's
+	// group, not that the player paid it
+	call, payment := commitTxns(player, "g1", algokeno.Commitment{1, 2, 3, 4, 5, 6}, 1_000_000, 11)
+	payment.Sender = payer.Address.String()
+	f := &fakeIndexer{
+		maxRound: 20,
+		calls: []models.Transaction{call},
+		payments: []models.Transaction{payment},
+	}
+
+	s := New(newFakeIndexer(t, f), testAppID)
+	tickets, err := s.Tickets(context.Background(), 11)
+	require.NoError(t, err)
+	require.Equal(t, []Ticket{
+		{Player: player.Address, Commitment: algokeno.Commitment{1, 2, 3, 4, 5, 6}, Wager: 1_000_000, Round: 11},
+	}, tickets)
+}
+
+func TestTicketsAsset(t *testing.T) {
+
+	PollInterval = time.Millisecond
+
+	acc1 := crypto.GenerateAccount()
+	acc2 := crypto.GenerateAccount()
+	sponsor := crypto.GenerateAccount()
+
+	config := algokeno.DefaultGameConfig
+	config.AssetID = 99
+	config.TicketPrice = 1000
+
+	f := &fakeIndexer{maxRound: 20, config: config}
+	add := func(call, payment models.Transaction) {
+		f.calls = append(f.calls, call)
+		f.payments = append(f.payments, payment)
+	}
+	add(assetCommitTxns(acc1, "g1", algokeno.Commitment{1, 2, 3, 4, 5, 6}, 99, 1000, 11))
+	add(assetCommitTxns(acc2, "g2", algokeno.Commitment{1, 2, 3, 7, 8, 9}, 99, 999, 12))
+
+	call, payment := assetCommitTxns(sponsor, "g3", nil, 99, 5000, 13)
+	arg := make([]byte, 8)
+	binary.BigEndian.PutUint64(arg, 6)
+	call.ApplicationTransaction.ApplicationArgs = [][]byte{lotto.MethodSponsor.GetSelector(), arg}
+	add(call, payment)
+
+	s := New(newFakeIndexer(t, f), testAppID)
+	tickets, err := s.Tickets(context.Background(), 13)
+	require.NoError(t, err)
+	require.Equal(t, []Ticket{
+		{Player: acc1.Address, Commitment: algokeno.Commitment{1, 2, 3, 4, 5, 6}, Wager: 1000, Round: 11},
+		{Player: acc2.Address, Commitment: algokeno.Commitment{1, 2, 3, 7, 8, 9}, Wager: 999, Round: 12},
+	}, tickets)
+
+	// acc2 wagered less than the ticket price in the asset so doesn't count
+	require.Equal(t, []uint64{0, 0, 0, 0, 0, 1}, Winners(config, algokeno.Commitment{1, 2, 3, 4, 5, 6}, tickets))
+
+	sponsorships, err := s.Sponsorships(context.Background(), 13)
+	require.NoError(t, err)
+	require.Equal(t, []Sponsorship{
+		{Sponsor: sponsor.Address, Tier: 6, Amount: 5000, Round: 13},
+	}, sponsorships)
+}
+
+func TestSponsorships(t *testing.T) {
+
+	PollInterval = time.Millisecond
+
+	player := crypto.GenerateAccount()
+	sponsor := crypto.GenerateAccount()
+
+	f := &fakeIndexer{maxRound: 20}
+	call, payment := commitTxns(player, "g1", algokeno.Commitment{1, 2, 3, 4, 5, 6}, 1_000_000, 11)
+	f.calls = append(f.calls, call)
+	f.payments = append(f.payments, payment)
+
+	sponsorTxns := func(group string, tier, amount, round uint64) {
+		call, payment := commitTxns(sponsor, group, nil, amount, round)
+		arg := make([]byte, 8)
+		binary.BigEndian.PutUint64(arg, tier)
+		call.ApplicationTransaction.ApplicationArgs = [][]byte{lotto.MethodSponsor.GetSelector(), arg}
+		call.Note = []byte("jackpot seed")
+		f.calls = append(f.calls, call)
+		f.payments = append(f.payments, payment)
+	}
+	sponsorTxns("g2", 6, 5_000_000, 12)
+	sponsorTxns("g3", 6, 2_000_000, 13)
+	sponsorTxns("g4", 1, 100, 14)
+
+	s := New(newFakeIndexer(t, f), testAppID)
+	sponsorships, err := s.Sponsorships(context.Background(), 14)
+	require.NoError(t, err)
+	require.Equal(t, []Sponsorship{
+		{Sponsor: sponsor.Address, Tier: 6, Amount: 5_000_000, Note: []byte("jackpot seed"), Round: 12},
+		{Sponsor: sponsor.Address, Tier: 6, Amount: 2_000_000, Note: []byte("jackpot seed"), Round: 13},
+		{Sponsor: sponsor.Address, Tier: 1, Amount: 100, Note: []byte("jackpot seed"), Round: 14},
+	}, sponsorships)
+
+	// Sponsorships aren't tickets
+	tickets, err := s.Tickets(context.Background(), 14)
+	require.NoError(t, err)
+	require.Len(t, tickets, 1)
+
+	guaranteed := Guaranteed(algokeno.DefaultGameConfig, sponsorships)
+	require.Equal(t, []uint64{100, 0, 0, 0, 0, 7_000_000}, guaranteed)
+	require.Equal(
+		t,
+		[]uint64{100, 20, 30, 40, 50, 7_000_000},
+		HonourGuarantees([]uint64{10, 20, 30, 40, 50, 60}, guaranteed),
+	)
+	require.Equal(
+		t,
+		[]uint64{200, 20, 30, 40, 50, 8_000_000},
+		HonourGuarantees([]uint64{200, 20, 30, 40, 50, 8_000_000}, guaranteed),
+	)
+}
+
+func TestWinnersMixedEncodings(t *testing.T) {
+
+	bitmask := func(nums ...uint8) algokeno.Commitment {
+		c, err := algokeno.NewBitmaskCommitment(nums...)
+		require.NoError(t, err)
+		return c
+	}
+
+	tickets := []Ticket{
+		{Commitment: algokeno.Commitment{1, 2, 3, 4, 5, 6}, Wager: 1_000_000},
+		{Commitment: bitmask(1, 2, 3, 4, 5, 6), Wager: 1_000_000},
+		{Commitment: bitmask(1, 2, 3, 7, 8, 9), Wager: 1_000_000},
+		{Commitment: algokeno.Commitment{1, 7, 8, 9, 10, 11}, Wager: 1_000_000},
+	}
+
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	expected := []uint64{1, 0, 1, 0, 0, 2}
+	require.Equal(t, expected, Winners(algokeno.DefaultGameConfig, draw, tickets))
+	require.Equal(t, expected, Winners(algokeno.DefaultGameConfig, bitmask(1, 2, 3, 4, 5, 6), tickets))
+
+	// Draws with numbers which don't fit in a bitmap are matched number by
+	// number, and can't match any bitmask numbers
+	config := algokeno.DefaultGameConfig
+	config.MaxNumber = 200
+	tickets = append(tickets, Ticket{Commitment: algokeno.Commitment{1, 2, 3, 4, 5, 100}, Wager: 1_000_000})
+	require.Equal(t, []uint64{1, 0, 1, 0, 3, 0}, Winners(config, algokeno.Commitment{1, 2, 3, 4, 6, 100}, tickets))
+}
+
+func TestBonusWinners(t *testing.T) {
+
+	config := algokeno.DefaultGameConfig
+	config.Picks, config.MaxNumber, config.BonusMax = 5, 70, 26
+
+	picks := algokeno.Commitment{1, 2, 3, 4, 5}
+	bits, err := picks.ToBitmask()
+	require.NoError(t, err)
+
+	tickets := []Ticket{
+		{Commitment: picks.WithBonus(7), Wager: 1_000_000},
+		{Commitment: bits.WithBonus(8), Wager: 1_000_000},
+		{Commitment: algokeno.Commitment{1, 2, 3, 4, 69}.WithBonus(7), Wager: 1_000_000},
+		{Commitment: algokeno.Commitment{10, 20, 30, 40, 50}.WithBonus(7), Wager: 1_000_000},
+		{Commitment: algokeno.Commitment{10, 20, 30, 40, 50}.WithBonus(8), Wager: 1_000_000},
+		{Commitment: picks.WithBonus(7), Wager: 1},
+		{Commitment: algokeno.Seal(picks.WithBonus(7), []byte("salt")), Wager: 1_000_000},
+	}
+
+	// Tickets whose bonus number was drawn only win a bonus tier
+	draw := picks.WithBonus(7)
+	require.Equal(t, []uint64{0, 0, 0, 0, 1}, Winners(config, draw, tickets))
+	require.Equal(t, []uint64{1, 0, 0, 0, 1, 1}, BonusWinners(config, draw, tickets))
+	require.Nil(t, BonusWinners(algokeno.DefaultGameConfig, draw, tickets))
+
+	tiers := Tiers(config, draw, tickets, []uint64{0, 0, 0, 0, 500})
+	bonusTiers := BonusTiers(config, draw, tickets, []uint64{10, 20, 30, 40, 1_000, 3_000})
+	require.Equal(t, model.Tier{Winners: 1, Prize: 500}, tiers[4])
+	require.Equal(t, model.Tier{Winners: 1, Prize: 3_000}, bonusTiers[5])
+	require.Equal(t, model.Tier{Winners: 0, Prize: 20}, bonusTiers[1])
+
+	require.Equal(
+		t,
+		[]uint64{3_000, 500, 1_000, 10, 0, 0, 0},
+		Payouts(config, draw, tickets, tiers, bonusTiers),
+	)
+}
+
+func TestTicketsSealed(t *testing.T) {
+
+	PollInterval = time.Millisecond
+
+	revealer := crypto.GenerateAccount()
+	hidden := crypto.GenerateAccount()
+	imposter := crypto.GenerateAccount()
+
+	numbers := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	salt := []byte("revealer's salt")
+	sealed := algokeno.Seal(numbers, salt)
+
+	f := &fakeIndexer{maxRound: 20}
+	add := func(call, payment models.Transaction) {
+		f.calls = append(f.calls, call)
+		f.payments = append(f.payments, payment)
+	}
+	reveal := func(player crypto.Account, salt []byte, round uint64) {
+		f.calls = append(f.calls, models.Transaction{
+			Sender: player.Address.String(),
+			ConfirmedRound: round,
+			ApplicationTransaction: models.TransactionApplication{
+				ApplicationId: testAppID,
+				ApplicationArgs: [][]byte{lotto.MethodReveal.GetSelector(), model.BytesArg(numbers), model.BytesArg(salt)},
+			},
+		})
+	}
+	add(commitTxns(revealer, "g1", sealed, 1_000_000, 11))
+	add(commitTxns(hidden, "g2", algokeno.Seal(numbers, []byte("hidden salt")), 1_000_000, 12))
+	add(commitTxns(imposter, "g3", algokeno.Commitment{1, 2, 3, 7, 8, 9}, 1_000_000, 13))
+	reveal(revealer, salt, 15)
+
+	// A reveal only opens the sealed ticket of its sender, with its salt
+	reveal(imposter, salt, 16)
+	reveal(hidden, salt, 16)
+
+	s := New(newFakeIndexer(t, f), testAppID)
+	tickets, err := s.Tickets(context.Background(), 16)
+	require.NoError(t, err)
+	require.Len(t, tickets, 3)
+	require.Equal(t, numbers, tickets[0].Commitment)
+	require.False(t, tickets[0].Unrevealed())
+	require.True(t, tickets[1].Unrevealed())
+	require.Equal(t, algokeno.Commitment{1, 2, 3, 7, 8, 9}, tickets[2].Commitment)
+
+	// The hidden ticket can't claim once the draw is set, so it wins no
+	// tier and the prizes of tiers without winners roll over
+	require.Equal(t, []uint64{0, 0, 1, 0, 0, 1}, Winners(algokeno.DefaultGameConfig, numbers, tickets))
+	require.Equal(t, uint64(1), Unrevealed(tickets))
+
+	tiers := Tiers(algokeno.DefaultGameConfig, numbers, tickets, []uint64{2, 4, 6, 8, 10, 12})
+	for i, w := range []uint64{0, 0, 1, 0, 0, 1} {
+		require.Equal(t, w, tiers[i].Winners)
+	}
+	rollover, err := model.RolloverAmount(tiers)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2+4+8+10), rollover)
+}
+
+func TestUnrevealedUnderPrice(t *testing.T) {
+
+	numbers := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	tickets := []Ticket{
+		{Commitment: algokeno.Seal(numbers, []byte("salt")), Wager: algokeno.DefaultGameConfig.TicketPrice},
+		{Commitment: algokeno.Seal(numbers, []byte("cheap salt")), Wager: algokeno.DefaultGameConfig.TicketPrice - 1},
+		{Commitment: numbers, Wager: algokeno.DefaultGameConfig.TicketPrice},
+	}
+
+	// The app counts the sealed ticket under the price too, so the reveal
+	// window stays open until it's revealed, even though it can't claim
+	require.Equal(t, uint64(2), Unrevealed(tickets))
+	require.Equal(t, []uint64{0, 0, 0, 0, 0, 1}, Winners(algokeno.DefaultGameConfig, numbers, tickets))
+}
+
+func TestPayouts(t *testing.T) {
+
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	ticket := func(c algokeno.Commitment) Ticket {
+		return Ticket{Commitment: c, Wager: 1_000_000}
+	}
+
+	testCases := []struct{
+		Name string
+		Tickets []Ticket
+		Prizes []uint64
+		ExpectedPayouts []uint64
+		ExpectedRollover uint64
+	}{
+		{
+			Name: "two share the jackpot",
+			Tickets: []Ticket{
+				ticket(draw),
+				ticket(draw),
+			},
+			Prizes: []uint64{0, 0, 0, 0, 0, 1_000_001},
+			ExpectedPayouts: []uint64{500_000, 500_000},
+			ExpectedRollover: 1,
+		},
+		{
+			Name: "five share a tier",
+			Tickets: []Ticket{
+				ticket(algokeno.Commitment{1, 2, 3, 7, 8, 9}),
+				ticket(algokeno.Commitment{1, 2, 3, 10, 11, 12}),
+				ticket(algokeno.Commitment{4, 5, 6, 10, 11, 12}),
+				ticket(algokeno.Commitment{2, 4, 6, 10, 11, 12}),
+				ticket(algokeno.Commitment{1, 3, 5, 10, 11, 12}),
+				ticket(algokeno.Commitment{1, 3, 10, 11, 12, 13}),
+			},
+			Prizes: []uint64{0, 5, 100_003, 0, 0, 7},
+			ExpectedPayouts: []uint64{20_000, 20_000, 20_000, 20_000, 20_000, 5},
+			ExpectedRollover: 3 + 7,
+		},
+		{
+			Name: "ineligible tickets don't share",
+			Tickets: []Ticket{
+				ticket(draw),
+				{Commitment: draw, Wager: 999_999},
+				ticket(algokeno.Seal(draw, []byte("salt"))),
+			},
+			Prizes: []uint64{0, 0, 0, 0, 0, 1_000_000},
+			ExpectedPayouts: []uint64{1_000_000, 0, 0},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			tiers := Tiers(algokeno.DefaultGameConfig, draw, test.Tickets, test.Prizes)
+			require.Equal(t, test.ExpectedPayouts, Payouts(algokeno.DefaultGameConfig, draw, test.Tickets, tiers, nil))
+
+			rollover, err := model.RolloverAmount(tiers)
+			require.NoError(t, err)
+			require.Equal(t, test.ExpectedRollover, rollover)
+		})
+	}
+}
+
+// BenchmarkWinners compares working out the winners among tickets which
+// are byte encoded to among tickets which are bitmask encoded
+func BenchmarkWinners(b *testing.B) {
+
+	r := rand.New(rand.NewSource(1))
+	draw := model.RandomCommitment(r, algokeno.DefaultGameConfig)
+
+	byteTickets := make([]Ticket, 10_000)
+	bitmaskTickets := make([]Ticket, len(byteTickets))
+	for i := range byteTickets {
+		c := model.RandomCommitment(r, algokeno.DefaultGameConfig)
+		byteTickets[i] = Ticket{Commitment: c, Wager: model.MinWager}
+
+		bc, err := c.ToBitmask()
+		require.NoError(b, err)
+		bitmaskTickets[i] = Ticket{Commitment: bc, Wager: model.MinWager}
+	}
+
+	b.Run("bytes", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Winners(algokeno.DefaultGameConfig, draw, byteTickets)
+		}
+	})
+	b.Run("bitmask", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Winners(algokeno.DefaultGameConfig, draw, bitmaskTickets)
+		}
+	})
+}
+
+func TestCheckSolvency(t *testing.T) {
+
+	treasury := algokeno.DefaultGameConfig
+	treasury.Treasury = []algokeno.Beneficiary{{ShareBps: 3_333}, {ShareBps: 6_667}}
+
+	asset := algokeno.DefaultGameConfig
+	asset.AssetID = 42
+
+	testCases := []struct{
+		Name string
+		Config algokeno.GameConfig
+		Escrow uint64
+		Sponsored uint64
+		Tiers []model.Tier
+		ExpectedErr error
+	}{
+		{
+			Name: "prizes and fee leave min balance",
+			Config: algokeno.DefaultGameConfig,
+			Escrow: 1_000_000,
+			Tiers: []model.Tier{{Winners: 0, Prize: 400_000}, {Winners: 2, Prize: 400_000}},
+		},
+		{
+			Name: "prizes eat into min balance",
+			Config: algokeno.DefaultGameConfig,
+			Escrow: 1_000_000,
+			Tiers: []model.Tier{{Winners: 0, Prize: 400_000}, {Winners: 2, Prize: 400_001}},
+			ExpectedErr: ErrInsolvent,
+		},
+		{
+			Name: "fee split between treasury",
+			Config: treasury,
+			Escrow: 1_000_000,
+			Tiers: []model.Tier{{Winners: 1, Prize: 800_000}},
+		},
+		{
+			Name: "fee split between treasury eats into min balance",
+			Config: treasury,
+			Escrow: 1_000_000,
+			Tiers: []model.Tier{{Winners: 1, Prize: 800_001}},
+			ExpectedErr: ErrInsolvent,
+		},
+		{
+			Name: "no fee on sponsored",
+			Config: algokeno.DefaultGameConfig,
+			Escrow: 1_000_000,
+			Sponsored: 800_000,
+			Tiers: []model.Tier{{Winners: 0, Prize: 880_000}},
+		},
+		{
+			Name: "fee on the rest of the escrow eats into min balance",
+			Config: algokeno.DefaultGameConfig,
+			Escrow: 1_000_000,
+			Sponsored: 800_000,
+			Tiers: []model.Tier{{Winners: 0, Prize: 880_001}},
+			ExpectedErr: ErrInsolvent,
+		},
+		{
+			Name: "sponsored more than escrow",
+			Config: algokeno.DefaultGameConfig,
+			Escrow: 1_000_000,
+			Sponsored: 1_000_001,
+			ExpectedErr: ErrInsolvent,
+		},
+		{
+			Name: "asset escrow emptied",
+			Config: asset,
+			Escrow: 1_000_000,
+			Tiers: []model.Tier{{Winners: 1, Prize: 900_000}},
+		},
+		{
+			Name: "asset escrow overdrawn",
+			Config: asset,
+			Escrow: 1_000_000,
+			Tiers: []model.Tier{{Winners: 1, Prize: 900_001}},
+			ExpectedErr: ErrInsolvent,
+		},
+		{
+			Name: "prizes overflow",
+			Config: asset,
+			Escrow: 1_000_000,
+			Tiers: []model.Tier{{Winners: 1, Prize: math.MaxUint64}, {Winners: 1, Prize: 1}},
+			ExpectedErr: model.ErrOverflow,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			err := CheckSolvency(test.Config, test.Escrow, test.Sponsored, test.Tiers)
+			require.ErrorIs(t, err, test.ExpectedErr)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package sim
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var csvHeader = []string{
+	"round",
+	"tickets",
+	"rollover_in",
+	"funding",
+	"escrow",
+	"fee",
+	"jackpot",
+	"jackpot_won",
+	"paid",
+	"rollover_out",
+	"stranded",
+	"short",
+}
+
+// WriteCSV writes rounds as CSV, a row per round under a header naming
+// each column like Round's JSON fields
+func WriteCSV(w io.Writer, rounds []Round) error {
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	u := func(v uint64) string { return strconv.FormatUint(v, 10) }
+	for _, r := range rounds {
+		err := cw.Write([]string{
+			strconv.Itoa(r.Round),
+			u(r.Tickets),
+			u(r.RolloverIn),
+			u(r.Funding),
+			u(r.Escrow),
+			u(r.Fee),
+			u(r.Jackpot),
+			strconv.FormatBool(r.JackpotWon),
+			u(r.Paid),
+			u(r.RolloverOut),
+			u(r.Stranded),
+			strconv.FormatBool(r.Short),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes rounds as a JSON array
+func WriteJSON(w io.Writer, rounds []Round) error {
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rounds)
+}
+
+// Summary totals the rounds of a simulation
+type Summary struct {
+	Rounds int
+	Tickets uint64
+	Funding uint64
+	Fee uint64
+	Paid uint64
+	Stranded uint64
+	JackpotsWon int
+	MaxJackpot uint64
+	ShortRounds int
+}
+
+// Summarize returns the totals of rounds
+func Summarize(rounds []Round) Summary {
+
+	s := Summary{Rounds: len(rounds)}
+	for _, r := range rounds {
+		s.Tickets += r.Tickets
+		s.Funding += r.Funding
+		s.Fee += r.Fee
+		s.Paid += r.Paid
+		s.Stranded += r.Stranded
+		if r.JackpotWon {
+			s.JackpotsWon++
+		}
+		if r.Jackpot > s.MaxJackpot {
+			s.MaxJackpot = r.Jackpot
+		}
+		if r.Short {
+			s.ShortRounds++
+		}
+	}
+	return s
+}
+
+// String returns the summary on a single line
+func (s Summary) String() string {
+
+	return fmt.Sprintf(
+		"%d rounds, %d tickets: funding %d, fee %d, paid %d, stranded %d, %d jackpots won (max %d), %d rounds short",
+		s.Rounds,
+		s.Tickets,
+		s.Funding,
+		s.Fee,
+		s.Paid,
+		s.Stranded,
+		s.JackpotsWon,
+		s.MaxJackpot,
+		s.ShortRounds,
+	)
+}
@@ -0,0 +1,70 @@
+package sim
+
+import (
+	"github.com/neurotempest/algokeno"
+)
+
+// SharePolicy splits the pot (see Pot) between the tiers and bonus tiers
+// by shareBps and bonusShareBps, in basis points. Shares of tiers which
+// aren't won roll over, growing the next round's pot.
+func SharePolicy(shareBps, bonusShareBps []uint64) Policy {
+
+	return func(config algokeno.GameConfig, escrow uint64, winners, bonusWinners []uint64) ([]uint64, []uint64) {
+
+		pot := Pot(config, escrow)
+
+		split := func(shares []uint64) []uint64 {
+			if shares == nil {
+				return nil
+			}
+
+			prizes := make([]uint64, len(shares))
+			for i, share := range shares {
+				prizes[i] = mulDiv(pot, share, algokeno.MaxHouseFeeBps)
+			}
+			return prizes
+		}
+		return split(shareBps), split(bonusShareBps)
+	}
+}
+
+// FixedPolicy pays each winner of a tier (or bonus tier) its entry of
+// prizes (or bonusPrizes), and puts the rest of the pot (see Pot) in the
+// jackpot, whose own entry is ignored. The escrow falls short when the
+// fixed prizes add up to more than the pot.
+func FixedPolicy(prizes, bonusPrizes []uint64) Policy {
+
+	return func(config algokeno.GameConfig, escrow uint64, winners, bonusWinners []uint64) ([]uint64, []uint64) {
+
+		pot := Pot(config, escrow)
+
+		tiers := make([]uint64, len(winners))
+		var bonusTiers []uint64
+		if bonusWinners != nil {
+			bonusTiers = make([]uint64, len(bonusWinners))
+		}
+
+		var fixed uint64
+		fix := func(res, winners, prizes []uint64) {
+			for i := range res {
+				res[i] = mulDiv(winners[i], prizes[i], 1)
+				fixed += res[i]
+			}
+		}
+
+		// The jackpot is the last of the tiers, or of the bonus tiers
+		jackpot := &tiers[len(tiers)-1]
+		if bonusTiers != nil {
+			fix(tiers, winners, prizes)
+			fix(bonusTiers[:len(bonusTiers)-1], bonusWinners, bonusPrizes)
+			jackpot = &bonusTiers[len(bonusTiers)-1]
+		} else {
+			fix(tiers[:len(tiers)-1], winners, prizes)
+		}
+
+		if fixed < pot {
+			*jackpot = pot - fixed
+		}
+		return tiers, bonusTiers
+	}
+}
@@ -0,0 +1,72 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+)
+
+// FixedSales sells n tickets every round
+func FixedSales(n uint64) Sales {
+
+	return func(*rand.Rand, int, uint64) uint64 {
+
+		return n
+	}
+}
+
+// UniformSales sells between min and max tickets a round, inclusive
+func UniformSales(min, max uint64) Sales {
+
+	return func(r *rand.Rand, _ int, _ uint64) uint64 {
+
+		if max <= min {
+			return min
+		}
+		return min + uint64(r.Int63n(int64(max-min+1)))
+	}
+}
+
+// PoissonSales sells a Poisson distributed number of tickets a round with
+// mean mean, as when each player buys a ticket independently of the rest.
+// Large means are drawn from the normal distribution approximating it.
+func PoissonSales(mean float64) Sales {
+
+	return func(r *rand.Rand, _ int, _ uint64) uint64 {
+
+		return poisson(r, mean)
+	}
+}
+
+// RolloverSales adds perUnit extra tickets for each unit (microalgo or base
+// unit of the app's asset) rolled over into the round to the tickets sold
+// by s, as a growing jackpot draws more players
+func RolloverSales(s Sales, perUnit float64) Sales {
+
+	return func(r *rand.Rand, round int, rollover uint64) uint64 {
+
+		return s(r, round, rollover) + uint64(math.Round(perUnit*float64(rollover)))
+	}
+}
+
+func poisson(r *rand.Rand, mean float64) uint64 {
+
+	if mean <= 0 {
+		return 0
+	}
+
+	if mean > 500 {
+		n := math.Round(mean + math.Sqrt(mean)*r.NormFloat64())
+		if n < 0 {
+			return 0
+		}
+		return uint64(n)
+	}
+
+	// Knuth's: the number of uniforms whose product stays above e^-mean
+	limit := math.Exp(-mean)
+	var n uint64
+	for p := r.Float64(); p > limit; p *= r.Float64() {
+		n++
+	}
+	return n
+}
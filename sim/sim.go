@@ -0,0 +1,333 @@
+// Package sim is a Monte Carlo simulator of the economics of a chain of
+// lotto apps, each rolling its unwon prizes over to the next, to see how
+// jackpots grow, what the treasury earns and how often a prize policy
+// leaves the escrow short.
+//
+// Each round sells a number of tickets drawn from a Sales distribution,
+// counts the winners of each tier by drawing each ticket's outcome from
+// the exact odds of the game, and sets the draw with the prizes of a
+// Policy. The house fee, rollover (and its threshold), dust and claims
+// are applied by model.Lotto, so they follow the contract's integer
+// arithmetic. Every app is funded with model.MinBalance by its creator
+// before its tickets are sold, and keeps it once it's settled. If that
+// and its sales are too little for the house fee to be paid without
+// leaving it below its min balance, the creator funds it with the rest.
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/model"
+	"github.com/neurotempest/algokeno/odds"
+	"github.com/neurotempest/algokeno/settlement"
+)
+
+var (
+	ErrNoRounds = errors.New("at least one round must be simulated")
+	ErrUnfundable = errors.New("no funding covers the house fee and min balance")
+)
+
+// Sales returns the number of tickets sold in round, given what was rolled
+// over into it from the previous round, which sales tend to follow
+type Sales func(r *rand.Rand, round int, rollover uint64) uint64
+
+// Policy returns the prizes of the tiers and bonus tiers to set the draw of
+// an app created with config with, indexed like settlement.Tiers and
+// settlement.BonusTiers, given its escrow balance when the draw is set and
+// the winners of each tier
+type Policy func(config algokeno.GameConfig, escrow uint64, winners, bonusWinners []uint64) ([]uint64, []uint64)
+
+// Config is what a simulation is run with
+type Config struct {
+	Game algokeno.GameConfig
+	Rounds int
+	Sales Sales
+	Policy Policy
+
+	// Seed seeds the simulation's randomness, so runs with the same config
+	// are the same
+	Seed int64
+}
+
+// Round is the outcome of a single round of a simulation
+type Round struct {
+	Round int `json:"round"`
+	Tickets uint64 `json:"tickets"`
+
+	// RolloverIn is what was rolled over into the app from the previous one
+	RolloverIn uint64 `json:"rollover_in"`
+
+	// Funding is what the creator funded the app with
+	Funding uint64 `json:"funding"`
+
+	// Escrow is the app's balance when the draw is set
+	Escrow uint64 `json:"escrow"`
+
+	// Fee is the house fee paid to the treasury
+	Fee uint64 `json:"fee"`
+
+	// Jackpot is the prize pool of the jackpot (the tier of tickets
+	// matching every number, and the bonus number in a bonus game)
+	Jackpot uint64 `json:"jackpot"`
+	JackpotWon bool `json:"jackpot_won"`
+
+	// Paid is the total of the prizes claimed
+	Paid uint64 `json:"paid"`
+
+	// RolloverOut is what was rolled over to the next app
+	RolloverOut uint64 `json:"rollover_out"`
+
+	// Stranded is a rollover which didn't exceed the rollover threshold,
+	// so was left in the app rather than sent to the next
+	Stranded uint64 `json:"stranded"`
+
+	// Short is whether the escrow couldn't cover the prizes of the policy,
+	// which were then cut down pro rata to fit
+	Short bool `json:"short"`
+}
+
+// outcome is one of the ways a ticket can win, with its probability
+type outcome struct {
+	bonus bool
+	tier int
+	p float64
+}
+
+// Run simulates config.Rounds rounds, returning the outcome of each
+func Run(config Config) ([]Round, error) {
+
+	if config.Rounds < 1 {
+		return nil, ErrNoRounds
+	}
+
+	if err := config.Game.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := rand.New(rand.NewSource(config.Seed))
+	outcomes := outcomes(config.Game)
+
+	var creator, next types.Address
+	next[0] = 1
+
+	rounds := make([]Round, config.Rounds)
+	var rollover uint64
+	for i := range rounds {
+		res := Round{
+			Round: i,
+			Tickets: config.Sales(r, i, rollover),
+			RolloverIn: rollover,
+		}
+
+		l := model.NewWithConfig(creator, config.Game)
+		sales := res.Tickets * config.Game.TicketPrice
+		if config.Game.TicketPrice != 0 && sales/config.Game.TicketPrice != res.Tickets || rollover+sales < rollover {
+			return nil, fmt.Errorf("round %d: %w", i, model.ErrOverflow)
+		}
+
+		funding, err := Funding(config.Game, rollover+sales)
+		if err != nil {
+			return nil, fmt.Errorf("round %d: %w", i, err)
+		}
+		l.Escrow = funding + rollover + sales
+		res.Funding, res.Escrow = funding, l.Escrow
+
+		winners, bonusWinners := sampleWinners(r, config.Game, outcomes, res.Tickets)
+		prizes, bonusPrizes := config.Policy(config.Game, l.Escrow, winners, bonusWinners)
+		tiers, bonusTiers := toTiers(winners, prizes), toTiers(bonusWinners, bonusPrizes)
+
+		err = settlement.CheckSolvency(config.Game, l.Escrow, l.Sponsored, append(append([]model.Tier(nil), tiers...), bonusTiers...))
+		if errors.Is(err, settlement.ErrInsolvent) {
+			res.Short = true
+			fitPrizes(config.Game, l.Escrow, tiers, bonusTiers)
+		} else if err != nil {
+			return nil, fmt.Errorf("round %d: %w", i, err)
+		}
+
+		jackpot := tiers[len(tiers)-1]
+		if config.Game.HasBonus() {
+			jackpot = bonusTiers[len(bonusTiers)-1]
+		}
+		res.Jackpot, res.JackpotWon = jackpot.Prize, jackpot.Winners > 0
+
+		ro, err := model.RolloverAmount(append(append([]model.Tier(nil), tiers...), bonusTiers...))
+		if err != nil {
+			return nil, fmt.Errorf("round %d: %w", i, err)
+		}
+
+		payments, err := l.SetBonusDraw(creator, nil, tiers, bonusTiers, next)
+		if err != nil {
+			return nil, fmt.Errorf("round %d: %w", i, err)
+		}
+
+		rollover = 0
+		for _, p := range payments {
+			if p.To == next {
+				rollover = p.Amount
+			} else {
+				res.Fee += p.Amount
+			}
+		}
+		res.RolloverOut = rollover
+		if rollover == 0 {
+			res.Stranded = ro
+		}
+
+		// Every winner claims an equal share of their tier
+		for _, t := range append(append([]model.Tier(nil), l.Tiers...), l.BonusTiers...) {
+			res.Paid += t.Share() * t.Winners
+		}
+
+		rounds[i] = res
+	}
+	return rounds, nil
+}
+
+// outcomes returns the ways a ticket of a game created with config can win
+func outcomes(config algokeno.GameConfig) []outcome {
+
+	var res []outcome
+	for i, p := range odds.Tiers(config) {
+		f, _ := p.Float64()
+		res = append(res, outcome{tier: i, p: f})
+	}
+	for i, p := range odds.BonusTiers(config) {
+		f, _ := p.Float64()
+		res = append(res, outcome{bonus: true, tier: i, p: f})
+	}
+	return res
+}
+
+// sampleWinners returns the winners of each of the tiers and bonus tiers
+// of a game created with config when tickets are sold, drawing each
+// ticket's outcome at random
+func sampleWinners(r *rand.Rand, config algokeno.GameConfig, outcomes []outcome, tickets uint64) ([]uint64, []uint64) {
+
+	winners := make([]uint64, config.Picks)
+	var bonusWinners []uint64
+	if config.HasBonus() {
+		bonusWinners = make([]uint64, config.Picks+1)
+	}
+
+	for t := uint64(0); t < tickets; t++ {
+		u := r.Float64()
+		for _, o := range outcomes {
+			if u >= o.p {
+				u -= o.p
+				continue
+			}
+
+			if o.bonus {
+				bonusWinners[o.tier]++
+			} else {
+				winners[o.tier]++
+			}
+			break
+		}
+	}
+	return winners, bonusWinners
+}
+
+func toTiers(winners, prizes []uint64) []model.Tier {
+
+	if winners == nil {
+		return nil
+	}
+
+	tiers := make([]model.Tier, len(winners))
+	for i := range tiers {
+		tiers[i] = model.Tier{
+			Winners: winners[i],
+			Prize: prizes[i],
+		}
+	}
+	return tiers
+}
+
+// fitPrizes cuts down the prizes of tiers and bonusTiers pro rata for them
+// to fit in what's left of escrow once the house fee is paid, as
+// settlement.CheckSolvency requires
+func fitPrizes(config algokeno.GameConfig, escrow uint64, tiers, bonusTiers []model.Tier) {
+
+	pot := Pot(config, escrow)
+
+	var total uint64
+	all := append(append([]*model.Tier(nil), tierPtrs(tiers)...), tierPtrs(bonusTiers)...)
+	for _, t := range all {
+		total += t.Prize
+	}
+
+	for _, t := range all {
+		t.Prize = mulDiv(t.Prize, pot, total)
+	}
+}
+
+func tierPtrs(tiers []model.Tier) []*model.Tier {
+
+	res := make([]*model.Tier, len(tiers))
+	for i := range tiers {
+		res[i] = &tiers[i]
+	}
+	return res
+}
+
+// Funding is what the creator of an app created with config has to fund
+// it with for its draw to be set once its escrow holds balance otherwise:
+// model.MinBalance for an Algo escrow, and more if paying the house fee
+// would leave it below that
+func Funding(config algokeno.GameConfig, balance uint64) (uint64, error) {
+
+	if config.AssetID != 0 {
+		return 0, nil
+	}
+
+	if config.HouseFeeBps >= algokeno.MaxHouseFeeBps {
+		return 0, ErrUnfundable
+	}
+
+	// The fee is rounded down, so an escrow of at least
+	// MinBalance*10000/(10000-feeBps) is left with MinBalance
+	keep := algokeno.MaxHouseFeeBps - config.HouseFeeBps
+	needed := (model.MinBalance*algokeno.MaxHouseFeeBps + keep - 1) / keep
+	if balance+model.MinBalance >= needed {
+		return model.MinBalance, nil
+	}
+	return needed - balance, nil
+}
+
+// Pot is what an app created with config can pay out in prizes when its
+// escrow holds escrow, i.e. what's left once the house fee is paid and, for
+// an Algo escrow, its min balance is kept
+func Pot(config algokeno.GameConfig, escrow uint64) uint64 {
+
+	pot := escrow - model.HouseFee(escrow, config.HouseFeeBps)
+	if config.AssetID == 0 {
+		if pot < model.MinBalance {
+			return 0
+		}
+		pot -= model.MinBalance
+	}
+	return pot
+}
+
+// mulDiv returns a*b/c without overflowing
+func mulDiv(a, b, c uint64) uint64 {
+
+	if c == 0 {
+		return 0
+	}
+
+	res := new(big.Int).Mul(new(big.Int).SetUint64(a), new(big.Int).SetUint64(b))
+	res.Div(res, new(big.Int).SetUint64(c))
+	if !res.IsUint64() {
+		return math.MaxUint64
+	}
+	return res.Uint64()
+}
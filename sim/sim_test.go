@@ -0,0 +1,210 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/model"
+)
+
+func TestRun(t *testing.T) {
+
+	testCases := []struct{
+		Name string
+		Config Config
+	}{
+		{
+			Name: "share policy",
+			Config: Config{
+				Game: algokeno.DefaultGameConfig,
+				Rounds: 200,
+				Sales: PoissonSales(2_000),
+				Policy: SharePolicy([]uint64{0, 1_000, 2_000, 2_000, 2_000, 3_000}, nil),
+			},
+		},
+		{
+			Name: "fixed policy",
+			Config: Config{
+				Game: algokeno.DefaultGameConfig,
+				Rounds: 200,
+				Sales: UniformSales(0, 5_000),
+				Policy: FixedPolicy([]uint64{0, 1_000_000, 5_000_000, 50_000_000, 1_000_000_000, 0}, nil),
+			},
+		},
+		{
+			Name: "bonus ball",
+			Config: Config{
+				Game: algokeno.GameConfig{
+					Picks: 5,
+					MaxNumber: 69,
+					TicketPrice: 2_000_000,
+					HouseFeeBps: 1_500,
+					RolloverThreshold: 100_000,
+					BonusMax: 26,
+				},
+				Rounds: 200,
+				Sales: RolloverSales(FixedSales(1_000), 0.000_000_01),
+				Policy: SharePolicy([]uint64{0, 0, 500, 1_000, 2_000}, []uint64{500, 500, 500, 1_000, 1_000, 3_000}),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+
+			rounds, err := Run(tc.Config)
+			require.NoError(t, err)
+			require.Len(t, rounds, tc.Config.Rounds)
+
+			game := tc.Config.Game
+			for i, r := range rounds {
+				require.Equal(t, i, r.Round)
+				require.Equal(t, r.Funding+r.RolloverIn+r.Tickets*game.TicketPrice, r.Escrow)
+
+				require.Equal(t, model.HouseFee(r.Escrow, game.HouseFeeBps), r.Fee)
+
+				// The app keeps its min balance, and whatever rounding left
+				// out of the prizes
+				out := r.Fee + r.Paid + r.RolloverOut + r.Stranded
+				require.LessOrEqual(t, out+model.MinBalance, r.Escrow)
+
+				if r.RolloverOut > 0 {
+					require.Greater(t, r.RolloverOut, game.RolloverThreshold)
+				}
+				if i > 0 {
+					require.Equal(t, rounds[i-1].RolloverOut, r.RolloverIn)
+				}
+			}
+
+			again, err := Run(tc.Config)
+			require.NoError(t, err)
+			require.Equal(t, rounds, again)
+		})
+	}
+}
+
+func TestRunStranded(t *testing.T) {
+
+	// Without sales the app can't pay out anything, and is funded just
+	// enough for the fee to leave it its min balance
+	rounds, err := Run(Config{
+		Game: algokeno.DefaultGameConfig,
+		Rounds: 3,
+		Sales: FixedSales(0),
+		Policy: SharePolicy([]uint64{0, 0, 0, 0, 0, 10_000}, nil),
+	})
+	require.NoError(t, err)
+	for _, r := range rounds {
+		require.Equal(t, uint64(111_112), r.Funding)
+		require.Equal(t, uint64(11_111), r.Fee)
+		require.Equal(t, uint64(1), r.Jackpot)
+		require.Equal(t, uint64(1), r.Stranded)
+		require.Equal(t, uint64(0), r.RolloverOut)
+	}
+}
+
+func TestRunShort(t *testing.T) {
+
+	// Every ticket wins a tier paying more than the ticket price
+	config := Config{
+		Game: algokeno.GameConfig{
+			Picks: 1,
+			MaxNumber: 1,
+			TicketPrice: 1_000_000,
+			RolloverThreshold: 100_000,
+		},
+		Rounds: 5,
+		Sales: FixedSales(100),
+		Policy: func(_ algokeno.GameConfig, _ uint64, winners, _ []uint64) ([]uint64, []uint64) {
+			return []uint64{winners[0] * 2_000_000}, nil
+		},
+	}
+
+	rounds, err := Run(config)
+	require.NoError(t, err)
+	for _, r := range rounds {
+		require.True(t, r.Short)
+		require.True(t, r.JackpotWon)
+		require.Equal(t, r.Escrow-model.MinBalance, r.Paid)
+	}
+	require.Equal(t, 5, Summarize(rounds).ShortRounds)
+
+	config.Rounds = 0
+	_, err = Run(config)
+	require.ErrorIs(t, err, ErrNoRounds)
+}
+
+func TestFunding(t *testing.T) {
+
+	testCases := []struct{
+		Name string
+		Config algokeno.GameConfig
+		Balance uint64
+		Expected uint64
+		ExpectedErr error
+	}{
+		{
+			Name: "min balance",
+			Config: algokeno.DefaultGameConfig,
+			Balance: 1_000_000,
+			Expected: model.MinBalance,
+		},
+		{
+			Name: "empty",
+			Config: algokeno.DefaultGameConfig,
+			Expected: 111_112,
+		},
+		{
+			Name: "topped up",
+			Config: algokeno.DefaultGameConfig,
+			Balance: 100,
+			Expected: 111_012,
+		},
+		{
+			Name: "asset",
+			Config: algokeno.GameConfig{AssetID: 42, HouseFeeBps: 1_000},
+		},
+		{
+			Name: "whole fee",
+			Config: algokeno.GameConfig{HouseFeeBps: algokeno.MaxHouseFeeBps},
+			ExpectedErr: ErrUnfundable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+
+			funding, err := Funding(tc.Config, tc.Balance)
+			require.ErrorIs(t, err, tc.ExpectedErr)
+			require.Equal(t, tc.Expected, funding)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+
+	rounds, err := Run(Config{
+		Game: algokeno.DefaultGameConfig,
+		Rounds: 10,
+		Sales: FixedSales(100),
+		Policy: SharePolicy([]uint64{0, 0, 0, 0, 0, 10_000}, nil),
+	})
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	require.NoError(t, WriteCSV(&b, rounds))
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	require.Len(t, lines, 11)
+	require.Equal(t, strings.Join(csvHeader, ","), lines[0])
+
+	b.Reset()
+	require.NoError(t, WriteJSON(&b, rounds))
+	var decoded []Round
+	require.NoError(t, json.Unmarshal(b.Bytes(), &decoded))
+	require.Equal(t, rounds, decoded)
+}
@@ -0,0 +1,212 @@
+// Package sponsor deposits into the prize pools of lotto apps with the
+// contract's `sponsor` method, which guarantees the prize of a tier
+// (set_draw can't set it any lower), and seeds jackpots up to a guaranteed minimum.
+//
+// Deposits are attributed by the note of the sponsor app call, which the
+// settlement engine reads back with each Sponsorship.
+package sponsor
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/client"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+const waitRounds = 4
+
+var ErrNoDeposit = errors.New("nothing to deposit")
+
+// Sponsor makes deposits from a single account
+type Sponsor struct {
+	cl *client.Context
+	acc crypto.Account
+}
+
+// New returns a sponsor depositing from acc
+func New(cl *client.Context, acc crypto.Account) *Sponsor {
+
+	return &Sponsor{
+		cl: cl,
+		acc: acc,
+	}
+}
+
+// Deposit adds amount to the guaranteed prize of tier (the tier of tickets
+// matching that many numbers) of the app appID, attributed with note, and
+// waits for it to be confirmed. It returns the ID of the sponsor app call.
+func (s *Sponsor) Deposit(ctx context.Context, appID uint64, tier int, amount uint64, note string) (string, error) {
+
+	state, err := s.globalState(ctx, appID)
+	if err != nil {
+		return "", err
+	}
+
+	config, err := model.ConfigFromState(state)
+	if err != nil {
+		return "", err
+	}
+
+	return s.deposit(ctx, config, appID, tier, amount, note)
+}
+
+// SeedJackpot deposits enough into the jackpot (the tier of tickets
+// matching every number) of the app appID for its guaranteed prize to be at
+// least min, returning the amount deposited. Nothing is deposited if the
+// jackpot is already guaranteed min.
+func (s *Sponsor) SeedJackpot(ctx context.Context, appID uint64, min uint64, note string) (uint64, error) {
+
+	state, err := s.globalState(ctx, appID)
+	if err != nil {
+		return 0, err
+	}
+
+	config, err := model.ConfigFromState(state)
+	if err != nil {
+		return 0, err
+	}
+
+	amount, err := JackpotTopUp(config, state, min)
+	if err != nil {
+		return 0, err
+	}
+	if amount == 0 {
+		return 0, nil
+	}
+
+	if _, err := s.deposit(ctx, config, appID, config.Picks, amount, note); err != nil {
+		return 0, err
+	}
+	return amount, nil
+}
+
+// JackpotTopUp returns how much has to be deposited into the jackpot of an
+// app created with config, whose global state is state, for its guaranteed
+// prize to be at least min
+func JackpotTopUp(config algokeno.GameConfig, state map[string]string, min uint64) (uint64, error) {
+
+	key := fmt.Sprintf("%dg", config.Picks)
+	guaranteed, err := strconv.ParseUint(state[key], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("decoding %q: %w", key, err)
+	}
+
+	if guaranteed >= min {
+		return 0, nil
+	}
+	return min - guaranteed, nil
+}
+
+// Txns returns the group depositing amount from sender into tier of the app
+// appID, created with config: a payment to the app, or a transfer of its
+// asset if it has one, followed by the sponsor app call taking it as its
+// deposit arg, attributed with note
+func Txns(
+	config algokeno.GameConfig,
+	appID uint64,
+	sender types.Address,
+	tier int,
+	amount uint64,
+	note string,
+	params types.SuggestedParams,
+) ([]types.Transaction, error) {
+
+	if amount == 0 {
+		return nil, ErrNoDeposit
+	}
+
+	if tier < 1 || tier > config.Picks {
+		return nil, fmt.Errorf("%w: %d", model.ErrTierRange, tier)
+	}
+
+	appAddr := crypto.GetApplicationAddress(appID)
+	var deposit types.Transaction
+	var err error
+	if config.AssetID == 0 {
+		deposit, err = future.MakePaymentTxn(sender.String(), appAddr.String(), amount, nil, "", params)
+	} else {
+		deposit, err = future.MakeAssetTransferTxn(sender.String(), appAddr.String(), amount, nil, params, "", config.AssetID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	arg := make([]byte, 8)
+	binary.BigEndian.PutUint64(arg, uint64(tier))
+
+	call, err := future.MakeApplicationNoOpTx(
+		appID,
+		[][]byte{lotto.MethodSponsor.GetSelector(), arg},
+		nil,
+		nil,
+		nil,
+		params,
+		sender,
+		[]byte(note),
+		types.Digest{},
+		[32]byte{},
+		types.Address{},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return []types.Transaction{deposit, call}, nil
+}
+
+func (s *Sponsor) deposit(
+	ctx context.Context,
+	config algokeno.GameConfig,
+	appID uint64,
+	tier int,
+	amount uint64,
+	note string,
+) (string, error) {
+
+	params, err := s.cl.SuggestedParams(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	txns, err := Txns(config, appID, s.acc.Address, tier, amount, note, params)
+	if err != nil {
+		return "", err
+	}
+
+	var atc future.AtomicTransactionComposer
+	for _, txn := range txns {
+		err := atc.AddTransaction(future.TransactionWithSigner{
+			Txn: txn,
+			Signer: future.BasicAccountTransactionSigner{Account: s.acc},
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	res, err := atc.Execute(s.cl.Algod, ctx, waitRounds)
+	if err != nil {
+		return "", err
+	}
+	// The app call is last, after the deposit it takes
+	return res.TxIDs[len(res.TxIDs)-1], nil
+}
+
+func (s *Sponsor) globalState(ctx context.Context, appID uint64) (map[string]string, error) {
+
+	app, err := s.cl.Algod.GetApplicationByID(appID).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return model.DecodeState(app.Params.GlobalState)
+}
@@ -0,0 +1,72 @@
+package sponsor
+
+import (
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+func TestJackpotTopUp(t *testing.T) {
+
+	l := model.New(crypto.GenerateAccount().Address)
+	l.Guaranteed[5] = 3_000_000
+
+	amount, err := JackpotTopUp(l.Config, l.GlobalState(), 5_000_000)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2_000_000), amount)
+
+	amount, err = JackpotTopUp(l.Config, l.GlobalState(), 3_000_000)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), amount)
+
+	_, err = JackpotTopUp(l.Config, map[string]string{}, 3_000_000)
+	require.Error(t, err)
+}
+
+func TestTxns(t *testing.T) {
+
+	sender := crypto.GenerateAccount().Address
+	params := types.SuggestedParams{
+		Fee: 1000,
+		FlatFee: true,
+		FirstRoundValid: 1,
+		LastRoundValid: 1000,
+		GenesisHash: make([]byte, 32),
+	}
+
+	txns, err := Txns(algokeno.DefaultGameConfig, 86, sender, 6, 5_000_000, "jackpot seed", params)
+	require.NoError(t, err)
+	require.Len(t, txns, 2)
+
+	payment := txns[0]
+	require.Equal(t, types.PaymentTx, payment.Type)
+	require.Equal(t, crypto.GetApplicationAddress(86), payment.Receiver)
+	require.Equal(t, types.MicroAlgos(5_000_000), payment.Amount)
+
+	call := txns[1]
+	require.Equal(t, types.ApplicationCallTx, call.Type)
+	require.Equal(t, types.AppIndex(86), call.ApplicationID)
+	require.Equal(t, [][]byte{lotto.MethodSponsor.GetSelector(), {0, 0, 0, 0, 0, 0, 0, 6}}, call.ApplicationArgs)
+	require.Equal(t, []byte("jackpot seed"), call.Note)
+
+	// Apps with an asset are sponsored in it
+	asset := algokeno.DefaultGameConfig
+	asset.AssetID = 42
+	txns, err = Txns(asset, 86, sender, 1, 100, "", params)
+	require.NoError(t, err)
+	require.Equal(t, types.AssetTransferTx, txns[0].Type)
+	require.Equal(t, types.AssetIndex(42), txns[0].XferAsset)
+	require.Equal(t, uint64(100), txns[0].AssetAmount)
+
+	_, err = Txns(algokeno.DefaultGameConfig, 86, sender, 7, 100, "", params)
+	require.ErrorIs(t, err, model.ErrTierRange)
+
+	_, err = Txns(algokeno.DefaultGameConfig, 86, sender, 6, 0, "", params)
+	require.ErrorIs(t, err, ErrNoDeposit)
+}
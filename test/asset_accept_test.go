@@ -0,0 +1,164 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+// TestAssetLotto creates an ASA and plays a round of a lotto app which takes
+// wagers and pays out prizes in it, requiring the app to follow the model
+func TestAssetLotto(t *testing.T) {
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	player := fx.Account("player")
+
+	accounts := []crypto.Account{creator, player}
+	fundAccounts(t, fx, accounts...)
+	reclaimAtCleanup(t, accounts...)
+
+	assetID := createAsset(t, TxAssetCreate{
+		Creator: creator,
+		Total: 1_000_000_000,
+		UnitName: "KENO",
+		AssetName: "algokeno test",
+	})
+	broadcastTxsAndWait(t, TxAssetOptIn{AssetID: assetID, Sender: player})
+	broadcastTxsAndWait(t, TxAssetTransfer{
+		AssetID: assetID,
+		From: creator,
+		To: player.Address,
+		Amount: 10_000_000,
+	})
+
+	config := algokeno.DefaultGameConfig
+	config.AssetID = assetID
+
+	deployedAppIDs := deployLottos(t, fx, config, 2, creator)
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+	nextAppID := deployedAppIDs[1]
+	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+	l := model.NewWithConfig(creator.Address, config)
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	optInAsset := func(appID uint64) TxAppCall {
+		return TxAppCall{
+			AppID: appID,
+			Sender: creator,
+			Method: lotto.OptInAssetSignature,
+			Args: [][]byte{{0}},
+			ForeignAssets: []uint64{assetID},
+			FlatFee: types.MicroAlgos(2000),
+		}
+	}
+
+	// The apps need Algo for the min balance of holding the asset
+	for _, addr := range []types.Address{appAddr, nextAppAddr} {
+		broadcastTxsAndWait(t, TxPayment{
+			From: creator,
+			To: addr,
+			Amount: 300_000,
+		})
+	}
+
+	// Only the creator can opt the app in to its asset
+	requireTxBroadcastError(t, TxAppCall{
+		AppID: appID,
+		Sender: player,
+		Method: lotto.OptInAssetSignature,
+		Args: [][]byte{{0}},
+		ForeignAssets: []uint64{assetID},
+		FlatFee: types.MicroAlgos(2000),
+	})
+	require.ErrorIs(t, l.OptInAsset(player.Address), model.ErrNotCreator)
+
+	broadcastTxsAndWait(t, optInAsset(appID), optInAsset(nextAppID))
+	require.NoError(t, l.OptInAsset(creator.Address))
+
+	broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: player})
+	require.NoError(t, l.OptIn(player.Address))
+
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	commit := TxAppCall{
+		AppID: appID,
+		Sender: player,
+		Method: lotto.CommitSignature,
+		Args: [][]byte{model.BytesArg(draw)},
+	}
+
+	// Wagers can't be paid in Algo
+	requireTxBroadcastError(t, TxPayment{
+		From: player,
+		To: appAddr,
+		Amount: 1_000_000,
+	}, commit)
+
+	broadcastTxsAndWait(t, TxAssetTransfer{
+		AssetID: assetID,
+		From: player,
+		To: appAddr,
+		Amount: 1_000_000,
+	}, commit)
+	require.NoError(t, l.Commit(player.Address, draw, 1_000_000))
+	require.Equal(t, l.LocalState(player.Address), getAppLocalState(t, appID, player.Address))
+
+	tiers := make([]model.Tier, algokeno.NumPicks)
+	tiers[0] = model.Tier{Winners: 0, Prize: 200_000}
+	tiers[algokeno.NumPicks-1] = model.Tier{Winners: 1, Prize: 500_000}
+	expectedPayments, err := l.SetDraw(creator.Address, draw, tiers, nextAppAddr)
+	require.NoError(t, err)
+	require.Len(t, expectedPayments, 2, "fee and rollover above the threshold")
+	txIDs := broadcastTxsAndWait(t, TxAppCall{
+		AppID: appID,
+		Sender: creator,
+		Method: lotto.SetDrawAssetSignature,
+		Args: append(setDrawArgs(draw, tiers, nil, 0), []byte{0}),
+		ForeignApps: []uint64{
+			nextAppID,
+		},
+		ForeignAssets: []uint64{
+			assetID,
+		},
+		Accounts: []string{
+			nextAppAddr.String(),
+		},
+		FlatFee: setDrawFee(config),
+	})
+	require.Equal(t, expectedPayments, innerPayments(t, txIDs[0]))
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	expectedPayments, err = l.Claim(player.Address)
+	require.NoError(t, err)
+	txIDs = broadcastTxsAndWait(t, TxAppCall{
+		AppID: appID,
+		Sender: player,
+		Method: lotto.ClaimAssetSignature,
+		Args: [][]byte{{0}},
+		ForeignAssets: []uint64{
+			assetID,
+		},
+		FlatFee: types.MicroAlgos(2000),
+	})
+	require.Equal(t, expectedPayments, innerPayments(t, txIDs[0]))
+}
+
+// createAsset broadcasts an asset creation tx and returns the ID of the
+// asset it created
+func createAsset(t *testing.T, tx TxAssetCreate) uint64 {
+
+	txIDs := broadcastTxsAndWait(t, tx)
+
+	pendingRes, _, err := algodClient(t).PendingTransactionInformation(txIDs[0]).Do(context.Background())
+	require.NoError(t, err)
+	return pendingRes.AssetIndex
+}
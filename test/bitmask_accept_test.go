@@ -0,0 +1,121 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+// TestBitmaskCommitments commits byte and bitmask encoded tickets to the
+// same app, and requires both to be able to claim against a draw in either
+// encoding
+func TestBitmaskCommitments(t *testing.T) {
+
+	nums := []uint8{1, 2, 3, 4, 5, 63}
+	bytesTicket, err := algokeno.NewCommitment(nums...)
+	require.NoError(t, err)
+	bitmaskTicket, err := algokeno.NewBitmaskCommitment(nums...)
+	require.NoError(t, err)
+
+	for name, draw := range map[string]algokeno.Commitment{
+		"byte draw": bytesTicket,
+		"bitmask draw": bitmaskTicket,
+	} {
+		t.Run(name, func(t *testing.T) {
+
+			fx := newFixture(t)
+			creator := fx.Account("creator")
+			players := map[string]crypto.Account{
+				"bytes": fx.Account("bytes"),
+				"bitmask": fx.Account("bitmask"),
+				"loser": fx.Account("loser"),
+			}
+			tickets := map[string]algokeno.Commitment{
+				"bytes": bytesTicket,
+				"bitmask": bitmaskTicket,
+			}
+			tickets["loser"], err = algokeno.NewBitmaskCommitment(1, 2, 3, 4, 5, 62)
+			require.NoError(t, err)
+
+			deployedAppIDs := fundAccountsAndDeployContracts(
+				t,
+				fx,
+				2,
+				creator,
+				players["bytes"],
+				players["bitmask"],
+				players["loser"],
+			)
+			appID := deployedAppIDs[0]
+			appAddr := crypto.GetApplicationAddress(appID)
+			nextAppID := deployedAppIDs[1]
+			nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+			l := model.New(creator.Address)
+			for name, player := range players {
+				broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: player})
+				broadcastTxsAndWait(
+					t,
+					TxPayment{
+						From: player,
+						To: appAddr,
+						Amount: model.MinWager,
+					},
+					TxAppCall{
+						AppID: appID,
+						Sender: player,
+						Method: lotto.CommitSignature,
+						Args: [][]byte{model.BytesArg(tickets[name])},
+					},
+				)
+				require.NoError(t, l.OptIn(player.Address))
+				require.NoError(t, l.Commit(player.Address, tickets[name], model.MinWager))
+				require.Equal(t, l.LocalState(player.Address), getAppLocalState(t, appID, player.Address))
+			}
+
+			tiers := make([]model.Tier, algokeno.NumPicks)
+			tiers[algokeno.NumPicks-1] = model.Tier{Winners: 2, Prize: 500_000}
+			_, err := l.SetDraw(creator.Address, draw, tiers, nextAppAddr)
+			require.NoError(t, err)
+			broadcastTxsAndWait(t, TxAppCall{
+				AppID: appID,
+				Sender: creator,
+				Method: lotto.SetDrawSignature,
+				Args: setDrawArgs(draw, tiers, nil, 0),
+				ForeignApps: []uint64{
+					nextAppID,
+				},
+				Accounts: []string{
+					nextAppAddr.String(),
+				},
+				FlatFee: setDrawFee(algokeno.DefaultGameConfig),
+			})
+
+			claim := func(player crypto.Account) TxAppCall {
+				return TxAppCall{
+					AppID: appID,
+					Sender: player,
+					Method: lotto.ClaimSignature,
+					FlatFee: types.MicroAlgos(2000),
+				}
+			}
+
+			for _, name := range []string{"bytes", "bitmask"} {
+				expectedPayments, err := l.Claim(players[name].Address)
+				require.NoError(t, err)
+				txIDs := broadcastTxsAndWait(t, claim(players[name]))
+				require.Equal(t, expectedPayments, innerPayments(t, txIDs[0]))
+			}
+
+			_, err = l.Claim(players["loser"].Address)
+			require.ErrorIs(t, err, model.ErrNoWinnersLeft)
+			requireTxBroadcastError(t, claim(players["loser"]))
+		})
+	}
+}
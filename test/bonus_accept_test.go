@@ -0,0 +1,153 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+	"github.com/neurotempest/algokeno/settlement"
+)
+
+// TestBonusGame plays a round of a lotto app with a bonus ball, settling its
+// tiers and bonus tiers from the indexer, and requires the app to follow
+// the model
+func TestBonusGame(t *testing.T) {
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	jackpot := fx.Account("jackpot")
+	picksOnly := fx.Account("picksOnly")
+	bonusOnly := fx.Account("bonusOnly")
+
+	players := []crypto.Account{jackpot, picksOnly, bonusOnly}
+	accounts := append([]crypto.Account{creator}, players...)
+	fundAccounts(t, fx, accounts...)
+	reclaimAtCleanup(t, accounts...)
+
+	config := algokeno.DefaultGameConfig
+	config.Picks, config.MaxNumber, config.BonusMax = 5, 70, 26
+
+	// Bonus games have a pick less, so their bonus tiers fit in global state
+	invalid := config
+	invalid.Picks = algokeno.MaxPicks
+	require.ErrorIs(t, invalid.Validate(), algokeno.ErrConfigBonus)
+	requireTxBroadcastError(t, TxLottoDeploy{Creator: creator, Config: invalid})
+
+	deployedAppIDs := deployLottos(t, fx, config, 2, creator)
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+	nextAppID := deployedAppIDs[1]
+	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+	l := model.NewWithConfig(creator.Address, config)
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	commit := func(player crypto.Account, c algokeno.Commitment) []TxCreator {
+		return []TxCreator{
+			TxPayment{
+				From: player,
+				To: appAddr,
+				Amount: 1_000_000,
+			},
+			TxAppCall{
+				AppID: appID,
+				Sender: player,
+				Method: lotto.CommitSignature,
+				Args: [][]byte{model.BytesArg(c)},
+			},
+		}
+	}
+
+	picks := algokeno.Commitment{3, 14, 15, 41, 69}
+	draw := picks.WithBonus(25)
+	tickets := []algokeno.Commitment{
+		draw,
+		picks.WithBonus(0),
+		algokeno.Commitment{1, 2, 4, 5, 6}.WithBonus(25),
+	}
+
+	broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: jackpot})
+	require.NoError(t, l.OptIn(jackpot.Address))
+
+	// Tickets are their picks followed by a bonus number below BonusMax
+	requireTxBroadcastError(t, commit(jackpot, picks)...)
+	requireTxBroadcastError(t, commit(jackpot, picks.WithBonus(26))...)
+
+	for i, player := range players {
+		if i > 0 {
+			broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: player})
+			require.NoError(t, l.OptIn(player.Address))
+		}
+		broadcastTxsAndWait(t, commit(player, tickets[i])...)
+		require.NoError(t, l.Commit(player.Address, tickets[i], 1_000_000))
+	}
+
+	ctx := context.Background()
+	round := waitForIndexer(t)
+	s := settlement.New(indexerClient(t), appID)
+	settled, err := s.Tickets(ctx, round)
+	require.NoError(t, err)
+
+	tiers := settlement.Tiers(config, draw, settled, []uint64{0, 0, 0, 0, 500_000})
+	bonusTiers := settlement.BonusTiers(config, draw, settled, []uint64{100_000, 0, 0, 0, 0, 1_500_000})
+	require.Equal(t, model.Tier{Winners: 1, Prize: 500_000}, tiers[4])
+	require.Equal(t, model.Tier{Winners: 1, Prize: 100_000}, bonusTiers[0])
+	require.Equal(t, model.Tier{Winners: 1, Prize: 1_500_000}, bonusTiers[5])
+	require.NoError(t, settlement.CheckSolvency(config, l.Escrow, l.Sponsored, append(tiers, bonusTiers...)))
+
+	setDraw := func(args [][]byte) TxAppCall {
+		return TxAppCall{
+			AppID: appID,
+			Sender: creator,
+			Method: lotto.SetDrawSignature,
+			Args: args,
+			ForeignApps: []uint64{
+				nextAppID,
+			},
+			Accounts: []string{
+				nextAppAddr.String(),
+			},
+			FlatFee: setDrawFee(config),
+		}
+	}
+
+	// set_draw takes a bonus tier for each of 0 to config.Picks matching
+	// numbers plus the bonus
+	requireTxBroadcastError(t, setDraw(setDrawArgs(draw, tiers, nil, 0)))
+	requireTxBroadcastError(t, setDraw(setDrawArgs(draw, tiers, bonusTiers[1:], 0)))
+
+	expectedPayments, err := l.SetBonusDraw(creator.Address, draw, tiers, bonusTiers, nextAppAddr)
+	require.NoError(t, err)
+	txIDs := broadcastTxsAndWait(t, setDraw(setDrawArgs(draw, tiers, bonusTiers, 0)))
+	require.Equal(t, expectedPayments, innerPayments(t, txIDs[0]))
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	payouts := settlement.Payouts(config, draw, settled, tiers, bonusTiers)
+	for i, ticket := range settled {
+		var player crypto.Account
+		for _, p := range players {
+			if p.Address == ticket.Player {
+				player = p
+			}
+		}
+
+		expectedPayments, err := l.Claim(player.Address)
+		require.NoError(t, err)
+		require.Equal(t, []model.Payment{{To: player.Address, Amount: payouts[i]}}, expectedPayments)
+
+		txIDs := broadcastTxsAndWait(t, TxAppCall{
+			AppID: appID,
+			Sender: player,
+			Method: lotto.ClaimSignature,
+			FlatFee: types.MicroAlgos(2000),
+		})
+		require.Equal(t, expectedPayments, innerPayments(t, txIDs[0]))
+	}
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+}
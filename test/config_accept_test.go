@@ -0,0 +1,179 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+// TestGameConfigFromCreateArgs plays a round of a lotto app created with
+// non-default game parameters, and requires the app to follow the model
+// created with the same parameters
+func TestGameConfigFromCreateArgs(t *testing.T) {
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	winner := fx.Account("winner")
+	cheapskate := fx.Account("cheapskate")
+
+	accounts := []crypto.Account{creator, winner, cheapskate}
+	fundAccounts(t, fx, accounts...)
+	reclaimAtCleanup(t, accounts...)
+
+	config := algokeno.GameConfig{
+		Picks: 3,
+		MaxNumber: 10,
+		TicketPrice: 500_000,
+		HouseFeeBps: 250,
+		RolloverThreshold: 7,
+	}
+
+	// The contract rejects configs which fail validation
+	invalid := []algokeno.GameConfig{config, config, config}
+	invalid[0].Picks = algokeno.MaxPicks + 1
+	invalid[1].MaxNumber = config.Picks - 1
+	invalid[2].HouseFeeBps = algokeno.MaxHouseFeeBps + 1
+	for _, c := range invalid {
+		require.Error(t, c.Validate())
+		requireTxBroadcastError(t, TxAppDeploy{
+			Creator: creator,
+			ApprovalPath: lottoApp.ApprovalPath,
+			ClearPath: lottoApp.ClearPath,
+			SchemaPath: lottoApp.SchemaPath,
+			AppArgs: c.CreateArgs(),
+		})
+	}
+	requireTxBroadcastError(t, TxAppDeploy{
+		Creator: creator,
+		ApprovalPath: lottoApp.ApprovalPath,
+		ClearPath: lottoApp.ClearPath,
+		SchemaPath: lottoApp.SchemaPath,
+	})
+
+	deployedAppIDs := deployLottos(t, fx, config, 2, creator)
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+	nextAppID := deployedAppIDs[1]
+	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+	l := model.NewWithConfig(creator.Address, config)
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	commit := func(player crypto.Account, c algokeno.Commitment, amount uint64) []TxCreator {
+		return []TxCreator{
+			TxPayment{
+				From: player,
+				To: appAddr,
+				Amount: amount,
+			},
+			TxAppCall{
+				AppID: appID,
+				Sender: player,
+				Method: lotto.CommitSignature,
+				Args: [][]byte{model.BytesArg(c)},
+			},
+		}
+	}
+
+	for _, player := range []crypto.Account{winner, cheapskate} {
+		broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: player})
+		require.NoError(t, l.OptIn(player.Address))
+	}
+
+	// Tickets are config.Picks numbers below config.MaxNumber
+	requireTxBroadcastError(t, commit(winner, algokeno.Commitment{1, 2, 3, 4, 5, 6}, 500_000)...)
+	requireTxBroadcastError(t, commit(winner, algokeno.Commitment{1, 2, 10}, 500_000)...)
+
+	draw := algokeno.Commitment{1, 2, 9}
+	broadcastTxsAndWait(t, commit(winner, draw, 500_000)...)
+	require.NoError(t, l.Commit(winner.Address, draw, 500_000))
+	broadcastTxsAndWait(t, commit(cheapskate, draw, 499_999)...)
+	require.NoError(t, l.Commit(cheapskate.Address, draw, 499_999))
+
+	tiers := []model.Tier{
+		{Winners: 0, Prize: 3},
+		{Winners: 0, Prize: 5},
+		{Winners: 1, Prize: 400_000},
+	}
+	setDraw := func(tiers []model.Tier) TxAppCall {
+		return TxAppCall{
+			AppID: appID,
+			Sender: creator,
+			Method: lotto.SetDrawSignature,
+			Args: setDrawArgs(draw, tiers, nil, 0),
+			ForeignApps: []uint64{
+				nextAppID,
+			},
+			Accounts: []string{
+				nextAppAddr.String(),
+			},
+			FlatFee: setDrawFee(config),
+		}
+	}
+
+	// set_draw takes a tier for each of config.Picks
+	requireTxBroadcastError(t, setDraw(append(tiers, model.Tier{}, model.Tier{}, model.Tier{})))
+
+	expectedPayments, err := l.SetDraw(creator.Address, draw, tiers, nextAppAddr)
+	require.NoError(t, err)
+	require.Len(t, expectedPayments, 2, "fee and rollover above the threshold")
+	txIDs := broadcastTxsAndWait(t, setDraw(tiers))
+	require.Equal(t, expectedPayments, innerPayments(t, txIDs[0]))
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	// Tickets below config.TicketPrice can't claim
+	claim := func(player crypto.Account) TxAppCall {
+		return TxAppCall{
+			AppID: appID,
+			Sender: player,
+			Method: lotto.ClaimSignature,
+			FlatFee: types.MicroAlgos(2000),
+		}
+	}
+	requireTxBroadcastError(t, claim(cheapskate))
+	_, err = l.Claim(cheapskate.Address)
+	require.ErrorIs(t, err, model.ErrNoWager)
+
+	expectedPayments, err = l.Claim(winner.Address)
+	require.NoError(t, err)
+	txIDs = broadcastTxsAndWait(t, claim(winner))
+	require.Equal(t, expectedPayments, innerPayments(t, txIDs[0]))
+}
+
+// innerPayments returns the payments and asset transfers made by the inner
+// txns of txID
+func innerPayments(t *testing.T, txID string) []model.Payment {
+
+	pendingRes, _, err := algodClient(t).PendingTransactionInformation(txID).Do(context.Background())
+	require.NoError(t, err)
+
+	var payments []model.Payment
+	for _, inner := range pendingRes.InnerTxns {
+		txn := inner.Transaction.Txn
+		switch txn.Type {
+		case types.PaymentTx:
+			payments = append(payments, model.Payment{
+				To: txn.Receiver,
+				Amount: uint64(txn.Amount),
+			})
+		case types.AssetTransferTx:
+			payments = append(payments, model.Payment{
+				To: txn.AssetReceiver,
+				Amount: txn.AssetAmount,
+				AssetID: uint64(txn.XferAsset),
+			})
+		case types.ApplicationCallTx:
+			// set_draw's app calls for its opcode budget pay nothing
+		default:
+			require.Fail(t, "unexpected inner txn type", txn.Type)
+		}
+	}
+	return payments
+}
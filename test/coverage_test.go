@@ -0,0 +1,119 @@
+package test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/neurotempest/algokeno/coverage"
+)
+
+var (
+	tealCoverageOut = flag.String("teal_coverage", "", "Write a text TEAL coverage report of the contract to this path")
+	tealCoverageHTMLOut = flag.String("teal_coverage_html", "", "Write an HTML TEAL coverage report of the contract to this path")
+	tealCoverageSources = flag.String("teal_coverage_sources", "../contract/approval.teal,../contract/keno_approval.teal,../contract/clear.teal", "Comma separated TEAL sources to report coverage of")
+)
+
+// tealCoverage holds a coverage profile of each TEAL source when coverage
+// reporting is enabled. Every group broadcast by the tests is first
+// dryrun so that its trace can be recorded.
+var tealCoverage struct {
+	sync.Mutex
+	profiles []*coverage.Profile
+}
+
+func TestMain(m *testing.M) {
+
+	flag.Parse()
+
+	if tealCoverageEnabled() {
+		for _, path := range strings.Split(*tealCoverageSources, ",") {
+			src, err := coverage.Load(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "loading TEAL coverage source:", err)
+				os.Exit(1)
+			}
+			tealCoverage.profiles = append(tealCoverage.profiles, coverage.New(src))
+		}
+	}
+
+	code := m.Run()
+
+	if err := writeTealCoverage(); err != nil {
+		fmt.Fprintln(os.Stderr, "writing TEAL coverage:", err)
+		os.Exit(1)
+	}
+
+	os.Exit(code)
+}
+
+func tealCoverageEnabled() bool {
+
+	return *tealCoverageOut != "" || *tealCoverageHTMLOut != ""
+}
+
+// recordTealCoverage dryruns txs and adds the trace of each app call to the
+// profile of the TEAL source it ran
+func recordTealCoverage(t *testing.T, txs ...TxCreator) {
+
+	res := dryrunTxs(t, txs...)
+
+	tealCoverage.Lock()
+	defer tealCoverage.Unlock()
+
+	for _, txn := range res.Txns {
+		if len(txn.AppCallTrace) == 0 {
+			continue
+		}
+		for _, p := range tealCoverage.profiles {
+			if p.Record(txn) {
+				break
+			}
+		}
+	}
+}
+
+func writeTealCoverage() error {
+
+	if !tealCoverageEnabled() {
+		return nil
+	}
+
+	tealCoverage.Lock()
+	defer tealCoverage.Unlock()
+
+	if *tealCoverageOut != "" {
+		f, err := os.Create(*tealCoverageOut)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		for _, p := range tealCoverage.profiles {
+			if err := p.WriteText(f); err != nil {
+				return err
+			}
+			fmt.Fprintln(f)
+		}
+	}
+
+	if *tealCoverageHTMLOut != "" {
+		f, err := os.Create(*tealCoverageHTMLOut)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if err := coverage.WriteHTML(f, tealCoverage.profiles...); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range tealCoverage.profiles {
+		fmt.Printf("TEAL coverage: %s: %v\n", p.Source.Path, p.Summary())
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/events"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+// TestEvents requires the events logged by a round's commit, set_draw and
+// claim to match what the model did, both from algod and from the indexer
+func TestEvents(t *testing.T) {
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	player := fx.Account("player")
+
+	deployedAppIDs := fundAccountsAndDeployContracts(t, fx, 2, creator, player)
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+	nextAppID := deployedAppIDs[1]
+	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+	l := model.New(creator.Address)
+	ticket := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+
+	broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: player})
+	require.NoError(t, l.OptIn(player.Address))
+	commitTxIDs := broadcastTxsAndWait(
+		t,
+		TxPayment{
+			From: player,
+			To: appAddr,
+			Amount: model.MinWager,
+		},
+		TxAppCall{
+			AppID: appID,
+			Sender: player,
+			Method: lotto.CommitSignature,
+			Args: [][]byte{model.BytesArg(ticket)},
+		},
+	)
+	require.NoError(t, l.Commit(player.Address, ticket, model.MinWager))
+
+	// The ticket wins the jackpot, and tier 1's prize is rolled over as it
+	// has no winners
+	tiers := make([]model.Tier, algokeno.NumPicks)
+	tiers[0].Prize = 200_000
+	tiers[algokeno.NumPicks-1] = model.Tier{Winners: 1, Prize: 500_000}
+	setDrawPayments, err := l.SetDraw(creator.Address, ticket, tiers, nextAppAddr)
+	require.NoError(t, err)
+	setDrawTxIDs := broadcastTxsAndWait(t, TxAppCall{
+		AppID: appID,
+		Sender: creator,
+		Method: lotto.SetDrawSignature,
+		Args: setDrawArgs(ticket, tiers, nil, 0),
+		ForeignApps: []uint64{
+			nextAppID,
+		},
+		Accounts: []string{
+			nextAppAddr.String(),
+		},
+		FlatFee: setDrawFee(algokeno.DefaultGameConfig),
+	})
+
+	claimPayments, err := l.Claim(player.Address)
+	require.NoError(t, err)
+	claimTxIDs := broadcastTxsAndWait(t, TxAppCall{
+		AppID: appID,
+		Sender: player,
+		Method: lotto.ClaimSignature,
+		FlatFee: types.MicroAlgos(2000),
+	})
+
+	expected := []events.Logged{
+		{
+			Event: events.TicketPurchased{Player: player.Address, Wager: model.MinWager, Ticket: ticket},
+			TxID: commitTxIDs[1],
+		},
+		{
+			Event: events.DrawSet{HouseFee: setDrawPayments[0].Amount, Draw: ticket},
+			TxID: setDrawTxIDs[0],
+		},
+		{
+			Event: events.Rollover{NextApp: nextAppID, Amount: setDrawPayments[1].Amount},
+			TxID: setDrawTxIDs[0],
+		},
+		{
+			Event: events.PrizeClaimed{Player: player.Address, Picks: algokeno.NumPicks, Amount: claimPayments[0].Amount},
+			TxID: claimTxIDs[0],
+		},
+	}
+
+	var logged []events.Logged
+	for _, txID := range []string{commitTxIDs[1], setDrawTxIDs[0], claimTxIDs[0]} {
+		pendingRes, _, err := algodClient(t).PendingTransactionInformation(txID).Do(context.Background())
+		require.NoError(t, err)
+
+		evs, err := events.FromPending(pendingRes)
+		require.NoError(t, err)
+		for _, ev := range evs {
+			logged = append(logged, events.Logged{Event: ev, TxID: txID})
+		}
+	}
+	require.Equal(t, expected, logged)
+
+	// The indexer has the round each was confirmed in, and no events for
+	// the deposit and opt in
+	waitForIndexer(t)
+	res, err := indexerClient(t).SearchForTransactions().ApplicationId(appID).Do(context.Background())
+	require.NoError(t, err)
+
+	logged, err = events.FromTransactions(res.Transactions)
+	require.NoError(t, err)
+	require.Len(t, logged, len(expected))
+	for i := range logged {
+		require.NotZero(t, logged[i].Round)
+		logged[i].Round = 0
+	}
+	require.Equal(t, expected, logged)
+}
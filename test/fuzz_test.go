@@ -0,0 +1,256 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+// The fuzz targets in this file run generated args through the contract
+// using algod's dryrun endpoint, so they need the sandnet from `tilt up`:
+//
+//   go test -run=^$ -fuzz=FuzzCommit ./test
+//   go test -run=^$ -fuzz=FuzzSetDraw ./test
+
+// fuzzApp is an app deployed once per test binary which the fuzz targets
+// dryrun against. The player has opted in and committed a ticket, so the
+// escrow holds fuzzAppEscrow.
+var fuzzApp struct {
+	once sync.Once
+	creator crypto.Account
+	player crypto.Account
+	appID uint64
+	nextAppID uint64
+}
+
+const fuzzAppEscrow = 2_000_000
+
+func setupFuzzApp(t *testing.T) {
+
+	fuzzApp.once.Do(func() {
+
+		fx := newFixture(t)
+		creator := fx.Account("creator")
+		player := fx.Account("player")
+
+		// Funds aren't reclaimed as the app outlives the test which set it up
+		fundAccounts(t, fx, creator, player)
+		deployedAppIDs := deployContracts(t, fx, 2, creator)
+		require.Equal(t, 2, len(deployedAppIDs))
+
+		broadcastTxsAndWait(
+			t,
+			TxAppOptIn{
+				AppID: deployedAppIDs[0],
+				Sender: player,
+			},
+		)
+		broadcastTxsAndWait(
+			t,
+			TxPayment{
+				From: player,
+				To: crypto.GetApplicationAddress(deployedAppIDs[0]),
+				Amount: fuzzAppEscrow,
+			},
+			TxAppCall{
+				AppID: deployedAppIDs[0],
+				Sender: player,
+				Method: lotto.CommitSignature,
+				Args: [][]byte{
+					commitmentArg(t, 1, 2, 3, 4, 5, 6),
+				},
+			},
+		)
+
+		fuzzApp.creator = creator
+		fuzzApp.player = player
+		fuzzApp.appID = deployedAppIDs[0]
+		fuzzApp.nextAppID = deployedAppIDs[1]
+	})
+
+	if fuzzApp.appID == 0 {
+		t.Fatal("fuzz app setup failed")
+	}
+}
+
+// FuzzCommit requires that the contract accepts exactly the commitments
+// which the Go Commitment validator accepts, or which are sealed
+func FuzzCommit(f *testing.F) {
+
+	f.Add([]byte{1, 2, 3, 4, 5, 6})
+	f.Add([]byte{0, 10, 20, 30, 40, 63})
+	f.Add([]byte{3, 1, 2, 4, 5, 6})
+	f.Add([]byte{1, 2, 3, 4, 8, 8})
+	f.Add([]byte{1, 2, 3, 4, 8})
+	f.Add([]byte{1, 2, 3, 4, 10, 64})
+	f.Add([]byte{1, 2, 3, 4, 5, 6, 7})
+	f.Add([]byte{})
+	f.Add(model.JoinArgs([][]byte{model.BytesArg(algokeno.Commitment{1, 2, 3, 4, 5, 6})}))
+	f.Add(model.JoinArgs(model.SetDrawArgs(
+		algokeno.Commitment{1, 2, 3, 4, 5, 6},
+		[]model.Tier{
+			5: {Winners: 1, Prize: 500000},
+		},
+		nil,
+	)[:model.NumSetDrawArgs-1]))
+	f.Add([]byte{algokeno.BitmaskVersion, 0, 0, 0, 0, 0, 0, 0, 0x7e})
+	f.Add([]byte{algokeno.BitmaskVersion, 0, 0, 0, 0, 0, 0, 0, 0x3e})
+	f.Add([]byte{2, 0, 0, 0, 0, 0, 0, 0, 0x7e})
+	f.Add([]byte(algokeno.Seal(algokeno.Commitment{1, 2, 3, 4, 5, 6}, []byte("salt"))))
+
+	f.Fuzz(func(t *testing.T, commitment []byte) {
+
+		setupFuzzApp(t)
+
+		res := dryrunTxs(
+			t,
+			TxPayment{
+				From: fuzzApp.player,
+				To: crypto.GetApplicationAddress(fuzzApp.appID),
+				Amount: 1_000_000,
+			},
+			TxAppCall{
+				AppID: fuzzApp.appID,
+				Sender: fuzzApp.player,
+				Method: lotto.CommitSignature,
+				Args: [][]byte{
+					model.BytesArg(commitment),
+				},
+			},
+		)
+
+		c := algokeno.Commitment(commitment)
+		err := c.Validate()
+		require.Equal(t, err == nil || c.IsSealed(), dryrunPassed(res), "commitment %v: validator returned %v", commitment, err)
+	})
+}
+
+// FuzzSetDraw runs arbitrary set_draw arg vectors through the contract. It
+// requires that the contract accepts the same args as the model, pays out
+// what the model does, and never pays out more than its escrow.
+func FuzzSetDraw(f *testing.F) {
+
+	f.Add(model.JoinArgs(model.SetDrawArgs(
+		algokeno.Commitment{1, 2, 3, 4, 5, 6},
+		[]model.Tier{
+			{Winners: 0, Prize: 10001},
+			{Winners: 0, Prize: 10002},
+			{Winners: 0, Prize: 10003},
+			{Winners: 0, Prize: 10004},
+			{Winners: 0, Prize: 10005},
+			{Winners: 1, Prize: 500000},
+		},
+		nil,
+	)))
+	f.Add(model.JoinArgs(model.SetDrawArgs(
+		algokeno.Commitment{1, 2, 3, 4, 5, 6},
+		[]model.Tier{
+			5: {Winners: 0, Prize: fuzzAppEscrow},
+		},
+		nil,
+	)))
+	f.Add([]byte{})
+	f.Add(model.JoinArgs([][]byte{model.BytesArg(algokeno.Commitment{1, 2, 3, 4, 5, 6})}))
+	f.Add(model.JoinArgs(model.SetDrawArgs(
+		algokeno.Commitment{1, 2, 3, 4, 5, 6},
+		[]model.Tier{
+			5: {Winners: 1, Prize: 500000},
+		},
+		nil,
+	)[:model.NumSetDrawArgs-1]))
+	f.Add([]byte{8, 0, 6, 1, 2, 3, 4, 5, 6, 9, 0, 1, 1, 1, 1, 1, 1, 1, 1, 2, 0, 0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+
+		setupFuzzApp(t)
+
+		// The references follow the fuzzed args at fixed positions, so
+		// extra args would be read as references and only the set_draw
+		// args are sent. Vectors missing args are sent as they are, with
+		// the references shifted into the missing args' places.
+		args := model.SplitArgs(data)
+		if len(args) > model.NumSetDrawArgs {
+			args = args[:model.NumSetDrawArgs]
+		}
+		nextAppAddr := crypto.GetApplicationAddress(fuzzApp.nextAppID)
+
+		res := dryrunTxs(
+			t,
+			TxAppCall{
+				AppID: fuzzApp.appID,
+				Sender: fuzzApp.creator,
+				Method: lotto.SetDrawSignature,
+				Args: append(args, setDrawArgs(nil, nil, nil, 0)[model.NumSetDrawArgs:]...),
+				ForeignApps: []uint64{
+					fuzzApp.nextAppID,
+				},
+				Accounts: []string{
+					nextAppAddr.String(),
+				},
+				FlatFee: setDrawFee(algokeno.DefaultGameConfig),
+			},
+		)
+		passed := dryrunPassed(res)
+
+		appInfo, err := algodClient(t).AccountInformation(crypto.GetApplicationAddress(fuzzApp.appID).String()).Do(context.Background())
+		require.NoError(t, err)
+
+		draw, tiers, _, err := model.ParseSetDrawArgs(args, algokeno.NumPicks, false)
+		if err != nil {
+			require.False(t, passed, "args %v: model rejected with %v but contract accepted", args, err)
+			return
+		}
+
+		l := model.New(fuzzApp.creator.Address)
+		l.Escrow = appInfo.Amount
+		payments, err := l.SetDraw(fuzzApp.creator.Address, draw, tiers, nextAppAddr)
+
+		// Dryrun does not apply the ledger's min balance check, so the
+		// contract may be approved where the model (and the ledger) would
+		// reject it for leaving the escrow below its min balance
+		if !passed {
+			require.Error(t, err, "args %v: model accepted but contract rejected", args)
+			return
+		}
+		if err != nil {
+			require.ErrorIs(t, err, model.ErrBelowMinBalance, "args %v: model rejected but contract accepted", args)
+			return
+		}
+
+		paid := dryrunPaid(res.Txns[0])
+		require.LessOrEqual(t, paid, appInfo.Amount, "args %v: pays more than escrow", args)
+
+		var modelPaid uint64
+		for _, p := range payments {
+			modelPaid += p.Amount
+		}
+		require.Equal(t, modelPaid, paid, "args %v: contract pays a different amount to the model", args)
+	})
+}
+
+// dryrunPaid sums the amounts of the inner payments and asset transfers an
+// app call submitted. Dryrun doesn't return inner txns, but each executed
+// itxn_field Amount or AssetAmount has the amount on top of the stack.
+func dryrunPaid(res models.DryrunTxnResult) uint64 {
+
+	var paid uint64
+	for _, state := range res.AppCallTrace {
+		if state.Line >= uint64(len(res.Disassembly)) || len(state.Stack) == 0 {
+			continue
+		}
+		fields := strings.Fields(res.Disassembly[state.Line])
+		if len(fields) == 2 && fields[0] == "itxn_field" && (fields[1] == "Amount" || fields[1] == "AssetAmount") {
+			paid += state.Stack[len(state.Stack)-1].Uint
+		}
+	}
+	return paid
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/history"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+// TestHistoryFollower indexes the creator's apps into a history store by
+// following blocks, and requires it to have the ticket bought and the draw
+func TestHistoryFollower(t *testing.T) {
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	player := fx.Account("player")
+
+	deployedAppIDs := fundAccountsAndDeployContracts(t, fx, 2, creator, player)
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+	nextAppID := deployedAppIDs[1]
+	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+	ticket := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: player})
+	broadcastTxsAndWait(
+		t,
+		TxPayment{
+			From: player,
+			To: appAddr,
+			Amount: model.MinWager,
+		},
+		TxAppCall{
+			AppID: appID,
+			Sender: player,
+			Method: lotto.CommitSignature,
+			Args: [][]byte{model.BytesArg(ticket)},
+		},
+	)
+
+	tiers := make([]model.Tier, algokeno.NumPicks)
+	tiers[algokeno.NumPicks-1] = model.Tier{Winners: 1, Prize: 500_000}
+	broadcastTxsAndWait(t, TxAppCall{
+		AppID: appID,
+		Sender: creator,
+		Method: lotto.SetDrawSignature,
+		Args: setDrawArgs(ticket, tiers, nil, 0),
+		ForeignApps: []uint64{
+			nextAppID,
+		},
+		Accounts: []string{
+			nextAppAddr.String(),
+		},
+		FlatFee: setDrawFee(algokeno.DefaultGameConfig),
+	})
+
+	store, err := history.Open(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	follower := history.NewFollower(testClients(t), store)
+	follower.Creators = []types.Address{creator.Address}
+	require.NoError(t, follower.Sync(ctx))
+	require.Subset(t, store.AppIDs(), []uint64{appID, nextAppID})
+
+	round, ok := store.Round(appID)
+	require.True(t, ok)
+	require.Equal(t, creator.Address.String(), round.Creator)
+	require.Equal(t, uint64(1), round.NumTickets)
+	require.NotNil(t, round.Draw)
+	require.Equal(t, []int{1, 2, 3, 4, 5, 6}, round.Draw.Numbers)
+
+	winners, ok := store.Winners(appID)
+	require.True(t, ok)
+	require.Len(t, winners, 1)
+	require.Equal(t, player.Address.String(), winners[0].Player)
+	require.Equal(t, algokeno.NumPicks, winners[0].Matches)
+	require.Equal(t, winners[0].Ticket, store.PlayerTickets(player.Address)[0])
+}
@@ -0,0 +1,183 @@
+package test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno/keno"
+)
+
+// TestKenoPaytableRows plays tickets of 1, 4 and 10 spots against a keno app
+// and requires each claim to pay the wager multiplied by its paytable entry
+func TestKenoPaytableRows(t *testing.T) {
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	players := map[string]crypto.Account{}
+	for _, name := range []string{"oneSpot", "fourOfFour", "threeOfFour", "twoOfFour", "zeroOfTen", "loser"} {
+		players[name] = fx.Account(name)
+	}
+
+	accounts := []crypto.Account{creator}
+	for _, acc := range players {
+		accounts = append(accounts, acc)
+	}
+	fundAccounts(t, fx, accounts...)
+	reclaimAtCleanup(t, accounts...)
+
+	deployedAppIDs := deployApps(t, fx, kenoApp, 1, creator)
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+
+	paytable := keno.Paytable{
+		1: {0, 3},
+		4: {0, 0, 1, 2, 4},
+		10: {2, 0, 0, 0, 0, 1, 2, 3, 4, 5, 6},
+	}
+	require.NoError(t, paytable.Validate())
+
+	// Only the creator can set the paytable
+	args, err := paytable.SetPaytableArgs(1)
+	require.NoError(t, err)
+	requireTxBroadcastError(t, TxAppCall{
+		AppID: appID,
+		Sender: players["oneSpot"],
+		Method: "SetPaytable",
+		Args: args,
+	})
+
+	for _, spots := range paytable.Spots() {
+		args, err := paytable.SetPaytableArgs(spots)
+		require.NoError(t, err)
+		broadcastTxsAndWait(t, TxAppCall{
+			AppID: appID,
+			Sender: creator,
+			Method: "SetPaytable",
+			Args: args,
+		})
+	}
+
+	global := getAppGlobalState(t, appID)
+	for _, spots := range paytable.Spots() {
+		row, err := paytable.Row(spots)
+		require.NoError(t, err)
+		require.Equal(t, base64.StdEncoding.EncodeToString(row), global[keno.RowKey(spots)])
+	}
+
+	// The house funds the escrow to cover payouts
+	broadcastTxsAndWait(t, TxPayment{
+		From: creator,
+		To: appAddr,
+		Amount: 10_000_000,
+	})
+
+	picks := map[string]keno.Picks{
+		"oneSpot": {7},
+		"fourOfFour": {1, 2, 3, 80},
+		"threeOfFour": {5, 6, 7, 8},
+		"twoOfFour": {1, 2, 40, 41},
+		"zeroOfTen": {8, 9, 10, 11, 12, 13, 14, 15, 16, 17},
+		"loser": {8},
+	}
+
+	commit := func(player crypto.Account, p keno.Picks) []TxCreator {
+		return []TxCreator{
+			TxAppCall{
+				AppID: appID,
+				Sender: player,
+				Method: "Commit",
+				Args: [][]byte{p},
+			},
+			TxPayment{
+				From: player,
+				To: appAddr,
+				Amount: 1_000_000,
+			},
+		}
+	}
+
+	for name, player := range players {
+		broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: player})
+		broadcastTxsAndWait(t, commit(player, picks[name])...)
+		require.Equal(
+			t,
+			map[string]string{"wager": "1000000", "picks": picks[name].String()},
+			getAppLocalState(t, appID, player.Address),
+		)
+	}
+
+	// Picks without a paytable row, or invalid picks, are rejected
+	requireTxBroadcastError(t, commit(players["loser"], keno.Picks{1, 2})...)
+	requireTxBroadcastError(t, commit(players["loser"], keno.Picks{1, 2, 3, 81})...)
+	requireTxBroadcastError(t, commit(players["loser"], keno.Picks{1, 2, 4, 3})...)
+
+	// And the paytable can't change once tickets are bought
+	requireTxBroadcastError(t, TxAppCall{
+		AppID: appID,
+		Sender: creator,
+		Method: "SetPaytable",
+		Args: args,
+	})
+
+	draw, err := keno.NewDraw(1, 2, 3, 4, 5, 6, 7, 61, 62, 63, 64, 65, 66, 67, 68, 69, 70, 71, 72, 80)
+	require.NoError(t, err)
+	requireTxBroadcastError(t, TxAppCall{
+		AppID: appID,
+		Sender: creator,
+		Method: "SetDraw",
+		Args: [][]byte{draw[:keno.NumDrawn-1]},
+	})
+	broadcastTxsAndWait(t, TxAppCall{
+		AppID: appID,
+		Sender: creator,
+		Method: "SetDraw",
+		Args: [][]byte{draw},
+	})
+	require.Equal(t, draw.String(), getAppGlobalState(t, appID)["draw"])
+
+	// No more tickets once the draw is set
+	requireTxBroadcastError(t, commit(players["loser"], keno.Picks{7})...)
+
+	claim := TxAppCall{
+		AppID: appID,
+		Method: "Claim",
+		FlatFee: types.MicroAlgos(2000),
+	}
+
+	for _, name := range []string{"oneSpot", "fourOfFour", "threeOfFour", "twoOfFour", "zeroOfTen"} {
+		t.Run(name, func(t *testing.T) {
+			expected, err := paytable.Payout(picks[name], draw, 1_000_000)
+			require.NoError(t, err)
+			require.Greater(t, expected, uint64(0))
+
+			claim.Sender = players[name]
+			txIDs := broadcastTxsAndWait(t, claim)
+
+			pendingRes, _, err := algodClient(t).PendingTransactionInformation(txIDs[0]).Do(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, len(pendingRes.InnerTxns))
+			inner := pendingRes.InnerTxns[0].Transaction.Txn
+			require.Equal(t, types.PaymentTx, inner.Type)
+			require.Equal(t, appAddr, inner.Sender)
+			require.Equal(t, players[name].Address, inner.Receiver)
+			require.Equal(t, expected, uint64(inner.Amount))
+
+			// Tickets can only be claimed once
+			require.Equal(
+				t,
+				map[string]string{"wager": "0", "picks": ""},
+				getAppLocalState(t, appID, players[name].Address),
+			)
+			requireTxBroadcastError(t, claim)
+		})
+	}
+
+	// Tickets whose paytable entry is 0 can't claim
+	claim.Sender = players["loser"]
+	requireTxBroadcastError(t, claim)
+}
@@ -0,0 +1,191 @@
+package test
+
+import (
+	"context"
+	"flag"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+)
+
+var (
+	modelSeed = flag.Int64("model_seed", 0, "Seed for the random op sequence of TestContractMatchesModel (defaults to current time)")
+	modelNumOps = flag.Int("model_num_ops", 40, "Number of random ops run by TestContractMatchesModel")
+)
+
+// TestContractMatchesModel drives a random sequence of operations against
+// both a deployed app and the Go model, and requires that they agree on
+// which operations succeed, the resulting app state and the payments made by
+// the app. Rerun a failure with -model_seed set to the seed logged below.
+func TestContractMatchesModel(t *testing.T) {
+
+	seed := *modelSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t.Logf("model seed: %d", seed)
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	players := []crypto.Account{
+		fx.Account("player1"),
+		fx.Account("player2"),
+		fx.Account("player3"),
+	}
+
+	deployedAppIDs := fundAccountsAndDeployContracts(t, fx, 2, creator, players...)
+	require.Equal(t, 2, len(deployedAppIDs))
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+	nextAppID := deployedAppIDs[1]
+	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+	accounts := map[types.Address]crypto.Account{
+		creator.Address: creator,
+	}
+	var playerAddrs []types.Address
+	for _, p := range players {
+		accounts[p.Address] = p
+		playerAddrs = append(playerAddrs, p.Address)
+	}
+
+	g := model.OpGenerator{
+		Rand: rand.New(rand.NewSource(seed)),
+		Creator: creator.Address,
+		Players: playerAddrs,
+		Config: algokeno.DefaultGameConfig,
+		MaxWager: 2_000_000,
+	}
+
+	l := model.New(creator.Address)
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	for i, op := range g.Ops(*modelNumOps) {
+
+		// The op's group is confirmed in the next round at the earliest,
+		// which is all the reveal window depends on
+		status, err := algodClient(t).Status().Do(context.Background())
+		require.NoError(t, err)
+		l.Round = status.LastRound + 1
+
+		expectedPayments, modelErr := l.Apply(op, nextAppAddr)
+
+		var txs []TxCreator
+		switch op.Type {
+		case model.OpOptIn:
+			txs = append(txs, TxAppOptIn{
+				AppID: appID,
+				Sender: accounts[op.Sender],
+			})
+		case model.OpCommit:
+			txs = append(
+				txs,
+				TxPayment{
+					From: accounts[op.Sender],
+					To: appAddr,
+					Amount: op.Amount,
+				},
+				TxAppCall{
+					AppID: appID,
+					Sender: accounts[op.Sender],
+					Method: lotto.CommitSignature,
+					Args: [][]byte{
+						model.BytesArg(op.Commitment),
+					},
+				},
+			)
+		case model.OpSetDraw:
+			txs = append(txs, TxAppCall{
+				AppID: appID,
+				Sender: accounts[op.Sender],
+				Method: lotto.SetDrawSignature,
+				Args: setDrawArgs(op.Commitment, op.Tiers, nil, 0),
+				ForeignApps: []uint64{
+					nextAppID,
+				},
+				Accounts: []string{
+					nextAppAddr.String(),
+				},
+				FlatFee: setDrawFee(algokeno.DefaultGameConfig),
+			})
+		case model.OpClaim:
+			txs = append(txs, TxAppCall{
+				AppID: appID,
+				Sender: accounts[op.Sender],
+				Method: lotto.ClaimSignature,
+				FlatFee: types.MicroAlgos(2000),
+			})
+		case model.OpReveal:
+			txs = append(txs, TxAppCall{
+				AppID: appID,
+				Sender: accounts[op.Sender],
+				Method: lotto.RevealSignature,
+				Args: [][]byte{
+					model.BytesArg(op.Commitment),
+					model.BytesArg(op.Salt),
+				},
+			})
+		case model.OpSponsor:
+			txs = append(
+				txs,
+				TxPayment{
+					From: accounts[op.Sender],
+					To: appAddr,
+					Amount: op.Amount,
+				},
+				TxAppCall{
+					AppID: appID,
+					Sender: accounts[op.Sender],
+					Method: lotto.SponsorSignature,
+					Args: [][]byte{
+						uint64ToBytes(t, uint64(op.Tier)),
+					},
+				},
+			)
+		case model.OpPublishDraw:
+			txs = append(txs, TxAppCall{
+				AppID: appID,
+				Sender: accounts[op.Sender],
+				Method: lotto.PublishDrawSignature,
+				Args: [][]byte{
+					model.BytesArg(op.Commitment),
+				},
+			})
+		}
+
+		txIDs, chainErr := broadcastTxs(t, txs...)
+		if modelErr != nil {
+			require.Error(t, chainErr, "op %d %v: model rejected with %v but chain accepted", i, op, modelErr)
+			continue
+		}
+		require.NoError(t, chainErr, "op %d %v: model accepted but chain rejected", i, op)
+
+		// The app call is last, after any deposit it takes
+		pendingRes, _, err := algodClient(t).PendingTransactionInformation(txIDs[len(txIDs)-1]).Do(context.Background())
+		require.NoError(t, err)
+		if op.Type == model.OpPublishDraw {
+			// The model can only guess the round the window starts in
+			l.RevealBy = pendingRes.ConfirmedRound + algokeno.RevealRounds
+		}
+		require.Equal(t, expectedPayments, innerPayments(t, txIDs[len(txIDs)-1]), "op %d %v: payments differ", i, op)
+
+		require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID), "op %d %v: global state differs", i, op)
+		for _, addr := range playerAddrs {
+			if expected := l.LocalState(addr); expected != nil {
+				require.Equal(t, expected, getAppLocalState(t, appID, addr), "op %d %v: local state of %v differs", i, op, addr)
+			}
+		}
+
+		appInfo, err := algodClient(t).AccountInformation(appAddr.String()).Do(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, l.Escrow, appInfo.Amount, "op %d %v: escrow balance differs", i, op)
+	}
+}
@@ -0,0 +1,229 @@
+package test
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+	"github.com/neurotempest/algokeno/profile"
+)
+
+var (
+	costBaselinePath = flag.String("cost_baseline", "testdata/cost_baseline.json", "Path to the opcode cost baseline")
+	updateCostBaseline = flag.Bool("update_cost_baseline", false, "Overwrite the opcode cost baseline with the current profile")
+	costThreshold = flag.Float64("cost_threshold", 0.1, "Fraction by which an op's opcode cost may grow before it is a regression")
+)
+
+// TestProfileContractOps dryruns each contract operation to measure its
+// opcode cost, inner transaction count and minimum fee, and fails if any of
+// them regressed compared to the baseline. After an intended change run:
+//
+//   go test ./test -run TestProfileContractOps -update_cost_baseline
+func TestProfileContractOps(t *testing.T) {
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	acc1 := fx.Account("acc1")
+	acc2 := fx.Account("acc2")
+	acc3 := fx.Account("acc3")
+
+	deployedAppIDs := fundAccountsAndDeployContracts(t, fx, 2, creator, acc1, acc2, acc3)
+	require.Equal(t, 2, len(deployedAppIDs))
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+	nextAppID := deployedAppIDs[1]
+	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+	bitmask, err := algokeno.NewBitmaskCommitment(1, 2, 3, 4, 5, 6)
+	require.NoError(t, err)
+
+	commit := func(acc crypto.Account, c []byte, fee types.MicroAlgos) []TxCreator {
+		return []TxCreator{
+			TxPayment{
+				From: acc,
+				To: appAddr,
+				Amount: 1_000_000,
+			},
+			TxAppCall{
+				AppID: appID,
+				Sender: acc,
+				Method: lotto.CommitSignature,
+				Args: [][]byte{
+					model.BytesArg(c),
+				},
+				FlatFee: fee,
+			},
+		}
+	}
+
+	setDraw := func(fee types.MicroAlgos, smallPrize uint64) []TxCreator {
+		return []TxCreator{
+			TxAppCall{
+				AppID: appID,
+				Sender: creator,
+				Method: lotto.SetDrawSignature,
+				Args: setDrawArgs(
+					commitmentToBytes(t, 1, 2, 3, 4, 5, 6),
+					tiersOf(
+						0, smallPrize, // 1s
+						0, smallPrize, // 2s
+						0, smallPrize, // 3s
+						0, smallPrize, // 4s
+						0, smallPrize, // 5s
+						1, 500_000, // 6s
+					),
+					nil,
+					0,
+				),
+				ForeignApps: []uint64{
+					nextAppID,
+				},
+				Accounts: []string{
+					nextAppAddr.String(),
+				},
+				FlatFee: fee,
+			},
+		}
+	}
+
+	claim := func(acc crypto.Account, fee types.MicroAlgos) []TxCreator {
+		return []TxCreator{
+			TxAppCall{
+				AppID: appID,
+				Sender: acc,
+				Method: lotto.ClaimSignature,
+				FlatFee: fee,
+			},
+		}
+	}
+
+	var report profile.Report
+
+	report.Add(profileOp(t, "OptIn", func(types.MicroAlgos) []TxCreator {
+		return []TxCreator{
+			TxAppOptIn{
+				AppID: appID,
+				Sender: acc1,
+			},
+		}
+	}))
+
+	for _, acc := range []crypto.Account{acc1, acc2, acc3} {
+		broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: acc})
+	}
+
+	report.Add(profileOp(t, "Commit", func(fee types.MicroAlgos) []TxCreator {
+		return commit(acc1, commitmentToBytes(t, 1, 2, 3, 4, 5, 6), fee)
+	}))
+	report.Add(profileOp(t, "Commit/bitmask", func(fee types.MicroAlgos) []TxCreator {
+		return commit(acc1, bitmask, fee)
+	}))
+
+	broadcastTxsAndWait(t, commit(acc1, commitmentToBytes(t, 1, 2, 3, 4, 5, 6), 0)...)
+	broadcastTxsAndWait(t, commit(acc2, bitmask, 0)...)
+	broadcastTxsAndWait(t, commit(acc3, commitmentToBytes(t, 1, 2, 3, 4, 5, 6), 0)...)
+
+	report.Add(profileOp(t, "SetDraw", func(fee types.MicroAlgos) []TxCreator {
+		return setDraw(fee, 10_000)
+	}))
+	report.Add(profileOp(t, "SetDraw/rollover", func(fee types.MicroAlgos) []TxCreator {
+		return setDraw(fee, 50_000)
+	}))
+
+	broadcastTxsAndWait(t, setDraw(setDrawFee(algokeno.DefaultGameConfig), 10_000)...)
+
+	report.Add(profileOp(t, "Claim", func(fee types.MicroAlgos) []TxCreator {
+		return claim(acc1, fee)
+	}))
+	report.Add(profileOp(t, "Claim/bitmask", func(fee types.MicroAlgos) []TxCreator {
+		return claim(acc2, fee)
+	}))
+
+	var table strings.Builder
+	require.NoError(t, report.WriteTable(&table))
+	t.Logf("contract op profile:\n%s", table.String())
+
+	if *updateCostBaseline {
+		require.NoError(t, report.Save(*costBaselinePath))
+		return
+	}
+
+	baseline, err := profile.Load(*costBaselinePath)
+	if os.IsNotExist(err) {
+		t.Fatalf("no cost baseline at %s, run with -update_cost_baseline to create it", *costBaselinePath)
+	}
+	require.NoError(t, err)
+
+	require.Empty(t, profile.Compare(baseline, report, *costThreshold))
+}
+
+// profileOp dryruns the group built by txs to measure the opcode cost and
+// inner transactions of its last txn (the app call, after any deposit it
+// takes), then finds the lowest multiple of the min fee for which that txn
+// still succeeds
+func profileOp(t *testing.T, name string, txs func(fee types.MicroAlgos) []TxCreator) profile.Op {
+
+	txParams := suggestedParams(t)
+	minFee := uint64(txParams.MinFee)
+
+	const maxFeeMultiple = 16
+
+	res := dryrunTxs(t, txs(types.MicroAlgos(maxFeeMultiple*minFee))...)
+	call := res.Txns[len(res.Txns)-1]
+	require.True(t, dryrunPassed(res), "%s: dryrun rejected: %v", name, call.AppCallMessages)
+
+	op := profile.Op{
+		Name: name,
+		Cost: call.Cost,
+	}
+	op.InnerTxns, op.InnerAppCalls = dryrunInnerTxns(call)
+
+	for k := uint64(1); k <= maxFeeMultiple; k++ {
+		if dryrunPassed(dryrunTxs(t, txs(types.MicroAlgos(k*minFee))...)) {
+			op.MinFee = k * minFee
+			break
+		}
+	}
+	require.NotZero(t, op.MinFee, "%s: no fee up to %d succeeded", name, maxFeeMultiple*minFee)
+
+	// Inner txns are paid for by pooling the outer txn's fee. That includes
+	// the inner app calls raising the opcode budget on purpose: the contract
+	// sets their fee to 0 and requires the outer fee to cover them, so
+	// needing another one is a fee regression callers have to pay for
+	if pooled := (1 + op.InnerTxns) * minFee; pooled > op.MinFee {
+		op.MinFee = pooled
+	}
+	return op
+}
+
+// dryrunInnerTxns counts the inner transactions an app call submitted by
+// counting the executed itxn_begin and itxn_next opcodes in its trace, and
+// which of them were app calls by the appl type set on them
+func dryrunInnerTxns(res models.DryrunTxnResult) (txns, appCalls uint64) {
+
+	var prev string
+	for _, state := range res.AppCallTrace {
+		if state.Line >= uint64(len(res.Disassembly)) {
+			continue
+		}
+		line := res.Disassembly[state.Line]
+		fields := strings.Fields(line)
+		if len(fields) > 0 && (fields[0] == "itxn_begin" || fields[0] == "itxn_next") {
+			txns++
+		}
+		if len(fields) > 1 && fields[0] == "itxn_field" && fields[1] == "TypeEnum" && strings.Contains(prev, "appl") {
+			appCalls++
+		}
+		prev = line
+	}
+	return txns, appCalls
+}
@@ -0,0 +1,193 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+	"github.com/neurotempest/algokeno/sealed"
+	"github.com/neurotempest/algokeno/settlement"
+)
+
+// TestSealedTicket commits a sealed ticket, saving its salt to a store, and
+// requires it to only be revealed once the draw is published. It's revealed
+// as a loser, so the tiers are set with just the winner's ticket and the
+// whole prize is paid out, leaving nothing in the escrow for sealed tickets.
+func TestSealedTicket(t *testing.T) {
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	player := fx.Account("player")
+	winner := fx.Account("winner")
+
+	deployedAppIDs := fundAccountsAndDeployContracts(t, fx, 2, creator, player, winner)
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+	nextAppID := deployedAppIDs[1]
+	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+	store, err := sealed.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	ticket, err := sealed.New(appID, player.Address, algokeno.Commitment{7, 8, 9, 10, 11, 12})
+	require.NoError(t, err)
+	require.NoError(t, store.Save(ticket))
+
+	l := model.New(creator.Address)
+	commit := func(acc crypto.Account, c algokeno.Commitment) []TxCreator {
+
+		return []TxCreator{
+			TxPayment{
+				From: acc,
+				To: appAddr,
+				Amount: model.MinWager,
+			},
+			TxAppCall{
+				AppID: appID,
+				Sender: acc,
+				Method: lotto.CommitSignature,
+				Args: [][]byte{model.BytesArg(c)},
+			},
+		}
+	}
+	for _, acc := range []crypto.Account{player, winner} {
+		broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: acc})
+		require.NoError(t, l.OptIn(acc.Address))
+	}
+	broadcastTxsAndWait(t, commit(player, ticket.Commitment())...)
+	require.NoError(t, l.Commit(player.Address, ticket.Commitment(), model.MinWager))
+	broadcastTxsAndWait(t, commit(winner, draw)...)
+	require.NoError(t, l.Commit(winner.Address, draw, model.MinWager))
+	require.Equal(t, l.LocalState(player.Address), getAppLocalState(t, appID, player.Address))
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	// Reload the ticket as a player would after the draw
+	ticket, err = store.Load(appID, player.Address, ticket.Commitment())
+	require.NoError(t, err)
+
+	reveal := TxAppCall{
+		AppID: appID,
+		Sender: player,
+		Method: lotto.RevealSignature,
+		Args: ticket.RevealArgs(),
+	}
+
+	// Tickets can't be revealed before the draw is published, which it has
+	// to be before it's set while there are sealed tickets
+	requireTxBroadcastError(t, reveal)
+	require.ErrorIs(t, l.Reveal(player.Address, ticket.Numbers, ticket.Salt), model.ErrNoDraw)
+
+	// The escrow is left with its min balance once the prize is paid
+	tiers := make([]model.Tier, algokeno.NumPicks)
+	tiers[algokeno.NumPicks-1] = model.Tier{Winners: 1, Prize: 2*model.MinWager - 200_000 - model.MinBalance}
+	setDraw := TxAppCall{
+		AppID: appID,
+		Sender: creator,
+		Method: lotto.SetDrawSignature,
+		Args: setDrawArgs(draw, tiers, nil, 0),
+		ForeignApps: []uint64{
+			nextAppID,
+		},
+		Accounts: []string{
+			nextAppAddr.String(),
+		},
+		FlatFee: setDrawFee(algokeno.DefaultGameConfig),
+	}
+	requireTxBroadcastError(t, setDraw)
+	_, err = l.SetDraw(creator.Address, draw, tiers, nextAppAddr)
+	require.ErrorIs(t, err, model.ErrNotPublished)
+
+	txIDs := broadcastTxsAndWait(t, TxAppCall{
+		AppID: appID,
+		Sender: creator,
+		Method: lotto.PublishDrawSignature,
+		Args: [][]byte{model.BytesArg(draw)},
+	})
+	pendingRes, _, err := algodClient(t).PendingTransactionInformation(txIDs[0]).Do(context.Background())
+	require.NoError(t, err)
+	l.Round = pendingRes.ConfirmedRound
+	require.NoError(t, l.PublishDraw(creator.Address, draw))
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	// Once the draw is known tickets can't be bought, or a sealed ticket
+	// could hide numbers picked to win
+	late, err := sealed.New(appID, player.Address, draw)
+	require.NoError(t, err)
+	requireTxBroadcastError(t, commit(player, late.Commitment())...)
+	require.ErrorIs(t, l.Commit(player.Address, late.Commitment(), model.MinWager), model.ErrDrawSet)
+
+	claim := func(acc crypto.Account) TxAppCall {
+
+		return TxAppCall{
+			AppID: appID,
+			Sender: acc,
+			Method: lotto.ClaimSignature,
+			FlatFee: types.MicroAlgos(2000),
+		}
+	}
+
+	// Sealed tickets can't claim
+	requireTxBroadcastError(t, claim(player))
+	_, err = l.Claim(player.Address)
+	require.ErrorIs(t, err, model.ErrSealed)
+
+	// Nor can they be revealed with the wrong salt
+	requireTxBroadcastError(t, TxAppCall{
+		AppID: appID,
+		Sender: player,
+		Method: lotto.RevealSignature,
+		Args: [][]byte{model.BytesArg(ticket.Numbers), model.BytesArg(make([]byte, sealed.SaltLength))},
+	})
+
+	// The tiers wait for the sealed ticket to be revealed
+	l.Round = pendingRes.ConfirmedRound + 1
+	requireTxBroadcastError(t, setDraw)
+	_, err = l.SetDraw(creator.Address, draw, tiers, nextAppAddr)
+	require.ErrorIs(t, err, model.ErrRevealWindow)
+
+	broadcastTxsAndWait(t, reveal)
+	require.NoError(t, l.Reveal(player.Address, ticket.Numbers, ticket.Salt))
+	require.Equal(t, l.LocalState(player.Address), getAppLocalState(t, appID, player.Address))
+	require.NoError(t, store.Remove(ticket))
+
+	// Once revealed, the ticket can't be revealed again
+	requireTxBroadcastError(t, reveal)
+
+	// With every ticket revealed the tiers only count the winner, and the
+	// draw is set without waiting for the reveal window to close
+	tickets := []settlement.Ticket{
+		{Player: player.Address, Commitment: ticket.Numbers, Wager: model.MinWager},
+		{Player: winner.Address, Commitment: draw, Wager: model.MinWager},
+	}
+	require.Zero(t, settlement.Unrevealed(tickets))
+	require.Equal(t, tiers, settlement.Tiers(algokeno.DefaultGameConfig, draw, tickets, []uint64{0, 0, 0, 0, 0, tiers[algokeno.NumPicks-1].Prize}))
+	require.NoError(t, settlement.CheckSolvency(algokeno.DefaultGameConfig, l.Escrow, l.Sponsored, tiers))
+
+	_, err = l.SetDraw(creator.Address, draw, tiers, nextAppAddr)
+	require.NoError(t, err)
+	broadcastTxsAndWait(t, setDraw)
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	// The revealed ticket lost, and the winner is paid the whole prize
+	requireTxBroadcastError(t, claim(player))
+	_, err = l.Claim(player.Address)
+	require.ErrorIs(t, err, model.ErrNotWinner)
+
+	expectedPayments, err := l.Claim(winner.Address)
+	require.NoError(t, err)
+	txIDs = broadcastTxsAndWait(t, claim(winner))
+	require.Equal(t, expectedPayments, innerPayments(t, txIDs[0]))
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	appInfo, err := algodClient(t).AccountInformation(appAddr.String()).Do(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(model.MinBalance), appInfo.Amount)
+	require.Equal(t, l.Escrow, appInfo.Amount)
+}
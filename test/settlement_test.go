@@ -0,0 +1,172 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+	"github.com/neurotempest/algokeno/settlement"
+)
+
+// TestSettleFromIndexer reads the tickets committed to an app back from the
+// indexer straight after the last commit, and sets the draw with the winners
+// counted from them, for an app taking wagers in Algo and one taking them in
+// an ASA
+func TestSettleFromIndexer(t *testing.T) {
+
+	testCases := []struct{
+		Name string
+		Asset bool
+	}{
+		{
+			Name: "algo",
+		},
+		{
+			Name: "asset",
+			Asset: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			fx := newFixture(t)
+			creator := fx.Account("creator")
+			players := []crypto.Account{
+				fx.Account("player1"),
+				fx.Account("player2"),
+				fx.Account("player3"),
+			}
+
+			accounts := append([]crypto.Account{creator}, players...)
+			fundAccounts(t, fx, accounts...)
+			reclaimAtCleanup(t, accounts...)
+
+			config := algokeno.DefaultGameConfig
+			if test.Asset {
+				config.AssetID = createAsset(t, TxAssetCreate{
+					Creator: creator,
+					Total: 1_000_000_000,
+					UnitName: "KENO",
+					AssetName: "algokeno test",
+				})
+				for _, player := range players {
+					broadcastTxsAndWait(t, TxAssetOptIn{AssetID: config.AssetID, Sender: player})
+					broadcastTxsAndWait(t, TxAssetTransfer{
+						AssetID: config.AssetID,
+						From: creator,
+						To: player.Address,
+						Amount: config.TicketPrice,
+					})
+				}
+			}
+
+			deployedAppIDs := deployLottos(t, fx, config, 2, creator)
+			require.Equal(t, 2, len(deployedAppIDs))
+			appID := deployedAppIDs[0]
+			appAddr := crypto.GetApplicationAddress(appID)
+			nextAppID := deployedAppIDs[1]
+			nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+			if test.Asset {
+				// The apps need Algo for the min balance of holding the asset
+				for _, id := range []uint64{appID, nextAppID} {
+					broadcastTxsAndWait(t, TxPayment{
+						From: creator,
+						To: crypto.GetApplicationAddress(id),
+						Amount: 300_000,
+					})
+					broadcastTxsAndWait(t, TxAppCall{
+						AppID: id,
+						Sender: creator,
+						Method: lotto.OptInAssetSignature,
+						Args: [][]byte{{0}},
+						ForeignAssets: []uint64{config.AssetID},
+						FlatFee: types.MicroAlgos(2000),
+					})
+				}
+			}
+
+			commitments := []algokeno.Commitment{
+				{1, 2, 3, 4, 5, 6},
+				{1, 2, 3, 7, 8, 9},
+				{1, 2, 10, 11, 12, 13},
+			}
+			for i, player := range players {
+				var wager TxCreator = TxPayment{
+					From: player,
+					To: appAddr,
+					Amount: config.TicketPrice,
+				}
+				if test.Asset {
+					wager = TxAssetTransfer{
+						AssetID: config.AssetID,
+						From: player,
+						To: appAddr,
+						Amount: config.TicketPrice,
+					}
+				}
+
+				broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: player})
+				broadcastTxsAndWait(
+					t,
+					wager,
+					TxAppCall{
+						AppID: appID,
+						Sender: player,
+						Method: lotto.CommitSignature,
+						Args: [][]byte{model.BytesArg(commitments[i])},
+					},
+				)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			s := settlement.New(indexerClient(t), appID)
+			tickets, err := s.Tickets(ctx, waitForIndexer(t))
+			require.NoError(t, err)
+			require.Len(t, tickets, len(players))
+			for i, ticket := range tickets {
+				require.Equal(t, players[i].Address, ticket.Player)
+				require.Equal(t, commitments[i], ticket.Commitment)
+				require.Equal(t, config.TicketPrice, ticket.Wager)
+			}
+
+			draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+			tiers := settlement.Tiers(config, draw, tickets, []uint64{0, 1000, 2000, 3000, 4000, 5000})
+			setDraw := TxAppCall{
+				AppID: appID,
+				Sender: creator,
+				Method: lotto.SetDrawSignature,
+				Args: setDrawArgs(draw, tiers, nil, 0),
+				ForeignApps: []uint64{
+					nextAppID,
+				},
+				Accounts: []string{
+					nextAppAddr.String(),
+				},
+				FlatFee: setDrawFee(config),
+			}
+			if test.Asset {
+				setDraw.Method = lotto.SetDrawAssetSignature
+				setDraw.Args = append(setDraw.Args, []byte{0})
+				setDraw.ForeignAssets = []uint64{config.AssetID}
+			}
+			broadcastTxsAndWait(t, setDraw)
+
+			global := getAppGlobalState(t, appID)
+			require.Equal(t, "1", global["2s"])
+			require.Equal(t, "1", global["3s"])
+			require.Equal(t, "0", global["4s"])
+			require.Equal(t, "1", global["6s"])
+		})
+	}
+}
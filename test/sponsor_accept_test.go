@@ -0,0 +1,199 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+	"github.com/neurotempest/algokeno/settlement"
+	"github.com/neurotempest/algokeno/sponsor"
+)
+
+// TestSponsor seeds an app's jackpot and sponsors a tier through the
+// sponsor package, and requires set_draw to honour the guarantees the
+// settlement engine reads back from the indexer
+func TestSponsor(t *testing.T) {
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	player := fx.Account("player")
+	backer := fx.Account("backer")
+
+	deployedAppIDs := fundAccountsAndDeployContracts(t, fx, 2, creator, player, backer)
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+	nextAppID := deployedAppIDs[1]
+	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+	ctx := context.Background()
+	l := model.New(creator.Address)
+
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: player})
+	require.NoError(t, l.OptIn(player.Address))
+	broadcastTxsAndWait(
+		t,
+		TxPayment{
+			From: player,
+			To: appAddr,
+			Amount: 1_000_000,
+		},
+		TxAppCall{
+			AppID: appID,
+			Sender: player,
+			Method: lotto.CommitSignature,
+			Args: [][]byte{model.BytesArg(algokeno.Commitment{1, 2, 3, 4, 5, 7})},
+		},
+	)
+	require.NoError(t, l.Commit(player.Address, algokeno.Commitment{1, 2, 3, 4, 5, 7}, 1_000_000))
+
+	// Seeding tops the jackpot up to the minimum, and no further
+	seeder := sponsor.New(testClients(t), creator)
+	deposited, err := seeder.SeedJackpot(ctx, appID, 2_000_000, "house seed")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2_000_000), deposited)
+	require.NoError(t, l.Sponsor(creator.Address, algokeno.NumPicks, deposited))
+
+	deposited, err = seeder.SeedJackpot(ctx, appID, 1_500_000, "house seed")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), deposited)
+
+	_, err = sponsor.New(testClients(t), backer).Deposit(ctx, appID, 5, 300_000, "backer")
+	require.NoError(t, err)
+	require.NoError(t, l.Sponsor(backer.Address, 5, 300_000))
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	// Only tiers 1 to picks can be sponsored
+	requireTxBroadcastError(
+		t,
+		TxPayment{
+			From: backer,
+			To: appAddr,
+			Amount: 300_000,
+		},
+		TxAppCall{
+			AppID: appID,
+			Sender: backer,
+			Method: lotto.SponsorSignature,
+			Args: [][]byte{uint64ToBytes(t, algokeno.NumPicks+1)},
+		},
+	)
+
+	round := waitForIndexer(t)
+	s := settlement.New(indexerClient(t), appID)
+	sponsorships, err := s.Sponsorships(ctx, round)
+	require.NoError(t, err)
+	require.Len(t, sponsorships, 2)
+	require.Equal(t, []byte("house seed"), sponsorships[0].Note)
+	require.Equal(t, backer.Address, sponsorships[1].Sponsor)
+
+	tickets, err := s.Tickets(ctx, round)
+	require.NoError(t, err)
+
+	guaranteed := settlement.Guaranteed(algokeno.DefaultGameConfig, sponsorships)
+	require.Equal(t, l.Guaranteed, guaranteed)
+
+	setDraw := func(tiers []model.Tier) TxAppCall {
+		return TxAppCall{
+			AppID: appID,
+			Sender: creator,
+			Method: lotto.SetDrawSignature,
+			Args: setDrawArgs(draw, tiers, nil, 0),
+			ForeignApps: []uint64{
+				nextAppID,
+			},
+			Accounts: []string{
+				nextAppAddr.String(),
+			},
+			FlatFee: setDrawFee(algokeno.DefaultGameConfig),
+		}
+	}
+
+	// Prizes below their guarantee are rejected
+	prizes := []uint64{0, 0, 0, 0, 100_000, 100_000}
+	below := settlement.Tiers(algokeno.DefaultGameConfig, draw, tickets, prizes)
+	requireTxBroadcastError(t, setDraw(below))
+	_, err = l.SetDraw(creator.Address, draw, below, nextAppAddr)
+	require.ErrorIs(t, err, model.ErrBelowGuarantee)
+
+	tiers := settlement.Tiers(
+		algokeno.DefaultGameConfig,
+		draw,
+		tickets,
+		settlement.HonourGuarantees(prizes, guaranteed),
+	)
+	require.NoError(t, settlement.CheckSolvency(algokeno.DefaultGameConfig, l.Escrow, l.Sponsored, tiers))
+
+	expectedPayments, err := l.SetDraw(creator.Address, draw, tiers, nextAppAddr)
+	require.NoError(t, err)
+	txIDs := broadcastTxsAndWait(t, setDraw(tiers))
+	require.Equal(t, expectedPayments, innerPayments(t, txIDs[0]))
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	// Once the draw is set, tiers can't be sponsored
+	_, err = sponsor.New(testClients(t), backer).Deposit(ctx, appID, 5, 300_000, "backer")
+	require.Error(t, err)
+	require.ErrorIs(t, l.Sponsor(backer.Address, 5, 300_000), model.ErrDrawSet)
+}
+
+// TestSponsorNoTickets seeds an app's jackpot and sets the draw without any
+// tickets having been bought, and requires the whole seed to roll over
+// since no house fee is taken from sponsors' deposits
+func TestSponsorNoTickets(t *testing.T) {
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+
+	deployedAppIDs := fundAccountsAndDeployContracts(t, fx, 2, creator)
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+	nextAppID := deployedAppIDs[1]
+	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+	ctx := context.Background()
+	l := model.New(creator.Address)
+
+	deposited, err := sponsor.New(testClients(t), creator).SeedJackpot(ctx, appID, 2_000_000, "house seed")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2_000_000), deposited)
+	require.NoError(t, l.Sponsor(creator.Address, algokeno.NumPicks, deposited))
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	tiers := settlement.Tiers(
+		algokeno.DefaultGameConfig,
+		draw,
+		nil,
+		settlement.HonourGuarantees(make([]uint64, algokeno.NumPicks), l.Guaranteed),
+	)
+	require.NoError(t, settlement.CheckSolvency(algokeno.DefaultGameConfig, l.Escrow, l.Sponsored, tiers))
+
+	expectedPayments, err := l.SetDraw(creator.Address, draw, tiers, nextAppAddr)
+	require.NoError(t, err)
+	require.Equal(t, model.Payment{To: nextAppAddr, Amount: 2_000_000}, expectedPayments[len(expectedPayments)-1])
+
+	txIDs := broadcastTxsAndWait(t, TxAppCall{
+		AppID: appID,
+		Sender: creator,
+		Method: lotto.SetDrawSignature,
+		Args: setDrawArgs(draw, tiers, nil, 0),
+		ForeignApps: []uint64{
+			nextAppID,
+		},
+		Accounts: []string{
+			nextAppAddr.String(),
+		},
+		FlatFee: setDrawFee(algokeno.DefaultGameConfig),
+	})
+	require.Equal(t, expectedPayments, innerPayments(t, txIDs[0]))
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	appInfo, err := algodClient(t).AccountInformation(appAddr.String()).Do(ctx)
+	require.NoError(t, err)
+	require.Equal(t, l.Escrow, appInfo.Amount)
+}
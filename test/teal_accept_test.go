@@ -10,16 +10,30 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"strconv"
+	"strings"
+	"path/filepath"
+	"time"
+	"sync"
+	"sync/atomic"
 
-	"github.com/algorand/go-algorand-sdk/client/kmd"
+	"github.com/algorand/go-algorand-sdk/abi"
 	"github.com/algorand/go-algorand-sdk/client/v2/algod"
 	"github.com/algorand/go-algorand-sdk/client/v2/indexer"
 	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
 	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/encoding/msgpack"
 	"github.com/algorand/go-algorand-sdk/future"
 	"github.com/algorand/go-algorand-sdk/types"
 	"github.com/stretchr/testify/require"
 
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/client"
+	"github.com/neurotempest/algokeno/dispenser"
+	"github.com/neurotempest/algokeno/fixture"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+	"github.com/neurotempest/algokeno/settlement"
+
 	"encoding/hex"
 )
 
@@ -38,6 +52,8 @@ var (
 	kmdHost = flag.String("kmd_host", "http://localhost:4002", "Host of kmd client")
 	kmdTokenPath = flag.String("kmd_token_path", "../algorand/kmd.token", "Path to kmd token")
 	indexerHost = flag.String("indexer_host", "http://localhost:4003", "Host of indexer client")
+	paramsTTL = flag.Duration("params_ttl", client.DefaultParamsTTL, "How long suggested params are cached for (negative to disable)")
+	fundAmount = flag.Uint64("fund_amount", 20_000_000, "Microalgos the dispenser funds each test account with")
 )
 
 func TestSomeTestSome(t *testing.T) {
@@ -80,28 +96,19 @@ type InnerTx struct {
 
 func TestContractWithSingleWinningTicket(t *testing.T) {
 
-	creator := crypto.GenerateAccount()
-	acc1 := crypto.GenerateAccount()
-	acc2 := crypto.GenerateAccount()
-	acc3 := crypto.GenerateAccount()
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	acc1 := fx.Account("acc1")
+	acc2 := fx.Account("acc2")
+	acc3 := fx.Account("acc3")
 
-	deployedAppIDs := fundAccountsAndDeployContracts(t, 2, creator, acc1, acc2, acc3)
+	deployedAppIDs := fundAccountsAndDeployContracts(t, fx, 2, creator, acc1, acc2, acc3)
 	require.Equal(t, 2, len(deployedAppIDs))
 	appID := deployedAppIDs[0]
 	appAddr := crypto.GetApplicationAddress(appID)
 	nextAppID := deployedAppIDs[1]
 	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
 
-	fmt.Println("deployed apps:")
-	fmt.Println(appID, appAddr)
-	fmt.Println(nextAppID, nextAppAddr)
-
-
-	fmt.Println("Creator:")
-	fmt.Println("addr:", creator.Address.String())
-	fmt.Println("pub key:", base64.StdEncoding.EncodeToString(creator.PublicKey))
-	fmt.Println("priv key:", base64.StdEncoding.EncodeToString(creator.PrivateKey))
-
 	testCases := []struct{
 		Name string
 		Txs []TxCreator
@@ -114,18 +121,27 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 			Name: "inital global state",
 			ExpectedGlobalState: map[string]string{
 				"numTickets": "0",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
 				"draw": "",
 				"1p": "0",
+				"1g": "0",
 				"1s": "0",
 				"2p": "0",
+				"2g": "0",
 				"2s": "0",
 				"3p": "0",
+				"3g": "0",
 				"3s": "0",
 				"4p": "0",
+				"4g": "0",
 				"4s": "0",
 				"5p": "0",
+				"5g": "0",
 				"5s": "0",
 				"6p": "0",
+				"6g": "0",
 				"6s": "0",
 			},
 		},
@@ -161,18 +177,27 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 			},
 			ExpectedGlobalState: map[string]string{
 				"numTickets": "0",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
 				"draw": "",
 				"1p": "0",
+				"1g": "0",
 				"1s": "0",
 				"2p": "0",
+				"2g": "0",
 				"2s": "0",
 				"3p": "0",
+				"3g": "0",
 				"3s": "0",
 				"4p": "0",
+				"4g": "0",
 				"4s": "0",
 				"5p": "0",
+				"5g": "0",
 				"5s": "0",
 				"6p": "0",
+				"6g": "0",
 				"6s": "0",
 			},
 		},
@@ -182,9 +207,9 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 				TxAppCall{
 					AppID: appID,
 					Sender: acc1,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 1, 2, 3, 4, 5, 6),
+						commitmentArg(t, 1, 2, 3, 4, 5, 6),
 					},
 				},
 			},
@@ -193,19 +218,19 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 		{
 			Name: "calling commit from acc1 with payment tx succeeds",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc1,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc1,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 1, 2, 3, 4, 5, 6),
+						commitmentArg(t, 1, 2, 3, 4, 5, 6),
 					},
 				},
-				TxPayment{
-					From: acc1,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectedLocalState: map[types.Address]map[string]string{
 				acc1.Address: {
@@ -215,189 +240,236 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 			},
 			ExpectedGlobalState: map[string]string{
 				"numTickets": "1",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
 				"draw": "",
 				"1p": "0",
+				"1g": "0",
 				"1s": "0",
 				"2p": "0",
+				"2g": "0",
 				"2s": "0",
 				"3p": "0",
+				"3g": "0",
 				"3s": "0",
 				"4p": "0",
+				"4g": "0",
 				"4s": "0",
 				"5p": "0",
+				"5g": "0",
 				"5s": "0",
 				"6p": "0",
+				"6g": "0",
 				"6s": "0",
 			},
 		},
 		{
 			Name: "calling commit with commitment which is not ordered in 2nd byte fails",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc2,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 3, 1, 2, 4, 5, 6),
+						commitmentArg(t, 3, 1, 2, 4, 5, 6),
 					},
 				},
-				TxPayment{
-					From: acc2,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectTxBroadcastError: true,
 		},
 		{
 			Name: "calling commit with commitment which is not ordered in 3rd byte fails",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc2,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 1, 3, 2, 4, 5, 6),
+						commitmentArg(t, 1, 3, 2, 4, 5, 6),
 					},
 				},
-				TxPayment{
-					From: acc2,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectTxBroadcastError: true,
 		},
 		{
 			Name: "calling commit with commitment which is not ordered in 4th byte fails",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc2,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 1, 2, 4, 3, 5, 6),
+						commitmentArg(t, 1, 2, 4, 3, 5, 6),
 					},
 				},
-				TxPayment{
-					From: acc2,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectTxBroadcastError: true,
 		},
 		{
 			Name: "calling commit with commitment which is not ordered in 5th byte fails",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc2,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 1, 2, 3, 5, 4, 6),
+						commitmentArg(t, 1, 2, 3, 5, 4, 6),
 					},
 				},
-				TxPayment{
-					From: acc2,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectTxBroadcastError: true,
 		},
 		{
 			Name: "calling commit with commitment which is not ordered in 6th byte fails",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc2,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 1, 2, 3, 4, 8, 6),
+						commitmentArg(t, 1, 2, 3, 4, 8, 6),
 					},
 				},
-				TxPayment{
-					From: acc2,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectTxBroadcastError: true,
 		},
 		{
 			Name: "calling commit with commitment with duplicate numbers fails",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc2,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 1, 2, 3, 4, 8, 8),
+						commitmentArg(t, 1, 2, 3, 4, 8, 8),
 					},
 				},
-				TxPayment{
-					From: acc2,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectTxBroadcastError: true,
 		},
 		{
 			Name: "calling commit with commitment shorter than 6 numbers fails",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc2,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 1, 2, 3, 4, 8),
+						commitmentArg(t, 1, 2, 3, 4, 8),
 					},
 				},
-				TxPayment{
-					From: acc2,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectTxBroadcastError: true,
 		},
 		{
 			Name: "calling commit with number greater than 63 fails",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc2,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 1, 2, 3, 4, 10, 64),
+						commitmentArg(t, 1, 2, 3, 4, 10, 64),
 					},
 				},
+			},
+			ExpectTxBroadcastError: true,
+		},
+		{
+			Name: "calling commit with commitment longer than 6 numbers fails",
+			Txs: []TxCreator{
 				TxPayment{
 					From: acc2,
 					To: appAddr,
 					Amount: 1000000,
 				},
+				TxAppCall{
+					AppID: appID,
+					Sender: acc2,
+					Method: lotto.CommitSignature,
+					Args: [][]byte{
+						commitmentArg(t, 1, 2, 3, 4, 5, 6, 7),
+					},
+				},
 			},
 			ExpectTxBroadcastError: true,
 		},
 		{
-			Name: "calling commit from acc2 with payment tx succeeds",
+			Name: "calling commit with empty commitment fails",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc2,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 10, 11, 12, 13, 14, 15),
+						{},
 					},
 				},
+			},
+			ExpectTxBroadcastError: true,
+		},
+		{
+			Name: "calling commit from acc2 with payment tx succeeds",
+			Txs: []TxCreator{
 				TxPayment{
 					From: acc2,
 					To: appAddr,
 					Amount: 1000000,
 				},
+				TxAppCall{
+					AppID: appID,
+					Sender: acc2,
+					Method: lotto.CommitSignature,
+					Args: [][]byte{
+						commitmentArg(t, 10, 11, 12, 13, 14, 15),
+					},
+				},
 			},
 			ExpectedLocalState: map[types.Address]map[string]string{
 				acc2.Address: {
@@ -407,18 +479,27 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 			},
 			ExpectedGlobalState: map[string]string{
 				"numTickets": "2",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
 				"draw": "",
 				"1p": "0",
+				"1g": "0",
 				"1s": "0",
 				"2p": "0",
+				"2g": "0",
 				"2s": "0",
 				"3p": "0",
+				"3g": "0",
 				"3s": "0",
 				"4p": "0",
+				"4g": "0",
 				"4s": "0",
 				"5p": "0",
+				"5g": "0",
 				"5s": "0",
 				"6p": "0",
+				"6g": "0",
 				"6s": "0",
 			},
 		},
@@ -428,22 +509,20 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 				TxAppCall{
 					AppID: appID,
 					Sender: acc1,
-					Method: "SetDraw",
-					Args: [][]byte{
+					Method: lotto.SetDrawSignature,
+					Args: setDrawArgs(
 						commitmentToBytes(t, 1, 2, 3, 4, 5, 6),
-						uint64ToBytes(t, 6), // 1s
-						uint64ToBytes(t, 61),
-						uint64ToBytes(t, 5), // 2s
-						uint64ToBytes(t, 51),
-						uint64ToBytes(t, 4), // 3s
-						uint64ToBytes(t, 41),
-						uint64ToBytes(t, 3), // 4s
-						uint64ToBytes(t, 31),
-						uint64ToBytes(t, 2), // 5s
-						uint64ToBytes(t, 21),
-						uint64ToBytes(t, 1), // 6s
-						uint64ToBytes(t, 500000),
-					},
+						tiersOf(
+							6, 61, // 1s
+							5, 51, // 2s
+							4, 41, // 3s
+							3, 31, // 4s
+							2, 21, // 5s
+							1, 500000, // 6s
+						),
+						nil,
+						0,
+					),
 				},
 			},
 			ExpectTxBroadcastError: true,
@@ -454,46 +533,53 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 				TxAppCall{
 					AppID: appID,
 					Sender: creator,
-					Method: "SetDraw",
-					Args: [][]byte{
+					Method: lotto.SetDrawSignature,
+					Args: setDrawArgs(
 						commitmentToBytes(t, 1, 2, 3, 4, 5, 6),
-						uint64ToBytes(t, 0), // 1s
-						uint64ToBytes(t, 10001),
-						uint64ToBytes(t, 0), // 2s
-						uint64ToBytes(t, 10002),
-						uint64ToBytes(t, 0), // 3s
-						uint64ToBytes(t, 10003),
-						uint64ToBytes(t, 0), // 4s
-						uint64ToBytes(t, 10004),
-						uint64ToBytes(t, 0), // 5s
-						uint64ToBytes(t, 10005),
-						uint64ToBytes(t, 1), // 6s
-						uint64ToBytes(t, 500000),
-					},
+						tiersOf(
+							0, 10001, // 1s
+							0, 10002, // 2s
+							0, 10003, // 3s
+							0, 10004, // 4s
+							0, 10005, // 5s
+							1, 500000, // 6s
+						),
+						nil,
+						0,
+					),
 					ForeignApps: []uint64{
 						nextAppID,
 					},
 					Accounts: []string{
 						nextAppAddr.String(),
 					},
-					FlatFee: types.MicroAlgos(3000),
+					FlatFee: setDrawFee(algokeno.DefaultGameConfig),
 				},
 			},
 			ExpectedGlobalState: map[string]string{
 				"numTickets": "2",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
 				"draw": "AQIDBAUG",
 				"1s": "0",
 				"1p": "10001",
+				"1g": "0",
 				"2s": "0",
 				"2p": "10002",
+				"2g": "0",
 				"3s": "0",
 				"3p": "10003",
+				"3g": "0",
 				"4s": "0",
 				"4p": "10004",
+				"4g": "0",
 				"5s": "0",
 				"5p": "10005",
+				"5g": "0",
 				"6s": "1",
 				"6p": "500000",
+				"6g": "0",
 			},
 			ExpectedInnerTxs: [][]InnerTx{
 				{
@@ -512,25 +598,34 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Claim",
+					Method: lotto.ClaimSignature,
 					FlatFee: types.MicroAlgos(2000),
 				},
 			},
 			ExpectedGlobalState: map[string]string{
 				"numTickets": "2",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
 				"draw": "AQIDBAUG",
 				"1s": "0",
 				"1p": "10001",
+				"1g": "0",
 				"2s": "0",
 				"2p": "10002",
+				"2g": "0",
 				"3s": "0",
 				"3p": "10003",
+				"3g": "0",
 				"4s": "0",
 				"4p": "10004",
+				"4g": "0",
 				"5s": "0",
 				"5p": "10005",
+				"5g": "0",
 				"6s": "1",
 				"6p": "500000",
+				"6g": "0",
 			},
 			ExpectTxBroadcastError: true,
 		},
@@ -540,25 +635,40 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 				TxAppCall{
 					AppID: appID,
 					Sender: acc1,
-					Method: "Claim",
+					Method: lotto.ClaimSignature,
 					FlatFee: types.MicroAlgos(2000),
 				},
 			},
+			ExpectedLocalState: map[types.Address]map[string]string{
+				acc1.Address: {
+					"wager": "0",
+					"commitment": "",
+				},
+			},
 			ExpectedGlobalState: map[string]string{
 				"numTickets": "2",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
 				"draw": "AQIDBAUG",
 				"1s": "0",
 				"1p": "10001",
+				"1g": "0",
 				"2s": "0",
 				"2p": "10002",
+				"2g": "0",
 				"3s": "0",
 				"3p": "10003",
+				"3g": "0",
 				"4s": "0",
 				"4p": "10004",
+				"4g": "0",
 				"5s": "0",
 				"5p": "10005",
-				"6s": "1",
-				"6p": "500000",
+				"5g": "0",
+				"6s": "0",
+				"6p": "0",
+				"6g": "0",
 			},
 			ExpectedInnerTxs: [][]InnerTx{
 				{
@@ -571,6 +681,18 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "calling claim from acc1 again fails",
+			Txs: []TxCreator{
+				TxAppCall{
+					AppID: appID,
+					Sender: acc1,
+					Method: lotto.ClaimSignature,
+					FlatFee: types.MicroAlgos(2000),
+				},
+			},
+			ExpectTxBroadcastError: true,
+		},
 	}
 
 	for _, test := range testCases {
@@ -593,7 +715,7 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 
 			if len(test.ExpectedGlobalState) > 0 {
 				globalState := getAppGlobalState(t, appID)
-				require.Equal(t, test.ExpectedGlobalState, globalState)
+				require.Equal(t, lottoGlobalState(algokeno.DefaultGameConfig, test.ExpectedGlobalState), globalState)
 			}
 
 			if len(test.ExpectedInnerTxs) > 0 {
@@ -605,9 +727,10 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 						pendingRes, _, err := algodCl.PendingTransactionInformation(txIDs[len(txIDs)-1]).Do(context.Background())
 						require.NoError(t, err)
 
-						require.Equal(t, len(expectedInnterTxs), len(pendingRes.InnerTxns))
+						innerTxns := innerTxnsWithoutAppCalls(pendingRes)
+						require.Equal(t, len(expectedInnterTxs), len(innerTxns))
 						for iTx, expected := range expectedInnterTxs {
-							actual := pendingRes.InnerTxns[iTx].Transaction.Txn
+							actual := innerTxns[iTx]
 							require.Equal(t, expected.Type, actual.Type)
 							require.Equal(t, expected.From, actual.Sender)
 							require.Equal(t, expected.To, actual.Receiver)
@@ -622,29 +745,20 @@ func TestContractWithSingleWinningTicket(t *testing.T) {
 
 func TestContractWithMultipleWinningTickets(t *testing.T) {
 
-	creator := crypto.GenerateAccount()
-	acc1 := crypto.GenerateAccount()
-	acc2 := crypto.GenerateAccount()
-	acc3 := crypto.GenerateAccount()
-	acc4 := crypto.GenerateAccount()
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	acc1 := fx.Account("acc1")
+	acc2 := fx.Account("acc2")
+	acc3 := fx.Account("acc3")
+	acc4 := fx.Account("acc4")
 
-	deployedAppIDs := fundAccountsAndDeployContracts(t, 2, creator, acc1, acc2, acc3, acc4)
+	deployedAppIDs := fundAccountsAndDeployContracts(t, fx, 2, creator, acc1, acc2, acc3, acc4)
 	require.Equal(t, 2, len(deployedAppIDs))
 	appID := deployedAppIDs[0]
 	appAddr := crypto.GetApplicationAddress(appID)
 	nextAppID := deployedAppIDs[1]
 	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
 
-	fmt.Println("deployed apps:")
-	fmt.Println(appID, appAddr)
-	fmt.Println(nextAppID, nextAppAddr)
-
-
-	fmt.Println("Creator:")
-	fmt.Println("addr:", creator.Address.String())
-	fmt.Println("pub key:", base64.StdEncoding.EncodeToString(creator.PublicKey))
-	fmt.Println("priv key:", base64.StdEncoding.EncodeToString(creator.PrivateKey))
-
 	testCases := []struct{
 		Name string
 		Txs []TxCreator
@@ -677,19 +791,19 @@ func TestContractWithMultipleWinningTickets(t *testing.T) {
 		{
 			Name: "acc1 commit",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc1,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc1,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 0, 5, 10, 15, 20, 25),
+						commitmentArg(t, 0, 5, 10, 15, 20, 25),
 					},
 				},
-				TxPayment{
-					From: acc1,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectedLocalState: map[types.Address]map[string]string{
 				acc1.Address: {
@@ -701,19 +815,19 @@ func TestContractWithMultipleWinningTickets(t *testing.T) {
 		{
 			Name: "acc2 commit",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc2,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 0, 10, 20, 30, 40, 50),
+						commitmentArg(t, 0, 10, 20, 30, 40, 50),
 					},
 				},
-				TxPayment{
-					From: acc2,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectedLocalState: map[types.Address]map[string]string{
 				acc2.Address: {
@@ -725,19 +839,19 @@ func TestContractWithMultipleWinningTickets(t *testing.T) {
 		{
 			Name: "acc3 commit",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc3,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc3,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 1, 10, 15, 25, 40, 50),
+						commitmentArg(t, 1, 10, 15, 25, 40, 50),
 					},
 				},
-				TxPayment{
-					From: acc3,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectedLocalState: map[types.Address]map[string]string{
 				acc3.Address: {
@@ -749,73 +863,80 @@ func TestContractWithMultipleWinningTickets(t *testing.T) {
 		{
 			Name: "acc4 commit",
 			Txs: []TxCreator{
+				TxPayment{
+					From: acc4,
+					To: appAddr,
+					Amount: 1000000,
+				},
 				TxAppCall{
 					AppID: appID,
 					Sender: acc4,
-					Method: "Commit",
+					Method: lotto.CommitSignature,
 					Args: [][]byte{
-						commitmentToBytes(t, 1, 10, 20, 25, 62, 63),
+						commitmentArg(t, 1, 10, 20, 25, 61, 62),
 					},
 				},
-				TxPayment{
-					From: acc4,
-					To: appAddr,
-					Amount: 1000000,
-				},
 			},
 			ExpectedLocalState: map[types.Address]map[string]string{
 				acc4.Address: {
 					"wager": "1000000",
-					"commitment": "AQoUGT4/",
+					"commitment": "AQoUGT0+",
 				},
 			},
 		},
 		{
-			Name: "creator sets draw succeeds - rollover amount not send because amount too small",
+			Name: "creator sets draw succeeds - rolls over empty tiers and the dust of the 3 number pool",
 			Txs: []TxCreator{
 				TxAppCall{
 					AppID: appID,
 					Sender: creator,
-					Method: "SetDraw",
-					Args: [][]byte{
+					Method: lotto.SetDrawSignature,
+					Args: setDrawArgs(
 						commitmentToBytes(t, 0, 10, 15, 20, 25, 63),
-						uint64ToBytes(t, 0), // 1s
-						uint64ToBytes(t, 0),
-						uint64ToBytes(t, 0), // 2s
-						uint64ToBytes(t, 10_001),
-						uint64ToBytes(t, 3), // 3s
-						uint64ToBytes(t, 30_002),
-						uint64ToBytes(t, 0), // 4s
-						uint64ToBytes(t, 60_004),
-						uint64ToBytes(t, 1), // 5s
-						uint64ToBytes(t, 500_000),
-						uint64ToBytes(t, 0), // 6s
-						uint64ToBytes(t, 1_000_000),
-					},
+						tiersOf(
+							0, 0, // 1s
+							0, 10_001, // 2s
+							3, 30_002, // 3s
+							0, 60_004, // 4s
+							1, 500_000, // 5s
+							0, 1_000_000, // 6s
+						),
+						nil,
+						0,
+					),
 					ForeignApps: []uint64{
 						nextAppID,
 					},
 					Accounts: []string{
 						nextAppAddr.String(),
 					},
-					FlatFee: types.MicroAlgos(3000),
+					FlatFee: setDrawFee(algokeno.DefaultGameConfig),
 				},
 			},
 			ExpectedGlobalState: map[string]string{
 				"numTickets": "4",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
 				"draw": "AAoPFBk/",
 				"1s": "0",
 				"1p": "0",
+				"1g": "0",
 				"2s": "0",
 				"2p": "10001",
+				"2g": "0",
 				"3s": "3",
-				"3p": "30002",
+				"3p": "30000",
+				"3g": "0",
 				"4s": "0",
 				"4p": "60004",
+				"4g": "0",
 				"5s": "1",
 				"5p": "500000",
+				"5g": "0",
 				"6s": "0",
 				"6p": "1000000",
+				"6g": "0",
 			},
 			ExpectedInnerTxs: [][]InnerTx{
 				{
@@ -829,18 +950,18 @@ func TestContractWithMultipleWinningTickets(t *testing.T) {
 						Type: types.PaymentTx,
 						From: appAddr,
 						To: nextAppAddr,
-						Amount: 1_070_005,
+						Amount: 1_070_007,
 					},
 				},
 			},
 		},
 		{
-			Name: "calling claim from acc2 succeeds - wins one third of 3 number pool rounded down",
+			Name: "calling claim from acc2 succeeds - wins a third of the 3 number pool",
 			Txs: []TxCreator{
 				TxAppCall{
 					AppID: appID,
 					Sender: acc2,
-					Method: "Claim",
+					Method: lotto.ClaimSignature,
 					FlatFee: types.MicroAlgos(2000),
 				},
 			},
@@ -852,94 +973,232 @@ func TestContractWithMultipleWinningTickets(t *testing.T) {
 			},
 			ExpectedGlobalState: map[string]string{
 				"numTickets": "4",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
 				"draw": "AAoPFBk/",
 				"1s": "0",
 				"1p": "0",
+				"1g": "0",
 				"2s": "0",
 				"2p": "10001",
+				"2g": "0",
 				"3s": "2",
-				"3p": "20002",
+				"3p": "20000",
+				"3g": "0",
 				"4s": "0",
 				"4p": "60004",
+				"4g": "0",
 				"5s": "1",
 				"5p": "500000",
+				"5g": "0",
 				"6s": "0",
 				"6p": "1000000",
+				"6g": "0",
 			},
 			ExpectedInnerTxs: [][]InnerTx{
 				{
 					{
 						Type: types.PaymentTx,
 						From: appAddr,
-						To: acc1.Address,
-						Amount: 10000,
+						To: acc2.Address,
+						Amount: 10_000,
 					},
 				},
 			},
 		},
-/*
 		{
-			Name: "calling claim from acc1 succeeds - wins 5 number pool",
+			Name: "calling claim from acc2 again fails",
 			Txs: []TxCreator{
 				TxAppCall{
 					AppID: appID,
-					Sender: acc1,
-					Method: "Claim",
+					Sender: acc2,
+					Method: lotto.ClaimSignature,
+					FlatFee: types.MicroAlgos(2000),
+				},
+			},
+			ExpectTxBroadcastError: true,
+		},
+		{
+			Name: "calling claim from acc3 succeeds - wins half of what's left of the 3 number pool",
+			Txs: []TxCreator{
+				TxAppCall{
+					AppID: appID,
+					Sender: acc3,
+					Method: lotto.ClaimSignature,
 					FlatFee: types.MicroAlgos(2000),
 				},
 			},
+			ExpectedLocalState: map[types.Address]map[string]string{
+				acc3.Address: {
+					"wager": "0",
+					"commitment": "",
+				},
+			},
 			ExpectedGlobalState: map[string]string{
-				"numTickets": "2",
-				"draw": "AQIDBAUG",
+				"numTickets": "4",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
+				"draw": "AAoPFBk/",
 				"1s": "0",
-				"1p": "10001",
+				"1p": "0",
+				"1g": "0",
 				"2s": "0",
-				"2p": "10002",
-				"3s": "0",
-				"3p": "10003",
+				"2p": "10001",
+				"2g": "0",
+				"3s": "1",
+				"3p": "10000",
+				"3g": "0",
 				"4s": "0",
-				"4p": "10004",
-				"5s": "0",
-				"5p": "10005",
-				"6s": "1",
-				"6p": "500000",
+				"4p": "60004",
+				"4g": "0",
+				"5s": "1",
+				"5p": "500000",
+				"5g": "0",
+				"6s": "0",
+				"6p": "1000000",
+				"6g": "0",
 			},
 			ExpectedInnerTxs: [][]InnerTx{
 				{
 					{
 						Type: types.PaymentTx,
 						From: appAddr,
-						To: acc1.Address,
-						Amount: 500000,
+						To: acc3.Address,
+						Amount: 10_000,
 					},
 				},
 			},
 		},
-*/
-	}
-
-	for _, test := range testCases {
-		t.Run(test.Name, func(t *testing.T) {
-
-			if test.ExpectTxBroadcastError {
-				requireTxBroadcastError(t, test.Txs...)
-				return
-			}
-
-			var txIDs []string
-			if len(test.Txs) > 0 {
-				txIDs = broadcastTxsAndWait(t, test.Txs...)
-			}
-
-			for addr, expectedLocalState := range test.ExpectedLocalState {
-				localState := getAppLocalState(t, appID, addr)
-				require.Equal(t, expectedLocalState, localState)
-			}
-
-			if len(test.ExpectedGlobalState) > 0 {
-				globalState := getAppGlobalState(t, appID)
-				require.Equal(t, test.ExpectedGlobalState, globalState)
-			}
+		{
+			Name: "calling claim from acc4 succeeds - wins the rest of the 3 number pool",
+			Txs: []TxCreator{
+				TxAppCall{
+					AppID: appID,
+					Sender: acc4,
+					Method: lotto.ClaimSignature,
+					FlatFee: types.MicroAlgos(2000),
+				},
+			},
+			ExpectedLocalState: map[types.Address]map[string]string{
+				acc4.Address: {
+					"wager": "0",
+					"commitment": "",
+				},
+			},
+			ExpectedGlobalState: map[string]string{
+				"numTickets": "4",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
+				"draw": "AAoPFBk/",
+				"1s": "0",
+				"1p": "0",
+				"1g": "0",
+				"2s": "0",
+				"2p": "10001",
+				"2g": "0",
+				"3s": "0",
+				"3p": "0",
+				"3g": "0",
+				"4s": "0",
+				"4p": "60004",
+				"4g": "0",
+				"5s": "1",
+				"5p": "500000",
+				"5g": "0",
+				"6s": "0",
+				"6p": "1000000",
+				"6g": "0",
+			},
+			ExpectedInnerTxs: [][]InnerTx{
+				{
+					{
+						Type: types.PaymentTx,
+						From: appAddr,
+						To: acc4.Address,
+						Amount: 10_000,
+					},
+				},
+			},
+		},
+		{
+			Name: "calling claim from acc1 succeeds - wins 5 number pool",
+			Txs: []TxCreator{
+				TxAppCall{
+					AppID: appID,
+					Sender: acc1,
+					Method: lotto.ClaimSignature,
+					FlatFee: types.MicroAlgos(2000),
+				},
+			},
+			ExpectedLocalState: map[types.Address]map[string]string{
+				acc1.Address: {
+					"wager": "0",
+					"commitment": "",
+				},
+			},
+			ExpectedGlobalState: map[string]string{
+				"numTickets": "4",
+				"numSealed": "0",
+				"revealBy": "0",
+				"sponsored": "0",
+				"draw": "AAoPFBk/",
+				"1s": "0",
+				"1p": "0",
+				"1g": "0",
+				"2s": "0",
+				"2p": "10001",
+				"2g": "0",
+				"3s": "0",
+				"3p": "0",
+				"3g": "0",
+				"4s": "0",
+				"4p": "60004",
+				"4g": "0",
+				"5s": "0",
+				"5p": "0",
+				"5g": "0",
+				"6s": "0",
+				"6p": "1000000",
+				"6g": "0",
+			},
+			ExpectedInnerTxs: [][]InnerTx{
+				{
+					{
+						Type: types.PaymentTx,
+						From: appAddr,
+						To: acc1.Address,
+						Amount: 500_000,
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			if test.ExpectTxBroadcastError {
+				requireTxBroadcastError(t, test.Txs...)
+				return
+			}
+
+			var txIDs []string
+			if len(test.Txs) > 0 {
+				txIDs = broadcastTxsAndWait(t, test.Txs...)
+			}
+
+			for addr, expectedLocalState := range test.ExpectedLocalState {
+				localState := getAppLocalState(t, appID, addr)
+				require.Equal(t, expectedLocalState, localState)
+			}
+
+			if len(test.ExpectedGlobalState) > 0 {
+				globalState := getAppGlobalState(t, appID)
+				require.Equal(t, lottoGlobalState(algokeno.DefaultGameConfig, test.ExpectedGlobalState), globalState)
+			}
 
 			if len(test.ExpectedInnerTxs) > 0 {
 				require.Equal(t, len(test.ExpectedInnerTxs), len(txIDs))
@@ -950,9 +1209,10 @@ func TestContractWithMultipleWinningTickets(t *testing.T) {
 						pendingRes, _, err := algodCl.PendingTransactionInformation(txIDs[len(txIDs)-1]).Do(context.Background())
 						require.NoError(t, err)
 
-						require.Equal(t, len(expectedInnterTxs), len(pendingRes.InnerTxns))
+						innerTxns := innerTxnsWithoutAppCalls(pendingRes)
+						require.Equal(t, len(expectedInnterTxs), len(innerTxns))
 						for iTx, expected := range expectedInnterTxs {
-							actual := pendingRes.InnerTxns[iTx].Transaction.Txn
+							actual := innerTxns[iTx]
 							require.Equal(t, expected.Type, actual.Type)
 							require.Equal(t, expected.From, actual.Sender)
 							require.Equal(t, expected.To, actual.Receiver)
@@ -965,87 +1225,204 @@ func TestContractWithMultipleWinningTickets(t *testing.T) {
 	}
 }
 
+// innerTxnsWithoutAppCalls returns the inner txns of res, skipping the app
+// calls set_draw makes to raise its opcode budget
+func innerTxnsWithoutAppCalls(res models.PendingTransactionInfoResponse) []types.Transaction {
+
+	var txns []types.Transaction
+	for _, inner := range res.InnerTxns {
+		if inner.Transaction.Txn.Type == types.ApplicationCallTx {
+			continue
+		}
+		txns = append(txns, inner.Transaction.Txn)
+	}
+	return txns
+}
+
 // TODO: Add test where there are many account have tickets so that the rollover amount is greater than the minimum account amount
 // and the rollover amount is send to the next contract, and all of the tickets claim their prizes
 
+// fundAccountsAndDeployContracts funds creator and accounts from the
+// dispenser, deploys numContracts apps from creator, and reclaims what's left
+// in the accounts when the test finishes
 func fundAccountsAndDeployContracts(
 	t *testing.T,
+	fx *fixture.Fixture,
 	numContracts int,
 	creator crypto.Account,
 	accounts ...crypto.Account,
 ) []uint64 {
 
-	kmdAcc := getKMDAccount(t)
+	accounts = append([]crypto.Account{creator}, accounts...)
+	fundAccounts(t, fx, accounts...)
+	reclaimAtCleanup(t, accounts...)
 
-	fundAmount := uint64(20_000_000)
+	return deployContracts(t, fx, numContracts, creator)
+}
 
-	var txs []TxCreator
-	txs = append(txs, TxPayment{
-		From: kmdAcc,
-		To: creator.Address,
-		Amount: fundAmount,
+// reclaimAtCleanup returns what's left in accounts to the dispenser when
+// the test finishes
+func reclaimAtCleanup(t *testing.T, accounts ...crypto.Account) {
+
+	t.Cleanup(func() {
+		err := testDispenser(t).Reclaim(context.Background(), accounts...)
+		if err != nil {
+			t.Log("reclaiming test account funds:", err)
+		}
 	})
+}
+
+// fundAccounts funds each account with -fund_amount from the dispenser
+func fundAccounts(t *testing.T, fx *fixture.Fixture, accounts ...crypto.Account) {
 
+	var addrs []types.Address
 	for _, acc := range accounts {
-		txs = append(txs, TxPayment{
-			From: kmdAcc,
-			To: acc.Address,
-			Amount: fundAmount,
+		addrs = append(addrs, acc.Address)
+	}
+
+	err := testDispenser(t).Fund(context.Background(), *fundAmount, addrs...)
+	require.NoError(t, err)
+
+	for _, addr := range addrs {
+		fx.AddFunding(addr, *fundAmount)
+	}
+}
+
+// appSource is the compiled TEAL and schema of one of the contracts
+type appSource struct {
+	ApprovalPath string
+	ClearPath string
+	SchemaPath string
+}
+
+var (
+	lottoApp = appSource{
+		ApprovalPath: "../contract/approval.teal",
+		ClearPath: "../contract/clear.teal",
+		SchemaPath: "../contract/schema.json",
+	}
+	kenoApp = appSource{
+		ApprovalPath: "../contract/keno_approval.teal",
+		ClearPath: "../contract/clear.teal",
+		SchemaPath: "../contract/keno_schema.json",
+	}
+)
+
+func deployContracts(t *testing.T, fx *fixture.Fixture, numContracts int, creator crypto.Account) []uint64 {
+
+	return deployLottos(t, fx, algokeno.DefaultGameConfig, numContracts, creator)
+}
+
+// deployLottos deploys numContracts lotto apps created with config
+func deployLottos(
+	t *testing.T,
+	fx *fixture.Fixture,
+	config algokeno.GameConfig,
+	numContracts int,
+	creator crypto.Account,
+) []uint64 {
+
+	var txs []TxCreator
+	for i:=0; i<numContracts; i++ {
+		txs = append(txs, TxLottoDeploy{
+			Creator: creator,
+			Config: config,
+			Note: uint64ToBytes(t, uint64(i)),
 		})
 	}
 
+	return deployTxs(t, fx, txs...)
+}
+
+func deployApps(t *testing.T, fx *fixture.Fixture, src appSource, numContracts int, creator crypto.Account) []uint64 {
+
+	var txs []TxCreator
 	for i:=0; i<numContracts; i++ {
 		txs = append(txs, TxAppDeploy{
 			Creator: creator,
-			ApprovalPath: "../contract/approval.teal",
-			ClearPath: "../contract/clear.teal",
-			SchemaPath: "../contract/schema.json",
+			ApprovalPath: src.ApprovalPath,
+			ClearPath: src.ClearPath,
+			SchemaPath: src.SchemaPath,
 			Note: uint64ToBytes(t, uint64(i)),
 		})
 	}
 
+	return deployTxs(t, fx, txs...)
+}
+
+// deployTxs broadcasts app creation txs and returns the IDs of the apps
+// they created
+func deployTxs(t *testing.T, fx *fixture.Fixture, txs ...TxCreator) []uint64 {
+
 	txIDs := broadcastTxsAndWait(t, txs...)
 
 	var deployedAppIDs []uint64
 	algodCl := algodClient(t)
-	for iRes:=0; iRes<numContracts; iRes++ {
-		pendingRes, _, err := algodCl.PendingTransactionInformation(
-			txIDs[len(txIDs)-(numContracts - iRes)],
-		).Do(context.Background())
+	for _, txID := range txIDs {
+		pendingRes, _, err := algodCl.PendingTransactionInformation(txID).Do(context.Background())
 		require.NoError(t, err)
 
 		deployedAppIDs = append(deployedAppIDs, pendingRes.ApplicationIndex)
 	}
+	fx.AddApps(deployedAppIDs...)
 
 	return deployedAppIDs
 }
 
+var (
+	fixtureSeed = flag.String("fixture_seed", "", "Seed test accounts are derived from, to replay a previous run (defaults to current time)")
+	fixtureDir = flag.String("fixture_dir", "testdata/fixtures", "Directory each test's fixture file is written to")
+	pokeFixture = flag.String("poke_fixture", "", "Fixture file of the app TestPokeExisting pokes")
+)
 
-func TestPokeExisting(t *testing.T) {
+// newFixture returns a fixture whose accounts are derived from the test
+// name and -fixture_seed, and saves it to -fixture_dir when the test
+// finishes if it deployed any apps, so tests failing during setup (e.g.
+// without a sandnet) don't write fixtures. Rerunning a test with the
+// logged seed derives the same accounts.
+func newFixture(t *testing.T) *fixture.Fixture {
 
-	addr, err := types.DecodeAddress("OCTAWZ77S7VISVSQREH5MONA4J5BU7SNHEL74XHVUGWNYC6U4NQGWANWTE")
-	require.NoError(t, err)
-	pubk, err := base64.StdEncoding.DecodeString("cKYLZ/+X6olWUIkP1jmg4noafk05F/5c9aGs3AvU42A=")
-	require.NoError(t, err)
-	privk, err := base64.StdEncoding.DecodeString("YPlwraDB/vC+XVtPmmXYydkzvJAbgr+wkGJ/TtntZidwpgtn/5fqiVZQiQ/WOaDiehp+TTkX/lz1oazcC9TjYA==")
-	require.NoError(t, err)
+	seed := *fixtureSeed
+	if seed == "" {
+		seed = strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+
+	fx := fixture.New(t.Name() + "/" + seed)
+	path := filepath.Join(*fixtureDir, strings.ReplaceAll(t.Name(), "/", "_") + ".json")
+	t.Logf("fixture seed: %s (saved to %s)", seed, path)
 
-	acc := crypto.Account{
-		Address: addr,
-		PublicKey: pubk,
-		PrivateKey: privk,
+	t.Cleanup(func() {
+		if len(fx.Apps) == 0 {
+			return
+		}
+		require.NoError(t, fx.Save(path))
+	})
+	return fx
+}
+
+// TestPokeExisting calls SetDraw on the first app of a previous test's
+// fixture, e.g. -poke_fixture=testdata/fixtures/TestContractWithSingleWinningTicket.json
+func TestPokeExisting(t *testing.T) {
+
+	if *pokeFixture == "" {
+		t.Skip("no -poke_fixture given")
 	}
 
-	appID := uint64(86)
+	fx, err := fixture.Load(*pokeFixture)
+	require.NoError(t, err)
+	require.NotEmpty(t, fx.Apps)
+
+	acc := fx.Account("creator")
+	appID := fx.Apps[0]
 
-	fmt.Println("app addr:", crypto.GetApplicationAddress(appID))
+	t.Log("app addr:", crypto.GetApplicationAddress(appID))
 
 	broadcastTxsAndWait(
 		t,
 		TxAppCall{
 			AppID: appID,
 			Sender: acc,
-			Method: "SetDraw",
+			Method: lotto.SetDrawSignature,
 			Args: [][]byte{
 				b64StrToBytes(t, "abcdefA="),
 				uint64ToBytes(t, 6), // 1s
@@ -1068,79 +1445,113 @@ func TestPokeExisting(t *testing.T) {
 		t,
 		map[string]string{
 			"owner": base64.StdEncoding.EncodeToString(acc.PublicKey),
+			"picks": "6",
+			"maxNumber": "64",
+			"price": "1000000",
+			"feeBps": "1000",
+			"rolloverMin": "100000",
 			"numTickets": "1",
+			"numSealed": "0",
+			"revealBy": "0",
+			"sponsored": "0",
 			"draw": "abcdefA=",
 			"1s": "6",
 			"1p": "61",
+			"1g": "0",
 			"2s": "5",
 			"2p": "51",
+			"2g": "0",
 			"3s": "4",
 			"3p": "41",
+			"3g": "0",
 			"4s": "3",
 			"4p": "31",
+			"4g": "0",
 			"5s": "2",
 			"5p": "21",
+			"5g": "0",
 			"6s": "1",
 			"6p": "500000",
+			"6g": "0",
 		},
 		getAppGlobalState(t, appID),
 	)
 }
 
-func algodClient(t *testing.T) *algod.Client {
-
-	token, err := os.ReadFile(*algodTokenPath)
-	require.NoError(t, err)
+var clientsOnce struct {
+	sync.Once
+	c *client.Context
+	err error
+}
 
-	c, err := algod.MakeClient(*algodHost, string(token))
-	require.NoError(t, err)
+// testClients returns the clients shared by all tests in the binary, so
+// the token files are read and the clients created only once
+func testClients(t *testing.T) *client.Context {
 
-	return c
-}
+	clientsOnce.Do(func() {
+		algodToken, err := os.ReadFile(*algodTokenPath)
+		if err != nil {
+			clientsOnce.err = err
+			return
+		}
+		kmdToken, err := os.ReadFile(*kmdTokenPath)
+		if err != nil {
+			clientsOnce.err = err
+			return
+		}
 
-func kmdClient(t *testing.T) kmd.Client {
+		clientsOnce.c, clientsOnce.err = client.New(client.Config{
+			AlgodHost: *algodHost,
+			AlgodToken: string(algodToken),
+			KMDHost: *kmdHost,
+			KMDToken: string(kmdToken),
+			// TODO: Figure out if we need to pass a token to the indexer client here
+			IndexerHost: *indexerHost,
+			ParamsTTL: *paramsTTL,
+		})
+	})
+	require.NoError(t, clientsOnce.err)
 
-	token, err := os.ReadFile(*kmdTokenPath)
-	require.NoError(t, err)
+	return clientsOnce.c
+}
 
-	c, err := kmd.MakeClient(*kmdHost, string(token))
-	require.NoError(t, err)
+func algodClient(t *testing.T) *algod.Client {
 
-	return c
+	return testClients(t).Algod
 }
 
 func indexerClient(t *testing.T) *indexer.Client {
 
-	// TODO: Figure out if we need to pass a token to the indexer client here
-	c, err := indexer.MakeClient(*indexerHost, "")
-	require.NoError(t, err)
-
-	return c
+	return testClients(t).Indexer
 }
 
-// getKMDAccount returns the Account (i.e. Priv+Pub keys) of the first account in KMD
-func getKMDAccount(t *testing.T) crypto.Account {
+// suggestedParams returns the shared clients' cached suggested params
+func suggestedParams(t *testing.T) types.SuggestedParams {
 
-	kmd := kmdClient(t)
-	w, err := kmd.ListWallets()
+	params, err := testClients(t).SuggestedParams(context.Background())
 	require.NoError(t, err)
-	require.Greater(t, len(w.Wallets), 0)
 
-	walletPassword := ""
-	handleResp, err := kmd.InitWalletHandle(w.Wallets[0].ID, walletPassword)
-	require.NoError(t, err)
-	handle := handleResp.WalletHandleToken
+	return params
+}
 
-	keys, err := kmd.ListKeys(handle)
-	require.NoError(t, err)
-	require.Greater(t, len(keys.Addresses), 0)
+var dispenserOnce struct {
+	sync.Once
+	d *dispenser.Dispenser
+	err error
+}
 
-	res, err := kmd.ExportKey(handle, walletPassword, keys.Addresses[0])
-	require.NoError(t, err)
-	kmdAccount, err := crypto.AccountFromPrivateKey(res.PrivateKey)
-	require.NoError(t, err)
+// testDispenser returns the dispenser shared by all tests in the binary,
+// which funds from every account in KMD
+func testDispenser(t *testing.T) *dispenser.Dispenser {
 
-	return kmdAccount
+	dispenserOnce.Do(func() {
+		config := dispenser.DefaultConfig
+		config.DefaultAmount = *fundAmount
+		dispenserOnce.d, dispenserOnce.err = dispenser.New(testClients(t), "", config)
+	})
+	require.NoError(t, dispenserOnce.err)
+
+	return dispenserOnce.d
 }
 
 type TealSchema struct {
@@ -1174,11 +1585,34 @@ type TxCreator interface {
 	Create(t *testing.T) (future.TransactionWithSigner)
 }
 
+// TxLottoDeploy creates a lotto app with the game parameters in Config,
+// which is validated before the tx is built
+type TxLottoDeploy struct {
+	Creator crypto.Account
+	Config algokeno.GameConfig
+	Note []byte
+}
+
+func (c TxLottoDeploy) Create(t *testing.T) (future.TransactionWithSigner) {
+
+	require.NoError(t, c.Config.Validate())
+
+	return TxAppDeploy{
+		Creator: c.Creator,
+		ApprovalPath: lottoApp.ApprovalPath,
+		ClearPath: lottoApp.ClearPath,
+		SchemaPath: lottoApp.SchemaPath,
+		AppArgs: c.Config.CreateArgs(),
+		Note: c.Note,
+	}.Create(t)
+}
+
 type TxAppDeploy struct {
 	Creator crypto.Account
 	ApprovalPath string
 	ClearPath string
 	SchemaPath string
+	AppArgs [][]byte
 	Note []byte
 }
 
@@ -1192,6 +1626,7 @@ func (c TxAppDeploy) Create(t *testing.T) (future.TransactionWithSigner) {
 		GlobalByteSlices: s.GlobalByteSlices,
 		LocalUints: s.LocalUints,
 		LocalByteSlices: s.LocalByteSlices,
+		AppArgs: c.AppArgs,
 		Note: c.Note,
 		Creator: c.Creator,
 	}
@@ -1220,9 +1655,7 @@ type TxAppCreate struct {
 
 func (c TxAppCreate) Create(t *testing.T) (future.TransactionWithSigner) {
 
-	algod := algodClient(t)
-	txParams, err := algod.SuggestedParams().Do(context.Background())
-	require.NoError(t, err)
+	txParams := suggestedParams(t)
 
 	tx, err := future.MakeApplicationCreateTx(
 		c.OptIn,
@@ -1272,9 +1705,7 @@ type TxAppOptIn struct {
 
 func (c TxAppOptIn) Create(t *testing.T) (future.TransactionWithSigner) {
 
-	algod := algodClient(t)
-	txParams, err := algod.SuggestedParams().Do(context.Background())
-	require.NoError(t, err)
+	txParams := suggestedParams(t)
 
 	var appArgs [][]byte
 	for _, arg := range c.Args {
@@ -1306,7 +1737,14 @@ func (c TxAppOptIn) Create(t *testing.T) (future.TransactionWithSigner) {
 
 type TxAppCall struct {
 	AppID uint64
+
+	// Method is the signature of the ARC-4 method called, whose selector is
+	// the first app arg, or for apps without ARC-4 methods (keno) the name
+	// passed as the first app arg
 	Method string
+
+	// Args are the app args following the method, already ABI encoded for
+	// ARC-4 methods
 	Args [][]byte
 	Accounts []string
 	ForeignApps []uint64
@@ -1323,12 +1761,16 @@ type TxAppCall struct {
 
 func (c TxAppCall) Create(t *testing.T) (future.TransactionWithSigner) {
 
-	algod := algodClient(t)
-	txParams, err := algod.SuggestedParams().Do(context.Background())
-	require.NoError(t, err)
+	txParams := suggestedParams(t)
 
 	var appArgs [][]byte
-	appArgs = append(appArgs, []byte(c.Method))
+	if strings.Contains(c.Method, "(") {
+		method, err := abi.MethodFromSignature(c.Method)
+		require.NoError(t, err)
+		appArgs = append(appArgs, method.GetSelector())
+	} else {
+		appArgs = append(appArgs, []byte(c.Method))
+	}
 	for _, arg := range c.Args {
 		appArgs = append(appArgs, []byte(arg))
 	}
@@ -1371,9 +1813,7 @@ type TxPayment struct {
 
 func (c TxPayment) Create(t *testing.T) (future.TransactionWithSigner) {
 
-	algod := algodClient(t)
-	txParams, err := algod.SuggestedParams().Do(context.Background())
-	require.NoError(t, err)
+	txParams := suggestedParams(t)
 
 	tx, err := future.MakePaymentTxn(
 		c.From.Address.String(),
@@ -1393,8 +1833,123 @@ func (c TxPayment) Create(t *testing.T) (future.TransactionWithSigner) {
 	}
 }
 
+type TxAssetCreate struct {
+	Creator crypto.Account // Tx signer will be the creator, who holds the whole supply
+	Total uint64
+	UnitName string
+	AssetName string
+	Note []byte
+}
+
+func (c TxAssetCreate) Create(t *testing.T) (future.TransactionWithSigner) {
+
+	txParams := suggestedParams(t)
+
+	tx, err := future.MakeAssetCreateTxn(
+		c.Creator.Address.String(),
+		c.Note,
+		txParams,
+		c.Total,
+		0,
+		false,
+		c.Creator.Address.String(),
+		"",
+		"",
+		"",
+		c.UnitName,
+		c.AssetName,
+		"",
+		"",
+	)
+	require.NoError(t, err)
+
+	return future.TransactionWithSigner{
+		Txn: tx,
+		Signer: future.BasicAccountTransactionSigner{
+			Account: c.Creator,
+		},
+	}
+}
+
+type TxAssetOptIn struct {
+	AssetID uint64
+	Sender crypto.Account // Tx sender will be the signer of this tx
+}
+
+func (c TxAssetOptIn) Create(t *testing.T) (future.TransactionWithSigner) {
+
+	txParams := suggestedParams(t)
+
+	tx, err := future.MakeAssetAcceptanceTxn(
+		c.Sender.Address.String(),
+		nil,
+		txParams,
+		c.AssetID,
+	)
+	require.NoError(t, err)
+
+	return future.TransactionWithSigner{
+		Txn: tx,
+		Signer: future.BasicAccountTransactionSigner{
+			Account: c.Sender,
+		},
+	}
+}
+
+type TxAssetTransfer struct {
+	AssetID uint64
+	From crypto.Account // Tx signer will be the from account
+	To types.Address
+	Amount uint64
+	Note string
+	CloseAssetsTo string
+}
+
+func (c TxAssetTransfer) Create(t *testing.T) (future.TransactionWithSigner) {
+
+	txParams := suggestedParams(t)
+
+	tx, err := future.MakeAssetTransferTxn(
+		c.From.Address.String(),
+		c.To.String(),
+		c.Amount,
+		[]byte(c.Note),
+		txParams,
+		c.CloseAssetsTo,
+		c.AssetID,
+	)
+	require.NoError(t, err)
+
+	return future.TransactionWithSigner{
+		Txn: tx,
+		Signer: future.BasicAccountTransactionSigner{
+			Account: c.From,
+		},
+	}
+}
+
 func broadcastTxsAndWait(t *testing.T, txs ...TxCreator) []string {
 
+	txIDs, err := broadcastTxs(t, txs...)
+	require.NoError(t, err)
+	require.Equal(t, len(txs), len(txIDs))
+	return txIDs
+}
+
+func requireTxBroadcastError(t *testing.T, txs ...TxCreator) {
+
+	_, err := broadcastTxs(t, txs...)
+	require.Error(t, err)
+}
+
+// broadcastTxs broadcasts txs as a single group and waits for it to be
+// confirmed, returning any error from the node rather than failing the test
+func broadcastTxs(t *testing.T, txs ...TxCreator) ([]string, error) {
+
+	if tealCoverageEnabled() {
+		recordTealCoverage(t, txs...)
+	}
+
 	var txGroupBuilder future.AtomicTransactionComposer
 	for _, tx := range txs {
 		txGroupBuilder.AddTransaction(
@@ -1403,22 +1958,104 @@ func broadcastTxsAndWait(t *testing.T, txs ...TxCreator) []string {
 	}
 	algod := algodClient(t)
 	execRes, err := txGroupBuilder.Execute(algod, context.Background(), 2)
-	require.NoError(t, err)
-	require.Equal(t, len(txs), len(execRes.TxIDs))
-	return execRes.TxIDs
+	if err != nil {
+		return nil, err
+	}
+	recordConfirmedRound(execRes.ConfirmedRound)
+
+	// The round has moved on, so refresh the params for the next group to
+	// keep identical transactions sent in later groups from clashing
+	testClients(t).InvalidateParams()
+	return execRes.TxIDs, nil
 }
 
-func requireTxBroadcastError(t *testing.T, txs ...TxCreator) {
+// lastConfirmedRound is the latest round any group broadcast by the tests
+// was confirmed in
+var lastConfirmedRound uint64
 
-	var txGroupBuilder future.AtomicTransactionComposer
+func recordConfirmedRound(round uint64) {
+
+	for {
+		last := atomic.LoadUint64(&lastConfirmedRound)
+		if round <= last || atomic.CompareAndSwapUint64(&lastConfirmedRound, last, round) {
+			return
+		}
+	}
+}
+
+// waitForIndexer waits until the indexer has processed every group the tests
+// have broadcast so far. Call it before querying the indexer.
+func waitForIndexer(t *testing.T) uint64 {
+
+	round := atomic.LoadUint64(&lastConfirmedRound)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, settlement.WaitForIndexerRound(ctx, indexerClient(t), round))
+
+	return round
+}
+
+// dryrunTxs signs txs as a single group and runs it through algod's dryrun
+// endpoint against the current ledger state, without broadcasting it
+func dryrunTxs(t *testing.T, txs ...TxCreator) models.DryrunResponse {
+
+	var (
+		group []future.TransactionWithSigner
+		txns []types.Transaction
+	)
 	for _, tx := range txs {
-		txGroupBuilder.AddTransaction(
-			tx.Create(t),
-		)
+		txWithSigner := tx.Create(t)
+		group = append(group, txWithSigner)
+		txns = append(txns, txWithSigner.Txn)
 	}
-	algod := algodClient(t)
-	_, err := txGroupBuilder.Execute(algod, context.Background(), 2)
-	require.Error(t, err)
+
+	if len(txns) > 1 {
+		gid, err := crypto.ComputeGroupID(txns)
+		require.NoError(t, err)
+		for i := range txns {
+			txns[i].Group = gid
+		}
+	}
+
+	var stxns []types.SignedTxn
+	for i, txWithSigner := range group {
+		signed, err := txWithSigner.Signer.SignTransactions(txns, []int{i})
+		require.NoError(t, err)
+		var stxn types.SignedTxn
+		require.NoError(t, msgpack.Decode(signed[0], &stxn))
+		stxns = append(stxns, stxn)
+	}
+
+	algodCl := algodClient(t)
+	req, err := future.CreateDryrun(algodCl, stxns, nil, context.Background())
+	require.NoError(t, err)
+	res, err := algodCl.TealDryrun(req).Do(context.Background())
+	require.NoError(t, err)
+	return res
+}
+
+// dryrunPassed returns whether every app call in a dryrun was approved
+func dryrunPassed(res models.DryrunResponse) bool {
+
+	if res.Error != "" {
+		return false
+	}
+	for _, txn := range res.Txns {
+		if len(txn.AppCallMessages) == 0 {
+			continue
+		}
+		var passed bool
+		for _, msg := range txn.AppCallMessages {
+			if msg == "PASS" {
+				passed = true
+			}
+		}
+		if !passed {
+			return false
+		}
+	}
+	return true
 }
 
 func getAppLocalState(t *testing.T, appID uint64, address types.Address) map[string]string {
@@ -1429,6 +2066,17 @@ func getAppLocalState(t *testing.T, appID uint64, address types.Address) map[str
 	return getAppStateAsMap(t, accAppInfo.AppLocalState.KeyValue)
 }
 
+// lottoGlobalState returns expected along with the global state keys holding
+// config, which every lotto app has
+func lottoGlobalState(config algokeno.GameConfig, expected map[string]string) map[string]string {
+
+	state := model.ConfigState(config)
+	for k, v := range expected {
+		state[k] = v
+	}
+	return state
+}
+
 func getAppGlobalState(t *testing.T, appID uint64) map[string]string {
 
 	algodCl := algodClient(t)
@@ -1439,25 +2087,50 @@ func getAppGlobalState(t *testing.T, appID uint64) map[string]string {
 
 func getAppStateAsMap(t *testing.T, state []models.TealKeyValue) map[string]string {
 
-	stateMap := make(map[string]string)
-	for _, kv := range state {
+	stateMap, err := model.DecodeState(state)
+	require.NoError(t, err)
+	return stateMap
+}
 
-		key, err := base64.StdEncoding.DecodeString(kv.Key)
-		require.NoError(t, err)
+// setDrawArgs returns the set_draw args following the method selector: the
+// ABI encoded draw and tiers, then the next app as the first foreign app, its
+// address as the first foreign account and a beneficiary for each of the
+// numBeneficiaries accounts following it (the sender for the rest)
+func setDrawArgs(draw algokeno.Commitment, tiers, bonusTiers []model.Tier, numBeneficiaries int) [][]byte {
 
-		var strVal string
-		if kv.Value.Type == 1 {
-			strVal = string(kv.Value.Bytes)
-		} else if kv.Value.Type == 2 {
-			strVal = strconv.FormatUint(kv.Value.Uint, 10)
+	args := append(model.SetDrawArgs(draw, tiers, bonusTiers), []byte{1}, []byte{1})
+	for i := 0; i < algokeno.MaxBeneficiaries; i++ {
+		if i < numBeneficiaries {
+			args = append(args, []byte{byte(2+i)})
 		} else {
-			require.Fail(t, "unknown type")
+			args = append(args, []byte{0})
 		}
+	}
+	return args
+}
+
+// setDrawFee returns the fee set_draw is sent with in an app created with
+// config, which covers its inner txns
+func setDrawFee(config algokeno.GameConfig) types.MicroAlgos {
+
+	return types.MicroAlgos(config.SetDrawFee(future.MinTxnFee))
+}
+
+// tiersOf returns the tiers of each (winners, prize) pair in pairs
+func tiersOf(pairs ...uint64) []model.Tier {
 
-		stateMap[string(key)] = strVal
+	var tiers []model.Tier
+	for i := 0; i+1 < len(pairs); i += 2 {
+		tiers = append(tiers, model.Tier{Winners: pairs[i], Prize: pairs[i+1]})
 	}
+	return tiers
+}
 
-	return stateMap
+// commitmentArg returns the ABI byte[] encoding of commitment, as commit
+// takes its ticket
+func commitmentArg(t *testing.T, commitment ...int8) []byte {
+
+	return model.BytesArg(commitmentToBytes(t, commitment...))
 }
 
 func commitmentToBytes(t *testing.T, commitment ...int8) []byte {
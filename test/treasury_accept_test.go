@@ -0,0 +1,107 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neurotempest/algokeno"
+	"github.com/neurotempest/algokeno/lotto"
+	"github.com/neurotempest/algokeno/model"
+	"github.com/neurotempest/algokeno/settlement"
+)
+
+// TestTreasury plays a round of a lotto app whose house fee is split
+// between a treasury of beneficiaries rather than paid to the creator
+func TestTreasury(t *testing.T) {
+
+	fx := newFixture(t)
+	creator := fx.Account("creator")
+	player := fx.Account("player")
+	ops := fx.Account("ops")
+	charity := fx.Account("charity")
+
+	accounts := []crypto.Account{creator, player, ops, charity}
+	fundAccounts(t, fx, accounts...)
+	reclaimAtCleanup(t, accounts...)
+
+	config := algokeno.DefaultGameConfig
+	config.Treasury = []algokeno.Beneficiary{
+		{Address: ops.Address, ShareBps: 3_333},
+		{Address: charity.Address, ShareBps: 6_667},
+	}
+
+	// The contract rejects treasuries whose shares don't add up to the fee
+	invalid := config
+	invalid.Treasury = []algokeno.Beneficiary{config.Treasury[0]}
+	require.ErrorIs(t, invalid.Validate(), algokeno.ErrConfigTreasury)
+	requireTxBroadcastError(t, TxLottoDeploy{Creator: creator, Config: invalid})
+
+	deployedAppIDs := deployLottos(t, fx, config, 2, creator)
+	appID := deployedAppIDs[0]
+	appAddr := crypto.GetApplicationAddress(appID)
+	nextAppID := deployedAppIDs[1]
+	nextAppAddr := crypto.GetApplicationAddress(nextAppID)
+
+	l := model.NewWithConfig(creator.Address, config)
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+
+	decoded, err := model.ConfigFromState(getAppGlobalState(t, appID))
+	require.NoError(t, err)
+	require.Equal(t, config, decoded)
+
+	draw := algokeno.Commitment{1, 2, 3, 4, 5, 6}
+	broadcastTxsAndWait(t, TxAppOptIn{AppID: appID, Sender: player})
+	require.NoError(t, l.OptIn(player.Address))
+	broadcastTxsAndWait(
+		t,
+		TxPayment{
+			From: player,
+			To: appAddr,
+			Amount: 1_000_001,
+		},
+		TxAppCall{
+			AppID: appID,
+			Sender: player,
+			Method: lotto.CommitSignature,
+			Args: [][]byte{model.BytesArg(draw)},
+		},
+	)
+	require.NoError(t, l.Commit(player.Address, draw, 1_000_001))
+
+	tiers := make([]model.Tier, algokeno.NumPicks)
+	tiers[algokeno.NumPicks-1] = model.Tier{Winners: 1, Prize: 500_000}
+	require.NoError(t, settlement.CheckSolvency(config, l.Escrow, l.Sponsored, tiers))
+
+	setDraw := func(fee types.MicroAlgos) TxAppCall {
+		return TxAppCall{
+			AppID: appID,
+			Sender: creator,
+			Method: lotto.SetDrawSignature,
+			Args: setDrawArgs(draw, tiers, nil, 2),
+			ForeignApps: []uint64{
+				nextAppID,
+			},
+			Accounts: []string{
+				nextAppAddr.String(),
+				ops.Address.String(),
+				charity.Address.String(),
+			},
+			FlatFee: fee,
+		}
+	}
+
+	// The fee has to cover an inner txn per beneficiary, the rollover and
+	// the app calls for the opcode budget
+	requireTxBroadcastError(t, setDraw(setDrawFee(config)-1000))
+
+	expectedPayments, err := l.SetDraw(creator.Address, draw, tiers, nextAppAddr)
+	require.NoError(t, err)
+	require.Equal(t, ops.Address, expectedPayments[0].To)
+	require.Equal(t, charity.Address, expectedPayments[1].To)
+	txIDs := broadcastTxsAndWait(t, setDraw(setDrawFee(config)))
+	require.Equal(t, expectedPayments, innerPayments(t, txIDs[0]))
+	require.Equal(t, l.GlobalState(), getAppGlobalState(t, appID))
+}